@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+// prefetchKey identifies a text of a message sent for translation
+type prefetchKey struct {
+	messageID      string
+	text           string
+	pluralCategory string
+}
+
+// requestRecorder is a translator that records the requests and returns their text unchanged.
+// It is used to collect the texts of messages exactly as translateText sends them.
+type requestRecorder struct {
+	requests []translator.Request
+}
+
+// Translate records the request and returns its text
+func (r *requestRecorder) Translate(_ context.Context, req translator.Request) (string, error) {
+	r.requests = append(r.requests, req)
+	return req.Text, nil
+}
+
+// prefetchedTranslator serves translation results fetched in batches ahead of time. Every result is
+// served once, so retries and texts missing from the batches go to the wrapped translator.
+type prefetchedTranslator struct {
+	translator.Translator
+	mu      sync.Mutex
+	results map[prefetchKey]translator.Result
+}
+
+// Translate returns the prefetched result of the request or translates it with the wrapped translator
+func (p *prefetchedTranslator) Translate(ctx context.Context, req translator.Request) (string, error) {
+	key := prefetchKey{messageID: req.MessageID, text: req.Text, pluralCategory: req.PluralCategory}
+
+	p.mu.Lock()
+	res, ok := p.results[key]
+	delete(p.results, key)
+	p.mu.Unlock()
+
+	if ok {
+		return res.Translation, res.Err
+	}
+
+	return p.Translator.Translate(ctx, req)
+}
+
+// prefetchTranslations translates the texts of the pending messages in batches when the translator
+// supports it. The returned translator serves the prefetched translations and falls back to trans
+// for everything else. If batching is not possible trans is returned as is.
+func prefetchTranslations(ctx context.Context, trans translator.Translator, messages []GotextMessage, pending []int, reqs []translator.Request) translator.Translator {
+	if _, ok := trans.(translator.BatchTranslator); !ok || globalArgs.BatchSize < 2 || len(pending) < 2 {
+		return trans
+	}
+
+	recorder := &requestRecorder{}
+	for i, idx := range pending {
+		if _, err := translateText(ctx, recorder, messages[idx].Message, messages[idx].Placeholders, reqs[i]); err != nil {
+			slog.Debug("failed to collect texts for batch translation",
+				slog.String("id", messages[idx].ID),
+				slog.String("error", err.Error()))
+		}
+	}
+
+	opts := translator.BatchOptions{
+		MaxItems:    globalArgs.BatchSize,
+		MaxTokens:   globalArgs.BatchMaxTokens,
+		Concurrency: globalArgs.Concurrency,
+	}
+
+	results := translator.TranslateAll(ctx, trans, recorder.requests, opts)
+
+	prefetched := &prefetchedTranslator{
+		Translator: trans,
+		results:    make(map[prefetchKey]translator.Result, len(results)),
+	}
+
+	for i, res := range results {
+		req := recorder.requests[i]
+		key := prefetchKey{messageID: req.MessageID, text: req.Text, pluralCategory: req.PluralCategory}
+		prefetched.results[key] = res
+	}
+
+	return prefetched
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTranslateMessages_Batch(t *testing.T) {
+	globalArgs = &args{BatchSize: 10}
+
+	messages := []GotextMessage{
+		{ID: "Unknown command", Message: Text{Msg: "Unknown command"}},
+		{ID: "Please, provide at least one photo", Message: Text{Msg: "Please, provide at least one photo"}},
+		{
+			ID:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			Message: Text{Msg: "Please, provide no more than {MaxAllowedPhotos} photo(s)"},
+			Placeholders: []Placeholder{
+				{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", ArgNum: 1},
+			},
+		},
+	}
+
+	mockTranslator := mocks.NewBatchTranslator(t)
+	mockTranslator.On("TranslateBatch", mock.Anything, mock.MatchedBy(func(reqs []translator.Request) bool {
+		return len(reqs) == 3 && reqs[0].Text == "Unknown command"
+	})).Return([]string{
+		"Неизвестная команда",
+		"", // missing from the batch response
+		"Пожалуйста, отправьте не более фото", // broken placeholder
+	}, nil).Once()
+
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Please, provide at least one photo", "ru-RU")).
+		Return("Пожалуйста, отправьте хотя бы одно фото", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Please, provide no more than {MaxAllowedPhotos} photo(s)", "ru-RU")).
+		Return("Пожалуйста, отправьте не более {MaxAllowedPhotos} фото", nil).Once()
+
+	count := translateMessages(context.Background(), mockTranslator, messages, []int{0, 1, 2}, "en-GB", "ru-RU")
+	assert.Equal(t, 3, count)
+
+	assert.Equal(t, "Неизвестная команда", messages[0].Translation.Msg)
+	assert.Equal(t, "Пожалуйста, отправьте хотя бы одно фото", messages[1].Translation.Msg)
+	assert.Equal(t, "Пожалуйста, отправьте не более {MaxAllowedPhotos} фото", messages[2].Translation.Msg)
+}
+
+func TestTranslateMessages_BatchDisabled(t *testing.T) {
+	globalArgs = &args{BatchSize: 1}
+
+	messages := []GotextMessage{
+		{ID: "Unknown command", Message: Text{Msg: "Unknown command"}},
+		{ID: "Please, provide at least one photo", Message: Text{Msg: "Please, provide at least one photo"}},
+	}
+
+	mockTranslator := mocks.NewBatchTranslator(t)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Unknown command", "ru-RU")).
+		Return("Неизвестная команда", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Please, provide at least one photo", "ru-RU")).
+		Return("Пожалуйста, отправьте хотя бы одно фото", nil).Once()
+
+	count := translateMessages(context.Background(), mockTranslator, messages, []int{0, 1}, "en-GB", "ru-RU")
+	assert.Equal(t, 2, count)
+	mockTranslator.AssertNotCalled(t, "TranslateBatch", mock.Anything, mock.Anything)
+}
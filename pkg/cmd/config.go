@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/ksysoev/gotext-translator/pkg/translator"
 	"github.com/spf13/viper"
 )
 
@@ -12,10 +16,200 @@ type LLMConfig struct {
 	APIKey   string            `mapstructure:"api_key"`
 	Model    string            `mapstructure:"model"`
 	Options  map[string]string `mapstructure:"options"`
+	// Command is the program and the arguments of the exec provider
+	Command []string `mapstructure:"command"`
+	// RequestsPerMinute and TokensPerMinute are the client side rate limits, 0 means no limit. Chains and
+	// ensembles have no limits of their own, every provider and the judge is limited separately.
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	TokensPerMinute   int `mapstructure:"tokens_per_minute"`
+	// Providers are the providers of the chain provider, tried in order, or of the ensemble provider
+	Providers []LLMConfig `mapstructure:"providers"`
+	// Judge is the provider choosing the best translation of an ensemble, nil selects by agreement
+	Judge *LLMConfig `mapstructure:"judge"`
+}
+
+// translatorConfig returns the config of the translator created by the provider
+func (c *LLMConfig) translatorConfig() map[string]interface{} {
+	config := map[string]interface{}{
+		"api_key": c.APIKey,
+		"model":   c.Model,
+	}
+
+	// Add any additional options from config
+	for k, v := range c.Options {
+		config[k] = v
+	}
+
+	if len(c.Command) > 0 {
+		config["command"] = c.Command
+	}
+
+	if c.RequestsPerMinute > 0 {
+		config["requests_per_minute"] = c.RequestsPerMinute
+	}
+
+	if c.TokensPerMinute > 0 {
+		config["tokens_per_minute"] = c.TokensPerMinute
+	}
+
+	if len(c.Providers) > 0 {
+		providers := make([]map[string]interface{}, 0, len(c.Providers))
+
+		for i := range c.Providers {
+			p := c.Providers[i].translatorConfig()
+			p["provider"] = c.Providers[i].Provider
+			providers = append(providers, p)
+		}
+
+		config["providers"] = providers
+	}
+
+	if c.Judge != nil {
+		judge := c.Judge.translatorConfig()
+		judge["provider"] = c.Judge.Provider
+		config["judge"] = judge
+	}
+
+	return config
+}
+
+// runtimeOptions are the provider options that do not change the translations, like credentials,
+// retries and connection settings. They are left out of the cache key.
+var runtimeOptions = map[string]bool{
+	"max_attempts":         true,
+	"retry_base_delay":     true,
+	"retry_max_delay":      true,
+	"retry_max_elapsed":    true,
+	"timeout":              true,
+	"organization":         true,
+	"project":              true,
+	"bearer_token":         true,
+	"ca_file":              true,
+	"cert_file":            true,
+	"key_file":             true,
+	"proxy_url":            true,
+	"insecure_skip_verify": true,
+	"keep_alive":           true,
+}
+
+// cacheModel returns the model part of the cache key, chains and ensembles are identified by their
+// providers and models. Providers without a configured model are identified by their default model,
+// so a changed default does not reuse the translations of the previous one. The options changing the
+// translations, like the deployment of Azure OpenAI or the formality of DeepL, and the command of the
+// exec provider are added as a digest, e.g. "gpt-4o#1f0c9a7e5b3d2c48".
+func (c *LLMConfig) cacheModel() string {
+	if len(c.Providers) == 0 {
+		model := c.Model
+		if model == "" {
+			model = translator.DefaultModel(c.Provider)
+		}
+
+		if digest := c.optionsDigest(); digest != "" {
+			return model + "#" + digest
+		}
+
+		return model
+	}
+
+	names := make([]string, 0, len(c.Providers))
+
+	for i := range c.Providers {
+		names = append(names, c.Providers[i].name())
+	}
+
+	model := strings.Join(names, ",")
+
+	if c.Judge != nil {
+		model += ";judge=" + c.Judge.name()
+	}
+
+	// Options of ensembles, like match, select the messages translated by all providers
+	if digest := c.optionsDigest(); digest != "" {
+		model += "#" + digest
+	}
+
+	return model
+}
+
+// optionsDigest returns a digest of the sorted options changing the translations and of the command,
+// empty if there are none
+func (c *LLMConfig) optionsDigest() string {
+	keys := make([]string, 0, len(c.Options))
+
+	for key := range c.Options {
+		if !runtimeOptions[key] && !strings.HasPrefix(key, "header_") {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 && len(c.Command) == 0 {
+		return ""
+	}
+
+	sort.Strings(keys)
+
+	h := sha256.New()
+
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(c.Options[key]))
+		h.Write([]byte{0})
+	}
+
+	for _, arg := range c.Command {
+		h.Write([]byte(arg))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// name returns the provider and model of the config, e.g. "anthropic/claude-3-haiku-20240307"
+func (c *LLMConfig) name() string {
+	if model := c.cacheModel(); model != "" {
+		return c.Provider + "/" + model
+	}
+
+	return c.Provider
+}
+
+// MemoryConfig configures the translation memory
+type MemoryConfig struct {
+	// Path is the TMX file of the translation memory, empty disables the memory
+	Path string `mapstructure:"path"`
+	// FuzzyThreshold is the minimum similarity (0-1) of texts whose translations are given to
+	// the model as references, 0 disables fuzzy matches
+	FuzzyThreshold float64 `mapstructure:"fuzzy_threshold"`
+}
+
+// GlossaryConfig configures the glossary of product terms
+type GlossaryConfig struct {
+	// Path is the CSV or TBX file of the glossary, empty disables the glossary
+	Path string `mapstructure:"path"`
+}
+
+// EvaluationConfig configures the scoring of new translations by a judge model
+type EvaluationConfig struct {
+	// LLMConfig is the provider of the judge, an empty provider disables the evaluation
+	LLMConfig `mapstructure:",squash"`
+	// MinScore is the lowest score (1-5) of any criterion that a translation needs to not be marked fuzzy
+	MinScore int `mapstructure:"min_score"`
 }
 
 type Config struct {
-	LLM LLMConfig `mapstructure:"llm"`
+	LLM        LLMConfig        `mapstructure:"llm"`
+	Memory     MemoryConfig     `mapstructure:"memory"`
+	Glossary   GlossaryConfig   `mapstructure:"glossary"`
+	Evaluation EvaluationConfig `mapstructure:"evaluation"`
+	// TargetLangs are the target languages used when no --target-lang flag is given
+	TargetLangs []string `mapstructure:"target_langs"`
+	// SourceLang is the source language of translate-dir used when no --source-lang flag is given
+	SourceLang string `mapstructure:"source_lang"`
+	// Layout is the layout of the catalogs of translate-dir
+	Layout LayoutConfig `mapstructure:"layout"`
+	// Gotext configures the gotext mode of translate-dir
+	Gotext GotextConfig `mapstructure:"gotext"`
 }
 
 // initConfig initializes the configuration by reading from the specified config file.
@@ -43,12 +237,25 @@ func initConfig(arg *args) (*Config, error) {
 	if cfg.LLM.Provider == "" {
 		cfg.LLM.Provider = "openai"
 	}
-	if cfg.LLM.Model == "" {
+	// Other providers choose their default models, or require one like ollama
+	if cfg.LLM.Model == "" && cfg.LLM.Provider == "openai" {
 		cfg.LLM.Model = "gpt-3.5-turbo"
 	}
 	if cfg.LLM.Options == nil {
 		cfg.LLM.Options = make(map[string]string)
 	}
 
+	if cfg.Memory.FuzzyThreshold < 0 || cfg.Memory.FuzzyThreshold > 1 {
+		return nil, fmt.Errorf("memory fuzzy_threshold must be between 0 and 1, got %v", cfg.Memory.FuzzyThreshold)
+	}
+
+	if cfg.Evaluation.MinScore == 0 {
+		cfg.Evaluation.MinScore = defaultMinScore
+	}
+
+	if cfg.Evaluation.MinScore < 1 || cfg.Evaluation.MinScore > 5 {
+		return nil, fmt.Errorf("evaluation min_score must be between 1 and 5, got %d", cfg.Evaluation.MinScore)
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitConfig_Chain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+llm:
+  provider: chain
+  providers:
+    - provider: anthropic
+      api_key: anthropic-key
+      model: claude-3-haiku-20240307
+      requests_per_minute: 50
+      options:
+        max_attempts: 2
+    - provider: openai
+      api_key: openai-key
+`), 0644))
+
+	cfg, err := initConfig(&args{ConfigPath: path})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"api_key": "",
+		"model":   "",
+		"providers": []map[string]interface{}{
+			{"provider": "anthropic", "api_key": "anthropic-key", "model": "claude-3-haiku-20240307", "max_attempts": "2", "requests_per_minute": 50},
+			{"provider": "openai", "api_key": "openai-key", "model": ""},
+		},
+	}, cfg.LLM.translatorConfig())
+
+	assert.Equal(t, "anthropic/claude-3-haiku-20240307,openai/gpt-3.5-turbo", cfg.LLM.cacheModel())
+}
+
+func TestInitConfig_ExecCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+llm:
+  provider: exec
+  command: [python3, "/opt/in house/translate.py", --fast]
+  options:
+    timeout: 30s
+`), 0644))
+
+	cfg, err := initConfig(&args{ConfigPath: path})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"api_key": "",
+		"model":   "",
+		"command": []string{"python3", "/opt/in house/translate.py", "--fast"},
+		"timeout": "30s",
+	}, cfg.LLM.translatorConfig())
+}
+
+func TestInitConfig_Evaluation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+evaluation:
+  provider: anthropic
+  api_key: anthropic-key
+  model: claude-3-5-sonnet-20241022
+`), 0644))
+
+	cfg, err := initConfig(&args{ConfigPath: path})
+	require.NoError(t, err)
+
+	assert.Equal(t, "anthropic", cfg.Evaluation.Provider)
+	assert.Equal(t, "claude-3-5-sonnet-20241022", cfg.Evaluation.Model)
+	assert.Equal(t, defaultMinScore, cfg.Evaluation.MinScore)
+
+	require.NoError(t, os.WriteFile(path, []byte("evaluation:\n  min_score: 6\n"), 0644))
+
+	_, err = initConfig(&args{ConfigPath: path})
+	assert.ErrorContains(t, err, "evaluation min_score must be between 1 and 5")
+}
+
+func TestLLMConfig_CacheModel(t *testing.T) {
+	assert.Equal(t, "openai/gpt-3.5-turbo", (&LLMConfig{Provider: "openrouter"}).cacheModel())
+
+	// Options changing the translations are part of the key, runtime options are not
+	azure := func(options map[string]string) string {
+		return (&LLMConfig{Provider: "azure-openai", Options: options}).cacheModel()
+	}
+
+	first := azure(map[string]string{"endpoint": "https://example.openai.azure.com", "deployment": "gpt-4o"})
+	second := azure(map[string]string{"endpoint": "https://example.openai.azure.com", "deployment": "gpt-4o-mini"})
+
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, first, azure(map[string]string{
+		"endpoint":     "https://example.openai.azure.com",
+		"deployment":   "gpt-4o",
+		"max_attempts": "2",
+		"timeout":      "30s",
+		"header_x-key": "secret",
+	}))
+
+	deepl := (&LLMConfig{Provider: "deepl", Options: map[string]string{"formality": "more"}}).cacheModel()
+	assert.NotEqual(t, deepl, (&LLMConfig{Provider: "deepl", Options: map[string]string{"formality": "less"}}).cacheModel())
+	assert.Empty(t, (&LLMConfig{Provider: "deepl", Options: map[string]string{"timeout": "10s"}}).cacheModel())
+
+	exec := (&LLMConfig{Provider: "exec", Command: []string{"translate", "--engine", "a"}}).cacheModel()
+	assert.NotEqual(t, exec, (&LLMConfig{Provider: "exec", Command: []string{"translate", "--engine", "b"}}).cacheModel())
+
+	// Chains are identified by the options of each provider
+	chain := func(deployment string) string {
+		return (&LLMConfig{Provider: "chain", Providers: []LLMConfig{
+			{Provider: "azure-openai", Options: map[string]string{"deployment": deployment}},
+			{Provider: "openai"},
+		}}).cacheModel()
+	}
+
+	assert.NotEqual(t, chain("gpt-4o"), chain("gpt-4o-mini"))
+}
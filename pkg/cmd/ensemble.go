@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+// CandidatesReport lists the candidate translations of the messages translated by an ensemble, so
+// reviewers can compare the selected translation with the alternatives
+type CandidatesReport struct {
+	Language string                      `json:"language"`
+	Messages []translator.EnsembleResult `json:"messages"`
+}
+
+// candidatesReportSuffix replaces the .json extension of the output file in the name of its report
+const candidatesReportSuffix = ".candidates.json"
+
+// candidatesReportPath returns the path of the candidates report of the output file. Reports are kept
+// in the reports directory, out of the catalogs found by layouts, under the path of the output file,
+// e.g. .gotext-translator/reports/locales/fr-FR/out.gotext.candidates.json.
+func candidatesReportPath(outputPath string) string {
+	dir := globalArgs.ReportsDir
+	if dir == "" {
+		dir = defaultReportsDir
+	}
+
+	rel := outputPath
+
+	if abs, err := filepath.Abs(outputPath); err == nil {
+		rel = strings.TrimPrefix(abs, filepath.VolumeName(abs))
+
+		// Output files outside of the working directory are kept under their absolute path
+		if wd, err := os.Getwd(); err == nil {
+			if r, err := filepath.Rel(wd, abs); err == nil && r != ".." && !strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+				rel = r
+			}
+		}
+	}
+
+	return filepath.Join(dir, strings.TrimSuffix(rel, ".json")+candidatesReportSuffix)
+}
+
+// writeCandidatesReport writes the candidates recorded by an ensemble to the report of the output file.
+// Nothing is written if no message was translated by an ensemble.
+func writeCandidatesReport(outputPath, lang string, provenance *translator.Provenance) error {
+	results := provenance.Ensembles()
+	if len(results) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].MessageID != results[j].MessageID {
+			return results[i].MessageID < results[j].MessageID
+		}
+
+		return results[i].PluralCategory < results[j].PluralCategory
+	})
+
+	data, err := json.MarshalIndent(CandidatesReport{Language: lang, Messages: results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal candidates report: %w", err)
+	}
+
+	path := candidatesReportPath(outputPath)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create reports directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write candidates report: %w", err)
+	}
+
+	slog.Info("candidates report written", slog.String("file", path), slog.Int("messages", len(results)))
+
+	return nil
+}
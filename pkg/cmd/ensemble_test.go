@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessFile_CandidatesReport(t *testing.T) {
+	tempDir := t.TempDir()
+	globalArgs = &args{ReportsDir: filepath.Join(tempDir, "reports")}
+
+	first := new(mocks.Translator)
+	first.On("Translate", mock.Anything, mock.Anything).Return("Conditions générales", nil)
+
+	second := new(mocks.Translator)
+	second.On("Translate", mock.Anything, mock.Anything).Return("Conditions générales", nil)
+
+	trans := translator.NewEnsemble([]translator.ChainLink{
+		{Name: "anthropic", Translator: first},
+		{Name: "openai", Translator: second},
+	}, nil, nil)
+
+	sourceData, err := json.Marshal(GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{{ID: "Terms", Message: Text{Msg: "Terms and Conditions"}}},
+	})
+	require.NoError(t, err)
+
+	sourcePath := filepath.Join(tempDir, "messages.gotext.json")
+	require.NoError(t, os.WriteFile(sourcePath, sourceData, 0644))
+
+	targetPath := filepath.Join(tempDir, "out.gotext.json")
+
+	count, err := processFile(context.Background(), &pipeline{trans: trans}, sourcePath, targetPath, "fr-FR")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Reports are kept out of the directory of the output file
+	assert.NoFileExists(t, filepath.Join(tempDir, "out.gotext.candidates.json"))
+
+	reportPath := candidatesReportPath(targetPath)
+	assert.True(t, strings.HasPrefix(reportPath, globalArgs.ReportsDir))
+	assert.True(t, strings.HasSuffix(reportPath, "out.gotext.candidates.json"))
+
+	reportData, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+
+	var report CandidatesReport
+	require.NoError(t, json.Unmarshal(reportData, &report))
+
+	assert.Equal(t, "fr-FR", report.Language)
+	require.Len(t, report.Messages, 1)
+	assert.Equal(t, "Terms", report.Messages[0].MessageID)
+	assert.Equal(t, "anthropic", report.Messages[0].Selected)
+	assert.Equal(t, "all providers agree", report.Messages[0].Reason)
+	assert.Len(t, report.Messages[0].Candidates, 2)
+
+	// Files translated without an ensemble have no report
+	assert.NoError(t, writeCandidatesReport(filepath.Join(tempDir, "other.gotext.json"), "fr-FR", nil))
+	assert.NoFileExists(t, candidatesReportPath(filepath.Join(tempDir, "other.gotext.json")))
+}
+
+func TestTranslateMessage_EnsembleValidation(t *testing.T) {
+	globalArgs = &args{}
+
+	first := new(mocks.Translator)
+	first.On("Translate", mock.Anything, mock.Anything).Return("Envoyez au plus photos", nil)
+
+	second := new(mocks.Translator)
+	second.On("Translate", mock.Anything, mock.Anything).Return("Envoyez au plus photos", nil).Once()
+	second.On("Translate", mock.Anything, mock.Anything).Return("Envoyez au plus {MaxAllowedPhotos} photos", nil).Once()
+
+	trans := translator.NewEnsemble([]translator.ChainLink{
+		{Name: "anthropic", Translator: first},
+		{Name: "openai", Translator: second},
+	}, nil, nil)
+
+	msg := GotextMessage{
+		ID:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+		Message: Text{Msg: "Please, provide no more than {MaxAllowedPhotos} photo(s)"},
+		Placeholders: []Placeholder{
+			{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", ArgNum: 1},
+		},
+	}
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	// Every candidate breaks the placeholder at first, the retry selects the only valid one
+	err := translateMessage(ctx, trans, &msg, translator.Request{MessageID: msg.ID, TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Envoyez au plus {MaxAllowedPhotos} photos", msg.Translation.Msg)
+
+	results := provenance.Ensembles()
+	require.Len(t, results, 1)
+	assert.Equal(t, "openai", results[0].Selected)
+	assert.True(t, strings.HasPrefix(results[0].Candidates[0].Error, "rejected: "))
+	assert.Empty(t, results[0].Candidates[1].Error)
+}
+
+func TestCandidatesReportPath(t *testing.T) {
+	globalArgs = &args{}
+	assert.Equal(t, filepath.Join(defaultReportsDir, "i18n", "fr-FR.candidates.json"), candidatesReportPath(filepath.Join("i18n", "fr-FR.json")))
+
+	globalArgs = &args{ReportsDir: "reports"}
+	assert.Equal(t, filepath.Join("reports", "locales", "fr-FR", "out.gotext.candidates.json"), candidatesReportPath("locales/fr-FR/out.gotext.json"))
+
+	// Output files outside of the working directory are kept under their absolute path
+	assert.Equal(t, filepath.Join("reports", "srv", "i18n", "fr-FR.candidates.json"), candidatesReportPath("/srv/i18n/fr-FR.json"))
+}
+
+func TestTranslateDirectory_EnsembleRerun(t *testing.T) {
+	dir := t.TempDir()
+	globalArgs = &args{Concurrency: 1, ReportsDir: filepath.Join(dir, "reports")}
+
+	i18nDir := filepath.Join(dir, "i18n")
+	require.NoError(t, os.MkdirAll(i18nDir, 0755))
+
+	sourceData, err := json.Marshal(GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{{ID: "greeting", Message: Text{Msg: "Hello"}}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(i18nDir, "en-US.json"), sourceData, 0644))
+
+	// Reports written next to the catalogs by earlier versions are not taken for catalogs
+	staleData, err := json.Marshal(CandidatesReport{
+		Language: "fr-FR",
+		Messages: []translator.EnsembleResult{{MessageID: "greeting", Selected: "anthropic"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(i18nDir, "fr-FR.candidates.json"), staleData, 0644))
+
+	first := new(mocks.Translator)
+	first.On("Translate", mock.Anything, translationRequest("Hello", "fr-FR")).Return("Bonjour", nil)
+
+	second := new(mocks.Translator)
+	second.On("Translate", mock.Anything, translationRequest("Hello", "fr-FR")).Return("Bonjour", nil)
+
+	trans := translator.NewEnsemble([]translator.ChainLink{
+		{Name: "anthropic", Translator: first},
+		{Name: "openai", Translator: second},
+	}, nil, nil)
+
+	l, err := newLayout(dir, LayoutConfig{Pattern: "{root}/i18n/{lang}.json"})
+	require.NoError(t, err)
+
+	for run := 0; run < 2; run++ {
+		require.NoError(t, translateDirectory(context.Background(), &pipeline{trans: trans}, l, []string{"fr-FR"}), "run %d", run+1)
+	}
+
+	data, err := os.ReadFile(filepath.Join(i18nDir, "fr-FR.json"))
+	require.NoError(t, err)
+
+	var file GotextFile
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Equal(t, "Bonjour", file.Messages[0].Translation.Msg)
+
+	assert.FileExists(t, candidatesReportPath(filepath.Join(i18nDir, "fr-FR.json")))
+}
@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/text/language"
+	gotextpipeline "golang.org/x/text/message/pipeline"
+)
+
+// defaultGenFile is the catalog Go file written by generate unless configured otherwise
+const defaultGenFile = "catalog.go"
+
+// TranslationError is a translation that does not compile into the catalog
+type TranslationError struct {
+	Lang string
+	ID   string
+	Err  error
+}
+
+// Error returns the language and message of the translation with the reason
+func (e *TranslationError) Error() string {
+	return fmt.Sprintf("%s: message %q: %v", e.Lang, e.ID, e.Err)
+}
+
+// Unwrap returns the reason of the error
+func (e *TranslationError) Unwrap() error {
+	return e.Err
+}
+
+// runGenerate writes the catalog Go file of the translated catalogs in the directory. The catalogs are
+// the messages.gotext.json of the locales in gotext mode, or else the catalogs of the layout.
+func runGenerate(cfg *Config) error {
+	root := globalArgs.SourceDir
+
+	var catalogs []catalog
+
+	sourceLang := globalArgs.SourceLang
+
+	if cfg.Gotext.Enabled {
+		l, err := gotextLayout(root, cfg.Gotext, gotextOutFile)
+		if err != nil {
+			return err
+		}
+
+		// gotext extracts the catalogs of the source language, but translators only edit the others
+		if sourceLang == "" {
+			extracted, err := l.find()
+			if err != nil {
+				return err
+			}
+
+			if sourceLang, err = findSourceLang(extracted, nil, ""); err != nil {
+				return err
+			}
+		}
+
+		if l, err = gotextLayout(root, cfg.Gotext, gotextMessagesFile); err != nil {
+			return err
+		}
+
+		if catalogs, err = l.find(); err != nil {
+			return err
+		}
+	} else {
+		l, err := newLayout(root, cfg.Layout)
+		if err != nil {
+			return err
+		}
+
+		if catalogs, err = l.find(); err != nil {
+			return err
+		}
+
+		if sourceLang == "" {
+			if sourceLang, err = findSourceLang(catalogs, nil, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(catalogs) == 0 {
+		return fmt.Errorf("no translated catalogs found")
+	}
+
+	if cfg.Gotext.GenFile == "" {
+		cfg.Gotext.GenFile = defaultGenFile
+	}
+
+	return generateCatalog(root, sourceLang, catalogs, cfg.Gotext)
+}
+
+// generateCatalog writes the catalog Go file with the translations of the catalogs, like gotext generate.
+// The messages are extracted from the Go packages again, as the catalogs of gotext do not keep the format
+// strings the messages are looked up by. Nothing is written if any translation does not compile.
+func generateCatalog(root, sourceLang string, catalogs []catalog, cfg GotextConfig) error {
+	tag, err := language.Parse(sourceLang)
+	if err != nil {
+		return fmt.Errorf("invalid source language %q: %w", sourceLang, err)
+	}
+
+	packages := cfg.Packages
+	if len(packages) == 0 {
+		packages = []string{"."}
+	}
+
+	for i, pkg := range packages {
+		if packages[i], err = localPackage(root, pkg); err != nil {
+			return err
+		}
+	}
+
+	state, err := gotextpipeline.Extract(&gotextpipeline.Config{
+		SourceLanguage: tag,
+		Packages:       packages,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to extract messages: %w", err)
+	}
+
+	for _, c := range catalogs {
+		messages, err := readTranslations(c)
+		if err != nil {
+			return err
+		}
+
+		state.Translations = append(state.Translations, messages)
+	}
+
+	if errs := validateTranslations(state.Extracted, state.Translations); len(errs) > 0 {
+		for _, err := range errs {
+			slog.Error("invalid translation", slog.String("error", err.Error()))
+		}
+
+		return fmt.Errorf("%d translations do not compile: %w", len(errs), errors.Join(errs...))
+	}
+
+	if err := state.Merge(); err != nil {
+		return fmt.Errorf("failed to merge translations: %w", err)
+	}
+
+	genFile := filepath.Join(root, cfg.GenFile)
+
+	pkgName := cfg.GenPackage
+	if pkgName == "" {
+		if pkgName, err = goPackageName(filepath.Dir(genFile)); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := state.WriteGen(&buf, pkgName); err != nil {
+		return fmt.Errorf("failed to generate catalog: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(genFile), 0755); err != nil {
+		return fmt.Errorf("failed to create catalog directory: %w", err)
+	}
+
+	if err := os.WriteFile(genFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+
+	slog.Info("catalog generated",
+		slog.String("file", genFile),
+		slog.String("package", pkgName),
+		slog.Int("messages", len(state.Extracted.Messages)),
+		slog.Int("languages", len(state.Messages)),
+	)
+
+	return nil
+}
+
+// readTranslations reads the catalog as messages of the pipeline, the language defaults to the one in its path
+func readTranslations(c catalog) (gotextpipeline.Messages, error) {
+	var file struct {
+		Language string `json:"language"`
+		gotextpipeline.Messages
+	}
+
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		return file.Messages, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file.Messages, fmt.Errorf("failed to parse catalog %s: %w", c.file, err)
+	}
+
+	lang := file.Language
+	if lang == "" {
+		lang = c.lang
+	}
+
+	if file.Messages.Language, err = language.Parse(lang); err != nil {
+		return file.Messages, fmt.Errorf("invalid language %q of catalog %s: %w", lang, c.file, err)
+	}
+
+	return file.Messages, nil
+}
+
+// validateTranslations compiles every translation of an extracted message on its own and returns a
+// TranslationError for every translation that refers to unknown placeholders, loses or adds printf
+// verbs, or does not compile.
+func validateTranslations(extracted gotextpipeline.Messages, translations []gotextpipeline.Messages) []error {
+	byID := make(map[string]gotextpipeline.Message)
+
+	for _, msg := range extracted.Messages {
+		for _, id := range msg.ID {
+			byID[id] = msg
+		}
+	}
+
+	var errs []error
+
+	for _, messages := range translations {
+		for _, msg := range messages.Messages {
+			if msg.Translation.IsEmpty() || len(msg.ID) == 0 {
+				continue
+			}
+
+			// Messages that are not extracted anymore are not in the catalog
+			src, ok := byID[msg.ID[0]]
+			if !ok {
+				continue
+			}
+
+			if err := compileTranslation(extracted.Language, messages.Language, src, msg.Translation); err != nil {
+				errs = append(errs, &TranslationError{Lang: messages.Language.String(), ID: msg.ID[0], Err: err})
+			}
+		}
+	}
+
+	return errs
+}
+
+// compileTranslation checks the translation of the extracted message src against its placeholders and
+// compiles it into a catalog of the message alone
+func compileTranslation(sourceLang, lang language.Tag, src gotextpipeline.Message, translation gotextpipeline.Text) error {
+	if err := checkTextPlaceholders(src, translation); err != nil {
+		return err
+	}
+
+	// Plain translations keep the printf verbs of the message, like the ones translated by this tool
+	if translation.Select == nil && translation.Var == nil && src.Message.Select == nil && src.Message.Var == nil {
+		placeholders := make([]Placeholder, 0, len(src.Placeholders))
+
+		for _, ph := range src.Placeholders {
+			placeholders = append(placeholders, Placeholder{ID: ph.ID, String: ph.String})
+		}
+
+		if err := validatePlaceholders(src.Message.Msg, translation.Msg, placeholders); err != nil {
+			return err
+		}
+	}
+
+	state := gotextpipeline.State{
+		Extracted: gotextpipeline.Messages{Language: sourceLang, Messages: []gotextpipeline.Message{src}},
+		Messages: []gotextpipeline.Messages{{
+			Language: lang,
+			Messages: []gotextpipeline.Message{{ID: src.ID, Translation: translation}},
+		}},
+	}
+
+	return state.WriteGen(io.Discard, "catalog")
+}
+
+// checkTextPlaceholders checks that the text and its cases and variables only refer to placeholders of
+// the message, which the pipeline requires to compile them
+func checkTextPlaceholders(msg gotextpipeline.Message, text gotextpipeline.Text) error {
+	for _, ref := range placeholderRefRe.FindAllStringSubmatch(text.Msg, -1) {
+		if msg.Placeholder(ref[1]) == nil {
+			return fmt.Errorf("unknown placeholder {%s}", ref[1])
+		}
+	}
+
+	for _, v := range text.Var {
+		if err := checkTextPlaceholders(msg, v); err != nil {
+			return err
+		}
+	}
+
+	if text.Select == nil {
+		return nil
+	}
+
+	if msg.Placeholder(text.Select.Arg) == nil {
+		return fmt.Errorf("unknown placeholder %q of %s select", text.Select.Arg, text.Select.Feature)
+	}
+
+	for _, c := range text.Select.Cases {
+		if err := checkTextPlaceholders(msg, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// localPackage returns the package path relative to root as a path relative to the working directory,
+// import paths are returned as is
+func localPackage(root, pkg string) (string, error) {
+	if pkg != "." && !strings.HasPrefix(pkg, "./") && !strings.HasPrefix(pkg, "../") {
+		return pkg, nil
+	}
+
+	dir, err := filepath.Abs(filepath.Join(root, pkg))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve package %s: %w", pkg, err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve package %s: %w", pkg, err)
+	}
+
+	rel, err := filepath.Rel(wd, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve package %s: %w", pkg, err)
+	}
+
+	rel = filepath.ToSlash(rel)
+	if rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+
+	return rel, nil
+}
+
+// goPackageName returns the name of the package of the Go files in dir
+func goPackageName(dir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", fmt.Errorf("failed to find Go files: %w", err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		return f.Name.Name, nil
+	}
+
+	return "", fmt.Errorf("no Go files found in %s, set the package of the catalog with gen_package", dir)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	gotextpipeline "golang.org/x/text/message/pipeline"
+)
+
+func TestRunGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads the Go packages of the messages")
+	}
+
+	// The messages are extracted from a module using this module's version of golang.org/x/text
+	sum, err := os.ReadFile(filepath.Join("..", "..", "go.sum"))
+	require.NoError(t, err)
+
+	t.Setenv("GOFLAGS", "-mod=mod")
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.sum"), sum, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte(`module example.com/app
+
+go 1.25.0
+
+require golang.org/x/text v0.21.0
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte(`package main
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func main() {
+	p := message.NewPrinter(language.German)
+	p.Printf("Hello %s!", "Gopher")
+}
+`), 0644))
+
+	placeholders := []Placeholder{
+		{ID: "Gopher", String: "%[1]s", Type: "string", UnderlyingType: "string", Expr: `"Gopher"`, ArgNum: 1},
+	}
+
+	writeGotextFile(t, root, "en-US", gotextMessagesFile, GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{{
+			ID:           "Hello {Gopher}!",
+			Message:      Text{Msg: "Hello {Gopher}!"},
+			Translation:  Text{Msg: "Hello {Gopher}!"},
+			Placeholders: placeholders,
+		}},
+	})
+	writeGotextFile(t, root, "de-DE", gotextMessagesFile, GotextFile{
+		Language: "de-DE",
+		Messages: []GotextMessage{{
+			ID:           "Hello {Gopher}!",
+			Message:      Text{Msg: "Hello {Gopher}!"},
+			Translation:  Text{Msg: "Hallo {Gopher}!"},
+			Placeholders: placeholders,
+		}},
+	})
+
+	globalArgs = &args{SourceDir: root}
+
+	require.NoError(t, runGenerate(&Config{}))
+
+	data, err := os.ReadFile(filepath.Join(root, defaultGenFile))
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "package main")
+	assert.Contains(t, string(data), `"Hello %s!"`)
+	assert.Contains(t, string(data), `"de_DE"`)
+	assert.Contains(t, string(data), "Hallo")
+}
+
+func TestValidateTranslations(t *testing.T) {
+	extracted := gotextpipeline.Messages{
+		Language: language.English,
+		Messages: []gotextpipeline.Message{
+			{
+				ID:      gotextpipeline.IDList{"Hello {Name}!"},
+				Key:     "Hello %s!",
+				Message: gotextpipeline.Text{Msg: "Hello {Name}!"},
+				Placeholders: []gotextpipeline.Placeholder{
+					{ID: "Name", String: "%[1]s", Type: "string", ArgNum: 1},
+				},
+			},
+			{
+				ID:      gotextpipeline.IDList{"{N} files"},
+				Key:     "%d files",
+				Message: gotextpipeline.Text{Msg: "{N} files"},
+				Placeholders: []gotextpipeline.Placeholder{
+					{ID: "N", String: "%[1]d", Type: "int", ArgNum: 1},
+				},
+			},
+		},
+	}
+
+	plural := func(arg string, cases map[string]string) gotextpipeline.Text {
+		text := gotextpipeline.Text{Select: &gotextpipeline.Select{Feature: "plural", Arg: arg, Cases: map[string]gotextpipeline.Text{}}}
+		for c, msg := range cases {
+			text.Select.Cases[c] = gotextpipeline.Text{Msg: msg}
+		}
+
+		return text
+	}
+
+	translations := []gotextpipeline.Messages{
+		{
+			Language: language.German,
+			Messages: []gotextpipeline.Message{
+				{ID: gotextpipeline.IDList{"Hello {Name}!"}, Translation: gotextpipeline.Text{Msg: "Hallo {Name}!"}},
+				{ID: gotextpipeline.IDList{"{N} files"}, Translation: plural("N", map[string]string{"one": "{N} Datei", "other": "{N} Dateien"})},
+				{ID: gotextpipeline.IDList{"Removed"}, Translation: gotextpipeline.Text{Msg: "Entfernt {X}"}},
+			},
+		},
+		{
+			Language: language.French,
+			Messages: []gotextpipeline.Message{
+				{ID: gotextpipeline.IDList{"Hello {Name}!"}, Translation: gotextpipeline.Text{Msg: "Bonjour {Nom} !"}},
+				{ID: gotextpipeline.IDList{"{N} files"}, Translation: plural("Count", map[string]string{"other": "{N} fichiers"})},
+			},
+		},
+		{
+			Language: language.Russian,
+			Messages: []gotextpipeline.Message{
+				{ID: gotextpipeline.IDList{"Hello {Name}!"}, Translation: gotextpipeline.Text{Msg: "Привет!"}},
+				{ID: gotextpipeline.IDList{"{N} files"}, Translation: plural("N", map[string]string{"lots": "{N} файлов"})},
+				{ID: gotextpipeline.IDList{"Untranslated"}},
+			},
+		},
+	}
+
+	errs := validateTranslations(extracted, translations)
+	require.Len(t, errs, 4)
+
+	var trErr *TranslationError
+
+	require.ErrorAs(t, errs[0], &trErr)
+	assert.Equal(t, "fr", trErr.Lang)
+	assert.Equal(t, "Hello {Name}!", trErr.ID)
+	assert.ErrorContains(t, errs[0], "unknown placeholder {Nom}")
+	assert.ErrorContains(t, errs[1], `fr: message "{N} files": unknown placeholder "Count" of plural select`)
+
+	var phErr *PlaceholderError
+
+	require.ErrorAs(t, errs[2], &phErr)
+	assert.Equal(t, []string{"%[1]s"}, phErr.Missing)
+
+	assert.ErrorContains(t, errs[3], `invalid plural form "lots"`)
+}
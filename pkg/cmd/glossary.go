@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+// glossaryCommentPrefix marks translator comments of messages whose translation misses mandated glossary terms
+const glossaryCommentPrefix = "Glossary check failed: "
+
+// loadGlossary loads the glossary configured in cfg, it returns nil if the glossary is disabled
+func loadGlossary(cfg *Config) (*translator.Glossary, error) {
+	if cfg.Glossary.Path == "" {
+		return nil, nil
+	}
+
+	gloss, err := translator.LoadGlossary(cfg.Glossary.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("glossary loaded", slog.String("path", cfg.Glossary.Path), slog.Int("entries", gloss.Len()))
+
+	return gloss, nil
+}
+
+// checkGlossary flags the translated messages at the pending indexes whose translation misses the
+// mandated translation of a glossary term used in the source text. Flagged messages keep their
+// translation, but are marked as fuzzy with the missing terms recorded in the translator comment.
+// It returns the number of flagged messages.
+func checkGlossary(gloss *translator.Glossary, messages []GotextMessage, pending []int, sourceLang, targetLang string) int {
+	if gloss == nil {
+		return 0
+	}
+
+	flagged := 0
+
+	for _, idx := range pending {
+		msg := &messages[idx]
+		if msg.Translation.IsEmpty() || strings.HasPrefix(msg.TranslatorComment, rejectedCommentPrefix) {
+			continue
+		}
+
+		var (
+			missing []string
+			seen    = make(map[translator.Term]bool)
+		)
+
+		for _, pair := range textPairs(msg.Message, msg.Translation) {
+			terms := gloss.Match(sourceLang, targetLang, pair[0])
+
+			for _, term := range translator.MissingTerms(pair[1], terms) {
+				if seen[term] {
+					continue
+				}
+
+				seen[term] = true
+
+				if term.DoNotTranslate() {
+					missing = append(missing, fmt.Sprintf("%q must not be translated", term.Source))
+				} else {
+					missing = append(missing, fmt.Sprintf("%q must be translated as %q", term.Source, term.Target))
+				}
+			}
+		}
+
+		if len(missing) == 0 {
+			continue
+		}
+
+		msg.Fuzzy = true
+		msg.TranslatorComment = glossaryCommentPrefix + strings.Join(missing, ", ")
+		flagged++
+
+		slog.Warn("translation misses glossary terms",
+			slog.String("id", msg.ID),
+			slog.String("terms", strings.Join(missing, ", ")))
+	}
+
+	return flagged
+}
+
+// textPairs returns the source and translated texts of a message. Cases of select statements are
+// paired with the source case of the same selector or, for plural categories that the source language
+// does not have, with the "other" case.
+func textPairs(src, dst Text) [][2]string {
+	if src.Select == nil || dst.Select == nil {
+		return [][2]string{{src.String(), dst.String()}}
+	}
+
+	var pairs [][2]string
+
+	for c, dstCase := range dst.Select.Cases {
+		srcCase, ok := src.Select.Cases[c]
+		if !ok {
+			srcCase, ok = src.Select.Cases[otherCategory]
+		}
+
+		if ok {
+			pairs = append(pairs, textPairs(srcCase, dstCase)...)
+		}
+	}
+
+	return pairs
+}
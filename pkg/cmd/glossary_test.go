@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckGlossary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "glossary.csv")
+	require.NoError(t, os.WriteFile(path, []byte("term,de-DE,dnt\nHelp My Pet Bot,,true\nquestionnaire,Fragebogen,\n"), 0644))
+
+	gloss, err := translator.LoadGlossary(path)
+	require.NoError(t, err)
+
+	messages := []GotextMessage{
+		{
+			ID:          "ok",
+			Message:     Text{Msg: "Open the questionnaire"},
+			Translation: Text{Msg: "Öffnen Sie den Fragebogen"},
+		},
+		{
+			ID:          "missing",
+			Message:     Text{Msg: "Welcome to Help My Pet Bot"},
+			Translation: Text{Msg: "Willkommen bei Hilf meinem Haustier"},
+		},
+		{
+			ID:      "plural",
+			Message: Text{Select: &Select{Feature: "plural", Arg: "N", Cases: map[string]Text{"one": {Msg: "One questionnaire"}, "other": {Msg: "%d questionnaires"}}}},
+			Translation: Text{Select: &Select{Feature: "plural", Arg: "N", Cases: map[string]Text{
+				"one":   {Msg: "Eine Umfrage"},
+				"other": {Msg: "%d Umfragen"},
+			}}},
+		},
+		{
+			ID:      "skipped",
+			Message: Text{Msg: "questionnaire"},
+		},
+	}
+
+	flagged := checkGlossary(gloss, messages, []int{0, 1, 2, 3}, "en-US", "de-DE")
+	assert.Equal(t, 2, flagged)
+
+	assert.False(t, messages[0].Fuzzy)
+	assert.Empty(t, messages[0].TranslatorComment)
+
+	assert.True(t, messages[1].Fuzzy)
+	assert.Equal(t, `Glossary check failed: "Help My Pet Bot" must not be translated`, messages[1].TranslatorComment)
+	assert.Equal(t, "Willkommen bei Hilf meinem Haustier", messages[1].Translation.Msg)
+
+	// Cases are checked against their source cases
+	assert.True(t, messages[2].Fuzzy)
+	assert.Equal(t, `Glossary check failed: "questionnaire" must be translated as "Fragebogen"`, messages[2].TranslatorComment)
+
+	assert.False(t, messages[3].Fuzzy)
+
+	assert.Equal(t, 0, checkGlossary(nil, messages, []int{0, 1}, "en-US", "de-DE"))
+}
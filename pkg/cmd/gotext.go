@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+const (
+	// defaultGotextDir is the directory of the catalogs of gotext, relative to the package
+	defaultGotextDir = "locales"
+	// gotextOutFile is the catalog of a locale extracted by gotext update
+	gotextOutFile = "out.gotext.json"
+	// gotextMessagesFile is the catalog of a locale with the translations read by gotext update
+	gotextMessagesFile = "messages.gotext.json"
+)
+
+// GotextConfig configures the gotext mode of translate-dir and the catalog written by generate
+type GotextConfig struct {
+	// Enabled translates the catalogs extracted by gotext update, instead of the catalogs of the layout,
+	// and generates the catalog Go file from their messages.gotext.json
+	Enabled bool `mapstructure:"enabled"`
+	// Dir is the directory of the locales relative to the translated directory, "locales" by default
+	Dir string `mapstructure:"dir"`
+	// Packages are the Go packages of the messages relative to the translated directory, "." by default
+	Packages []string `mapstructure:"packages"`
+	// GenFile is the Go file of the catalog relative to the translated directory, empty disables the generation
+	GenFile string `mapstructure:"gen_file"`
+	// GenPackage is the package name of the catalog file, by default the package of the Go files next to it
+	GenPackage string `mapstructure:"gen_package"`
+}
+
+// dir returns the directory of the locales
+func (c GotextConfig) dir() string {
+	if c.Dir == "" {
+		return defaultGotextDir
+	}
+
+	return c.Dir
+}
+
+// runGotextUpdate translates the catalogs extracted by gotext update in the directory into every target
+// language and regenerates the catalog Go file, if configured
+func runGotextUpdate(ctx context.Context, cfg *Config) error {
+	root := globalArgs.SourceDir
+
+	l, err := gotextLayout(root, cfg.Gotext, gotextOutFile)
+	if err != nil {
+		return err
+	}
+
+	// Prepare the translator
+	p, err := preparePipeline(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	sourceLang, err := translateGotext(ctx, p, l, globalArgs.TargetLangs)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Gotext.GenFile == "" {
+		return nil
+	}
+
+	translated, err := gotextLayout(root, cfg.Gotext, gotextMessagesFile)
+	if err != nil {
+		return err
+	}
+
+	catalogs, err := translated.find()
+	if err != nil {
+		return err
+	}
+
+	return generateCatalog(root, sourceLang, catalogs, cfg.Gotext)
+}
+
+// gotextLayout returns the layout of the catalogs of gotext named file under root
+func gotextLayout(root string, cfg GotextConfig, file string) (*layout, error) {
+	return newLayout(root, LayoutConfig{
+		Pattern: path.Join("{root}", filepath.ToSlash(cfg.dir()), "{lang}", file),
+	})
+}
+
+// translateGotext fills the translations of messages.gotext.json of every target language from the
+// catalog out.gotext.json extracted by gotext update next to it. It returns the source language, which
+// is --source-lang or the language whose extracted catalog gotext translated to itself.
+func translateGotext(ctx context.Context, p *pipeline, l *layout, langs []string) (string, error) {
+	catalogs, err := l.find()
+	if err != nil {
+		return "", err
+	}
+
+	sourceLang := globalArgs.SourceLang
+	if sourceLang == "" {
+		if sourceLang, err = findSourceLang(catalogs, langs, ""); err != nil {
+			return "", err
+		}
+	}
+
+	summaries := make([]langSummary, len(langs))
+
+	errs := parallel(ctx, globalArgs.Concurrency, len(langs), func(ctx context.Context, i int) error {
+		summaries[i] = langSummary{Lang: langs[i], Files: 1}
+
+		idx := slices.IndexFunc(catalogs, func(c catalog) bool { return sameLang(c.lang, langs[i]) })
+		if idx < 0 {
+			summaries[i].FailedFiles = 1
+			return fmt.Errorf("%s: no %s found, add the language to -lang of gotext update", langs[i], gotextOutFile)
+		}
+
+		messagesPath := filepath.Join(filepath.Dir(catalogs[idx].file), gotextMessagesFile)
+
+		processed, err := updateGotextCatalog(ctx, p, catalogs[idx].file, messagesPath, sourceLang, langs[i])
+		if err != nil {
+			summaries[i].FailedFiles = 1
+			return fmt.Errorf("%s: %w", langs[i], err)
+		}
+
+		summaries[i].Messages = processed
+
+		return nil
+	})
+
+	if len(langs) > 1 {
+		logSummaries(summaries)
+	}
+
+	return sourceLang, errors.Join(errs...)
+}
+
+// updateGotextCatalog writes the messages extracted to outPath, with their translations, to messagesPath.
+// Translations of messagesPath take precedence over the ones gotext merged into outPath, messages that
+// are translated in neither are translated. Messages no longer extracted are removed, like gotext does.
+func updateGotextCatalog(ctx context.Context, p *pipeline, outPath, messagesPath, sourceLang, targetLang string) (int, error) {
+	file, err := readGotextFile(outPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if file.Language == "" {
+		file.Language = targetLang
+	}
+
+	existing := make(map[string]GotextMessage)
+
+	if _, err := os.Stat(messagesPath); err == nil {
+		messages, err := readGotextFile(messagesPath)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, msg := range messages.Messages {
+			existing[msg.ID] = msg
+		}
+	}
+
+	slog.Info("processing file",
+		slog.String("source", outPath),
+		slog.String("target", messagesPath),
+		slog.Int("total_messages", len(file.Messages)),
+	)
+
+	var pending []int
+
+	for i := range file.Messages {
+		msg := &file.Messages[i]
+
+		if prev, ok := existing[msg.ID]; ok {
+			if !prev.Translation.IsEmpty() {
+				msg.Translation = prev.Translation
+			}
+
+			msg.TranslatorComment = prev.TranslatorComment
+			msg.Fuzzy = prev.Fuzzy
+
+			delete(existing, msg.ID)
+		}
+
+		if !msg.Translation.IsEmpty() && !globalArgs.ForceRewrite {
+			slog.Debug("skipping translated message", slog.String("id", msg.ID))
+			continue
+		}
+
+		pending = append(pending, i)
+	}
+
+	if len(existing) > 0 {
+		slog.Info("removing messages no longer extracted", slog.String("file", messagesPath), slog.Int("count", len(existing)))
+	}
+
+	ctx, provenance := translator.WithProvenance(ctx)
+
+	processedCount := translateMessages(ctx, p.trans, file.Messages, pending, sourceLang, file.Language)
+	checkGlossary(p.glossary, file.Messages, pending, sourceLang, file.Language)
+	evaluateTranslations(ctx, p, file.Messages, pending, sourceLang, file.Language).log(messagesPath)
+
+	output, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	if err := os.WriteFile(messagesPath, output, 0644); err != nil {
+		return 0, fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if err := writeCandidatesReport(messagesPath, file.Language, provenance); err != nil {
+		return 0, err
+	}
+
+	updateMemory(p.memory, file.Messages, sourceLang, file.Language)
+
+	slog.Info("file processing completed",
+		slog.String("file", messagesPath),
+		slog.Int("processed", processedCount),
+	)
+
+	return processedCount, nil
+}
+
+// readGotextFile reads and parses the catalog at path
+func readGotextFile(path string) (GotextFile, error) {
+	var file GotextFile
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read catalog: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse catalog %s: %w", path, err)
+	}
+
+	return file, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// writeGotextFile writes the catalog to root/locales/lang/name
+func writeGotextFile(t *testing.T, root, lang, name string, file GotextFile) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "locales", lang), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "locales", lang, name), data, 0644))
+}
+
+func TestTranslateGotext(t *testing.T) {
+	globalArgs = &args{Concurrency: 2}
+
+	root := t.TempDir()
+
+	// gotext update translates the messages of the source language to themselves
+	writeGotextFile(t, root, "en-US", gotextOutFile, GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{
+			{ID: "Hello", Message: Text{Msg: "Hello"}, Translation: Text{Msg: "Hello"}},
+			{ID: "Goodbye", Message: Text{Msg: "Goodbye"}, Translation: Text{Msg: "Goodbye"}},
+			{ID: "Welcome", Message: Text{Msg: "Welcome"}, Translation: Text{Msg: "Welcome"}},
+		},
+	})
+	writeGotextFile(t, root, "de-DE", gotextOutFile, GotextFile{
+		Language: "de-DE",
+		Messages: []GotextMessage{
+			{ID: "Hello", Message: Text{Msg: "Hello"}, Translation: Text{Msg: "Hallo"}},
+			{ID: "Goodbye", Message: Text{Msg: "Goodbye"}},
+			{ID: "Welcome", Message: Text{Msg: "Welcome"}},
+		},
+	})
+	writeGotextFile(t, root, "de-DE", gotextMessagesFile, GotextFile{
+		Language: "de-DE",
+		Messages: []GotextMessage{
+			{ID: "Welcome", Message: Text{Msg: "Welcome"}, Translation: Text{Msg: "Willkommen"}, TranslatorComment: "Reviewed"},
+			{ID: "Removed", Message: Text{Msg: "Removed"}, Translation: Text{Msg: "Entfernt"}},
+		},
+	})
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Goodbye", "de-DE")).Return("Auf Wiedersehen", nil)
+
+	l, err := newLayout(root, LayoutConfig{Pattern: "{root}/locales/{lang}/" + gotextOutFile})
+	require.NoError(t, err)
+
+	sourceLang, err := translateGotext(context.Background(), &pipeline{trans: mockTranslator}, l, []string{"de-DE"})
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", sourceLang)
+
+	file, err := readGotextFile(filepath.Join(root, "locales", "de-DE", gotextMessagesFile))
+	require.NoError(t, err)
+
+	assert.Equal(t, "de-DE", file.Language)
+	assert.Equal(t, []GotextMessage{
+		{ID: "Hello", Message: Text{Msg: "Hello"}, Translation: Text{Msg: "Hallo"}},
+		{ID: "Goodbye", Message: Text{Msg: "Goodbye"}, Translation: Text{Msg: "Auf Wiedersehen"}},
+		{ID: "Welcome", Message: Text{Msg: "Welcome"}, Translation: Text{Msg: "Willkommen"}, TranslatorComment: "Reviewed"},
+	}, file.Messages)
+
+	// The extracted catalog is left to gotext
+	out, err := readGotextFile(filepath.Join(root, "locales", "de-DE", gotextOutFile))
+	require.NoError(t, err)
+	assert.True(t, out.Messages[1].Translation.IsEmpty())
+
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 1)
+
+	_, err = translateGotext(context.Background(), &pipeline{trans: mockTranslator}, l, []string{"fr-FR"})
+	assert.ErrorContains(t, err, "fr-FR: no out.gotext.json found")
+}
@@ -2,21 +2,38 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/ksysoev/gotext-translator/pkg/translator"
 	"github.com/spf13/cobra"
 )
 
+const (
+	defaultCacheDir   = ".gotext-translator/cache"
+	defaultReportsDir = ".gotext-translator/reports"
+)
+
 type args struct {
-	version      string
-	LogLevel     string
-	ConfigPath   string
-	SourcePath   string
-	SourceDir    string
-	TargetLang   string
-	OutputPath   string
-	TextFormat   bool
-	ForceRewrite bool
+	version        string
+	LogLevel       string
+	ConfigPath     string
+	SourcePath     string
+	SourceDir      string
+	SourceLang     string
+	Layout         LayoutConfig
+	Gotext         GotextConfig
+	TargetLangs    []string
+	OutputPath     string
+	TextFormat     bool
+	ForceRewrite   bool
+	BatchSize      int
+	BatchMaxTokens int
+	Concurrency    int
+	NoCache        bool
+	CacheDir       string
+	ReportsDir     string
+	ProtectMarkup  bool
 }
 
 // InitCommands initializes and returns the root command for the application.
@@ -33,12 +50,22 @@ func InitCommands(version string) (*cobra.Command, error) {
 
 	cmd.AddCommand(translateCommand(args))
 	cmd.AddCommand(translateDirCommand(args))
+	cmd.AddCommand(generateCommand(args))
 	cmd.AddCommand(providersCommand())
+	cmd.AddCommand(cacheCommand(args))
+	cmd.AddCommand(memoryCommand(args))
 
 	cmd.PersistentFlags().StringVar(&args.ConfigPath, "config", "", "config file path")
 	cmd.PersistentFlags().StringVar(&args.LogLevel, "loglevel", "info", "log level (debug, info, warn, error)")
 	cmd.PersistentFlags().BoolVar(&args.TextFormat, "logtext", false, "log in text format, otherwise JSON")
 	cmd.PersistentFlags().BoolVar(&args.ForceRewrite, "force-rewrite", false, "force rewrite existing translations")
+	cmd.PersistentFlags().IntVar(&args.BatchSize, "batch-size", 20, "maximum number of messages translated in a single request, 1 disables batching")
+	cmd.PersistentFlags().IntVar(&args.Concurrency, "concurrency", 4, "maximum number of translation and evaluation requests running in parallel")
+	cmd.PersistentFlags().IntVar(&args.BatchMaxTokens, "batch-max-tokens", 2000, "estimated maximum number of tokens of the messages in a single request, 0 means no limit")
+	cmd.PersistentFlags().BoolVar(&args.NoCache, "no-cache", false, "do not read or store translations in the cache")
+	cmd.PersistentFlags().StringVar(&args.CacheDir, "cache-dir", defaultCacheDir, "directory of the translation cache")
+	cmd.PersistentFlags().StringVar(&args.ReportsDir, "reports-dir", defaultReportsDir, "directory of the candidates reports of ensembles")
+	cmd.PersistentFlags().BoolVar(&args.ProtectMarkup, "protect-markup", true, "replace HTML tags, URLs, emails and slash commands with tokens the LLM must keep")
 
 	return cmd, nil
 }
@@ -59,22 +86,22 @@ func translateCommand(args *args) *cobra.Command {
 				return fmt.Errorf("source file path is required")
 			}
 
-			if args.TargetLang == "" {
-				return fmt.Errorf("target language is required")
-			}
-
 			cfg, err := initConfig(args)
 			if err != nil {
 				return fmt.Errorf("failed to initialize config: %w", err)
 			}
 
+			if err := resolveTargetLangs(args, cfg); err != nil {
+				return err
+			}
+
 			return runTranslation(cmd.Context(), cfg)
 		},
 	}
 
 	cmd.Flags().StringVar(&args.SourcePath, "source", "", "source file path")
-	cmd.Flags().StringVar(&args.TargetLang, "target-lang", "", "target language (e.g., ru-RU)")
-	cmd.Flags().StringVar(&args.OutputPath, "output", "", "output file path (optional)")
+	cmd.Flags().StringSliceVar(&args.TargetLangs, "target-lang", nil, "target languages, comma separated or repeated (e.g., ru-RU,de-DE)")
+	cmd.Flags().StringVar(&args.OutputPath, "output", "", "output file path (optional), {lang} is replaced by the target language")
 
 	return cmd
 }
@@ -95,21 +122,69 @@ func translateDirCommand(args *args) *cobra.Command {
 				return fmt.Errorf("source directory path is required")
 			}
 
-			if args.TargetLang == "" {
-				return fmt.Errorf("target language is required")
-			}
-
 			cfg, err := initConfig(args)
 			if err != nil {
 				return fmt.Errorf("failed to initialize config: %w", err)
 			}
 
+			if err := resolveTargetLangs(args, cfg); err != nil {
+				return err
+			}
+
+			resolveDirConfig(args, cfg)
+
 			return runDirectoryTranslation(cmd.Context(), cfg)
 		},
 	}
 
 	cmd.Flags().StringVar(&args.SourceDir, "dir", "", "source directory path containing localization files")
-	cmd.Flags().StringVar(&args.TargetLang, "target-lang", "", "target language (e.g., ru-RU)")
+	cmd.Flags().StringVar(&args.SourceLang, "source-lang", "", "source language directory or catalog language (e.g., en-US), detected if not set")
+	cmd.Flags().StringVar(&args.Layout.Pattern, "layout", "", "path pattern of the catalogs (default \""+defaultLayoutPattern+"\")")
+	cmd.Flags().StringSliceVar(&args.Layout.Include, "include", nil, "globs of the catalog paths relative to the directory to translate, ** matches any directories")
+	cmd.Flags().StringSliceVar(&args.Layout.Exclude, "exclude", nil, "globs of the catalog paths relative to the directory to exclude (default \""+defaultLayoutExclude+"\" with the default layout)")
+	cmd.Flags().StringSliceVar(&args.TargetLangs, "target-lang", nil, "target languages, comma separated or repeated (e.g., ru-RU,de-DE)")
+	cmd.Flags().BoolVar(&args.Gotext.Enabled, "gotext", false, "translate the "+gotextOutFile+" of every locale extracted by gotext update into its "+gotextMessagesFile)
+	cmd.Flags().StringVar(&args.Gotext.GenFile, "gen-file", "", "Go file of the catalog regenerated after translating in gotext mode, relative to the directory")
+
+	return cmd
+}
+
+// generateCommand creates a cobra.Command to generate the catalog Go file of the translated files
+func generateCommand(args *args) *cobra.Command {
+	SetArgs(args) // Store args globally for generation use
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the catalog Go file",
+		Long:  "Generate the Go file of the message catalog from the translated gotext localization files, like gotext generate",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if err := initLogger(args); err != nil {
+				return fmt.Errorf("failed to initialize logger: %w", err)
+			}
+
+			if args.SourceDir == "" {
+				args.SourceDir = "."
+			}
+
+			cfg, err := initConfig(args)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+
+			resolveDirConfig(args, cfg)
+
+			return runGenerate(cfg)
+		},
+	}
+
+	cmd.Flags().StringVar(&args.SourceDir, "dir", "", "directory of the package and its localization files (default \".\")")
+	cmd.Flags().StringVar(&args.SourceLang, "source-lang", "", "source language directory or catalog language (e.g., en-US), detected if not set")
+	cmd.Flags().StringVar(&args.Layout.Pattern, "layout", "", "path pattern of the catalogs (default \""+defaultLayoutPattern+"\")")
+	cmd.Flags().StringSliceVar(&args.Layout.Include, "include", nil, "globs of the catalog paths relative to the directory, ** matches any directories")
+	cmd.Flags().StringSliceVar(&args.Layout.Exclude, "exclude", nil, "globs of the catalog paths relative to the directory to exclude (default \""+defaultLayoutExclude+"\" with the default layout)")
+	cmd.Flags().BoolVar(&args.Gotext.Enabled, "gotext", false, "generate from the "+gotextMessagesFile+" of every locale of gotext")
+	cmd.Flags().StringVar(&args.Gotext.GenFile, "gen-file", "", "Go file of the catalog relative to the directory (default \""+defaultGenFile+"\")")
+	cmd.Flags().StringVar(&args.Gotext.GenPackage, "gen-package", "", "package name of the catalog, by default the package of the Go files next to it")
+	cmd.Flags().StringSliceVar(&args.Gotext.Packages, "packages", nil, "Go packages of the messages relative to the directory (default \".\")")
 
 	return cmd
 }
@@ -142,3 +217,194 @@ func providersCommand() *cobra.Command {
 
 	return cmd
 }
+
+// cacheCommand creates a cobra.Command to manage the translation cache
+func cacheCommand(args *args) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the translation cache",
+		Long:  "Show statistics of the translation cache or remove all cached translations",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Show cache statistics",
+		Long:  "Show the location, number of entries and size of the translation cache",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			stats, err := translator.GetCacheStats(args.CacheDir)
+			if err != nil {
+				return fmt.Errorf("failed to read cache: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Cache file: %s\n", stats.Path)
+			fmt.Fprintf(cmd.OutOrStdout(), "Entries:    %d\n", stats.Entries)
+			fmt.Fprintf(cmd.OutOrStdout(), "Size:       %d bytes\n", stats.Size)
+
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all cached translations",
+		Long:  "Remove all translations stored in the translation cache",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := translator.ClearCache(args.CacheDir); err != nil {
+				return fmt.Errorf("failed to clear cache: %w", err)
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "Cache cleared")
+
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// memoryCommand creates a cobra.Command to import and export the translation memory
+func memoryCommand(args *args) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "memory",
+		Aliases: []string{"tm"},
+		Short:   "Manage the translation memory",
+		Long:    "Import approved translations from TMX files into the translation memory or export it as TMX",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "import FILE...",
+		Short: "Import TMX files",
+		Long:  "Import the translation units of TMX files into the translation memory, replacing stored translations of the same texts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, files []string) error {
+			cfg, err := initConfig(args)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+
+			mem, err := requireMemory(cfg)
+			if err != nil {
+				return err
+			}
+
+			for _, file := range files {
+				f, err := os.Open(file)
+				if err != nil {
+					return fmt.Errorf("failed to open TMX file: %w", err)
+				}
+
+				count, err := mem.Import(f)
+				_ = f.Close()
+
+				if err != nil {
+					return fmt.Errorf("failed to import %s: %w", file, err)
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "Imported %d translation units from %s\n", count, file)
+			}
+
+			if err := mem.Save(); err != nil {
+				return fmt.Errorf("failed to save translation memory: %w", err)
+			}
+
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export FILE",
+		Short: "Export the translation memory as TMX",
+		Long:  "Write all translation units of the translation memory to a TMX 1.4 file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, files []string) error {
+			cfg, err := initConfig(args)
+			if err != nil {
+				return fmt.Errorf("failed to initialize config: %w", err)
+			}
+
+			mem, err := requireMemory(cfg)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Create(files[0])
+			if err != nil {
+				return fmt.Errorf("failed to create TMX file: %w", err)
+			}
+
+			if err := mem.Export(f); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("failed to export translation memory: %w", err)
+			}
+
+			if err := f.Close(); err != nil {
+				return fmt.Errorf("failed to write TMX file: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Exported %d translation units to %s\n", mem.Len(), files[0])
+
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// resolveTargetLangs takes the target languages from the config file if none are given as flags
+func resolveTargetLangs(args *args, cfg *Config) error {
+	if len(args.TargetLangs) == 0 {
+		args.TargetLangs = cfg.TargetLangs
+	}
+
+	if len(args.TargetLangs) == 0 {
+		return fmt.Errorf("target language is required")
+	}
+
+	seen := make(map[string]bool)
+
+	for _, lang := range args.TargetLangs {
+		if lang == "" || seen[lang] {
+			return fmt.Errorf("invalid target languages %q", strings.Join(args.TargetLangs, ","))
+		}
+
+		seen[lang] = true
+	}
+
+	return nil
+}
+
+// resolveDirConfig applies the flags of the directory commands to the config, flags take precedence over
+// the config file
+func resolveDirConfig(args *args, cfg *Config) {
+	if args.SourceLang == "" {
+		args.SourceLang = cfg.SourceLang
+	}
+
+	if args.Layout.Pattern != "" {
+		cfg.Layout.Pattern = args.Layout.Pattern
+	}
+
+	if args.Layout.Include != nil {
+		cfg.Layout.Include = args.Layout.Include
+	}
+
+	if args.Layout.Exclude != nil {
+		cfg.Layout.Exclude = args.Layout.Exclude
+	}
+
+	if args.Gotext.Enabled {
+		cfg.Gotext.Enabled = true
+	}
+
+	if args.Gotext.GenFile != "" {
+		cfg.Gotext.GenFile = args.Gotext.GenFile
+	}
+
+	if args.Gotext.GenPackage != "" {
+		cfg.Gotext.GenPackage = args.Gotext.GenPackage
+	}
+
+	if args.Gotext.Packages != nil {
+		cfg.Gotext.Packages = args.Gotext.Packages
+	}
+}
@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// defaultLayoutPattern is the layout of the catalogs of translate-dir unless configured otherwise
+	defaultLayoutPattern = "{root}/locales/{lang}/{path}.gotext.json"
+	// defaultLayoutExclude excludes the files generated by gotext from the default layout
+	defaultLayoutExclude = "**/out.gotext.json"
+)
+
+// LayoutConfig configures where translate-dir finds the catalogs of every language
+type LayoutConfig struct {
+	// Pattern is the path of a catalog, {root} is the directory given to translate-dir, {lang} the
+	// language and {path} any path, e.g. "{root}/i18n/{lang}.json"
+	Pattern string `mapstructure:"pattern"`
+	// Include and Exclude are globs of the catalog paths relative to the root, "**" matches any number
+	// of directories. Catalogs must match an include glob, if any, and no exclude glob.
+	Include []string `mapstructure:"include"`
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// catalog is a catalog file found by a layout
+type catalog struct {
+	// file is the path of the catalog
+	file string
+	// lang is the language of the catalog in its path
+	lang string
+	// rel is the value of {path} in the path of the catalog
+	rel string
+}
+
+// layout maps the catalogs of every language to their paths
+type layout struct {
+	root    string
+	pattern string
+	re      *regexp.Regexp
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// newLayout creates the layout of the catalogs under root
+func newLayout(root string, cfg LayoutConfig) (*layout, error) {
+	pattern := cfg.Pattern
+	exclude := cfg.Exclude
+
+	if pattern == "" {
+		pattern = defaultLayoutPattern
+
+		if exclude == nil {
+			exclude = []string{defaultLayoutExclude}
+		}
+	}
+
+	// Paths are matched relative to the root
+	pattern = strings.TrimPrefix(strings.TrimPrefix(filepath.ToSlash(pattern), "{root}"), "/")
+
+	if strings.Count(pattern, "{lang}") != 1 {
+		return nil, fmt.Errorf("layout pattern %q must contain {lang} once", cfg.Pattern)
+	}
+
+	if strings.Count(pattern, "{path}") > 1 {
+		return nil, fmt.Errorf("layout pattern %q must contain {path} at most once", cfg.Pattern)
+	}
+
+	var expr strings.Builder
+
+	expr.WriteString("^")
+
+	for rest := pattern; rest != ""; {
+		switch {
+		case strings.HasPrefix(rest, "{lang}"):
+			expr.WriteString("(?P<lang>[^/]+)")
+			rest = rest[len("{lang}"):]
+		case strings.HasPrefix(rest, "{path}"):
+			expr.WriteString("(?P<path>.+)")
+			rest = rest[len("{path}"):]
+		default:
+			next := strings.Index(rest[1:], "{") + 1
+			if next == 0 {
+				next = len(rest)
+			}
+
+			expr.WriteString(regexp.QuoteMeta(rest[:next]))
+			rest = rest[next:]
+		}
+	}
+
+	expr.WriteString("$")
+
+	l := &layout{
+		root:    root,
+		pattern: pattern,
+		re:      regexp.MustCompile(expr.String()),
+	}
+
+	for _, glob := range cfg.Include {
+		l.include = append(l.include, globRegexp(glob))
+	}
+
+	for _, glob := range exclude {
+		l.exclude = append(l.exclude, globRegexp(glob))
+	}
+
+	return l, nil
+}
+
+// globRegexp converts the glob to a regular expression, "**" matches any number of directories
+func globRegexp(glob string) *regexp.Regexp {
+	glob = strings.TrimPrefix(filepath.ToSlash(glob), "/")
+
+	var expr strings.Builder
+
+	expr.WriteString("^")
+
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("[^/]*")
+		case glob[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.MustCompile(expr.String())
+}
+
+// find returns the catalogs of all languages
+func (l *layout) find() ([]catalog, error) {
+	// Only the directory before the first placeholder needs to be walked
+	static := l.pattern[:strings.Index(l.pattern, "{")]
+	baseDir := filepath.Join(l.root, filepath.FromSlash(path.Dir(static+"x")))
+
+	if _, err := os.Stat(baseDir); err != nil {
+		return nil, fmt.Errorf("failed to read base directory: %w", err)
+	}
+
+	var catalogs []catalog
+
+	err := filepath.Walk(baseDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, file)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		// Reports of earlier versions were written next to the catalogs
+		if strings.HasSuffix(rel, candidatesReportSuffix) {
+			return nil
+		}
+
+		m := l.re.FindStringSubmatch(rel)
+		if m == nil || !l.selected(rel) {
+			return nil
+		}
+
+		c := catalog{file: file, lang: m[l.re.SubexpIndex("lang")]}
+		if i := l.re.SubexpIndex("path"); i >= 0 {
+			c.rel = m[i]
+		}
+
+		catalogs = append(catalogs, c)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find source files: %w", err)
+	}
+
+	return catalogs, nil
+}
+
+// selected reports whether the path relative to the root passes the include and exclude globs
+func (l *layout) selected(rel string) bool {
+	if len(l.include) > 0 && !matchAny(l.include, rel) {
+		return false
+	}
+
+	return !matchAny(l.exclude, rel)
+}
+
+// targetPath returns the path of the catalog in the target language
+func (l *layout) targetPath(src catalog, lang string) string {
+	target := strings.ReplaceAll(l.pattern, "{lang}", lang)
+	target = strings.ReplaceAll(target, "{path}", src.rel)
+
+	return filepath.Join(l.root, filepath.FromSlash(target))
+}
+
+// matchAny reports whether any of the expressions matches s
+func matchAny(exprs []*regexp.Regexp, s string) bool {
+	for _, re := range exprs {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
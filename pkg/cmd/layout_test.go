@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// touch creates the files under dir
+func touch(t *testing.T, dir string, files ...string) {
+	t.Helper()
+
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("{}"), 0644))
+	}
+}
+
+// relCatalogs returns the catalogs with their files relative to the root
+func relCatalogs(t *testing.T, root string, catalogs []catalog) []catalog {
+	t.Helper()
+
+	for i := range catalogs {
+		rel, err := filepath.Rel(root, catalogs[i].file)
+		require.NoError(t, err)
+
+		catalogs[i].file = filepath.ToSlash(rel)
+	}
+
+	return catalogs
+}
+
+func TestLayout_Default(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root,
+		"locales/en-US/messages.gotext.json",
+		"locales/en-US/out.gotext.json",
+		"locales/en-US/admin/errors.gotext.json",
+		"locales/README.md",
+		"other/en-US/messages.gotext.json",
+	)
+
+	l, err := newLayout(root, LayoutConfig{})
+	require.NoError(t, err)
+
+	catalogs, err := l.find()
+	require.NoError(t, err)
+
+	assert.Equal(t, []catalog{
+		{file: "locales/en-US/admin/errors.gotext.json", lang: "en-US", rel: "admin/errors"},
+		{file: "locales/en-US/messages.gotext.json", lang: "en-US", rel: "messages"},
+	}, relCatalogs(t, root, catalogs))
+
+	assert.Equal(t, filepath.Join(root, "locales", "fr-FR", "admin", "errors.gotext.json"), l.targetPath(catalogs[0], "fr-FR"))
+}
+
+func TestLayout_Patterns(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root,
+		"internal/translations/locales/en-US/out.gotext.json",
+		"internal/translations/locales/de-DE/out.gotext.json",
+		"i18n/en.json",
+		"i18n/legacy/en.json",
+	)
+
+	// The default layout of gotext update
+	l, err := newLayout(root, LayoutConfig{Pattern: "{root}/internal/translations/locales/{lang}/out.gotext.json"})
+	require.NoError(t, err)
+
+	catalogs, err := l.find()
+	require.NoError(t, err)
+
+	assert.Equal(t, []catalog{
+		{file: "internal/translations/locales/de-DE/out.gotext.json", lang: "de-DE"},
+		{file: "internal/translations/locales/en-US/out.gotext.json", lang: "en-US"},
+	}, relCatalogs(t, root, catalogs))
+
+	assert.Equal(t, filepath.Join(root, "internal", "translations", "locales", "ja-JP", "out.gotext.json"), l.targetPath(catalogs[0], "ja-JP"))
+
+	// A file per language, the language does not match directories
+	l, err = newLayout(root, LayoutConfig{Pattern: "i18n/{lang}.json"})
+	require.NoError(t, err)
+
+	catalogs, err = l.find()
+	require.NoError(t, err)
+
+	assert.Equal(t, []catalog{{file: "i18n/en.json", lang: "en"}}, relCatalogs(t, root, catalogs))
+	assert.Equal(t, filepath.Join(root, "i18n", "fr.json"), l.targetPath(catalogs[0], "fr"))
+
+	_, err = newLayout(root, LayoutConfig{Pattern: "i18n/messages.json"})
+	assert.ErrorContains(t, err, "must contain {lang} once")
+
+	_, err = newLayout(root, LayoutConfig{Pattern: "{lang}/{path}/{path}.json"})
+	assert.ErrorContains(t, err, "must contain {path} at most once")
+
+	l, err = newLayout(root, LayoutConfig{Pattern: "missing/{lang}.json"})
+	require.NoError(t, err)
+
+	_, err = l.find()
+	assert.ErrorContains(t, err, "failed to read base directory")
+}
+
+func TestLayout_IncludeExclude(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root,
+		"locales/en-US/messages.gotext.json",
+		"locales/en-US/out.gotext.json",
+		"locales/en-US/admin/errors.gotext.json",
+		"locales/en-US/admin/drafts/new.gotext.json",
+	)
+
+	l, err := newLayout(root, LayoutConfig{
+		Include: []string{"locales/*/admin/**"},
+		Exclude: []string{"**/drafts/**"},
+	})
+	require.NoError(t, err)
+
+	catalogs, err := l.find()
+	require.NoError(t, err)
+
+	assert.Equal(t, []catalog{
+		{file: "locales/en-US/admin/errors.gotext.json", lang: "en-US", rel: "admin/errors"},
+	}, relCatalogs(t, root, catalogs))
+
+	// Configured excludes replace the default one
+	l, err = newLayout(root, LayoutConfig{Exclude: []string{}})
+	require.NoError(t, err)
+
+	catalogs, err = l.find()
+	require.NoError(t, err)
+	assert.Len(t, catalogs, 4)
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		match      bool
+	}{
+		{"**/out.gotext.json", "out.gotext.json", true},
+		{"**/out.gotext.json", "locales/en-US/out.gotext.json", true},
+		{"**/out.gotext.json", "locales/en-US/about.gotext.json", false},
+		{"locales/*/messages.gotext.json", "locales/en-US/messages.gotext.json", true},
+		{"locales/*/messages.gotext.json", "locales/en-US/admin/messages.gotext.json", false},
+		{"locales/**", "locales/en-US/admin/messages.gotext.json", true},
+		{"i18n/??.json", "i18n/en.json", true},
+		{"i18n/??.json", "i18n/en-US.json", false},
+		{"i18n/en+gb.json", "i18n/en+gb.json", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, globRegexp(tt.glob).MatchString(tt.path), "%s %s", tt.glob, tt.path)
+	}
+}
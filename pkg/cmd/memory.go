@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+// openMemory opens the translation memory configured in cfg, it returns nil if the memory is disabled
+func openMemory(cfg *Config) (*translator.Memory, error) {
+	if cfg.Memory.Path == "" {
+		return nil, nil
+	}
+
+	mem, err := translator.OpenMemory(cfg.Memory.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Debug("translation memory opened", slog.String("path", cfg.Memory.Path), slog.Int("units", mem.Len()))
+
+	return mem, nil
+}
+
+// saveMemory stores the translations added to the memory during the run
+func saveMemory(mem *translator.Memory) {
+	if mem == nil {
+		return
+	}
+
+	if err := mem.Save(); err != nil {
+		slog.Error("failed to save translation memory", slog.String("error", err.Error()))
+	}
+}
+
+// updateMemory adds the approved translations of the messages to the memory. Messages with select
+// statements are skipped, since their cases depend on the plural rules of the languages, and so are
+// fuzzy translations, which still need a review.
+func updateMemory(mem *translator.Memory, messages []GotextMessage, sourceLang, targetLang string) {
+	if mem == nil {
+		return
+	}
+
+	for _, msg := range messages {
+		if msg.Fuzzy || msg.Message.Select != nil || msg.Translation.Select != nil || msg.Translation.Msg == "" {
+			continue
+		}
+
+		mem.Add(sourceLang, targetLang, msg.Message.Msg, msg.Translation.Msg)
+	}
+}
+
+// requireMemory opens the translation memory for the memory subcommands, which need it configured
+func requireMemory(cfg *Config) (*translator.Memory, error) {
+	if cfg.Memory.Path == "" {
+		return nil, fmt.Errorf("translation memory is not configured, set memory.path in the config file")
+	}
+
+	return openMemory(cfg)
+}
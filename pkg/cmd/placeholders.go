@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxPlaceholderAttempts is the number of times a message is sent for translation
+// before it is rejected because of broken placeholders.
+const maxPlaceholderAttempts = 3
+
+var (
+	// printfVerbRe matches Go printf verbs, including the escaped percent sign. The space flag and the
+	// letters that are not verbs are left out, so a literal percent sign followed by a word, like
+	// "50 % off" or "20%ige", stays text.
+	printfVerbRe = regexp.MustCompile(`%(?:%|([+\-#0]*)(?:\[(\d+)\])?(\d+|\*)?(?:\.(\d+|\*)?)?(?:\[(\d+)\])?([bcdeEfFgGoOpqstTUvxX]))`)
+	// placeholderRefRe matches gotext placeholder references like {Name}.
+	placeholderRefRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// PlaceholderError describes placeholders that were lost, duplicated or altered by a translation.
+type PlaceholderError struct {
+	Missing    []string
+	Duplicated []string
+	Unexpected []string
+}
+
+// Error returns a human readable description of the placeholder mismatch.
+func (e *PlaceholderError) Error() string {
+	var parts []string
+
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing placeholders "+strings.Join(e.Missing, ", "))
+	}
+
+	if len(e.Duplicated) > 0 {
+		parts = append(parts, "duplicated placeholders "+strings.Join(e.Duplicated, ", "))
+	}
+
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, "unexpected placeholders "+strings.Join(e.Unexpected, ", "))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// validatePlaceholders checks that the translation contains exactly the same placeholders as the
+// source text. Printf verbs are compared with their flags, width, precision and argument index, and
+// {Name} references are resolved to the printf verb of the matching placeholder, so both forms are
+// interchangeable. Messages with placeholders only use the printf verbs of their placeholders, so any
+// other percent sign in them is literal text.
+func validatePlaceholders(source, translation string, placeholders []Placeholder) error {
+	expected := countPlaceholders(source, placeholders)
+	actual := countPlaceholders(translation, placeholders)
+
+	var phErr PlaceholderError
+
+	for token, n := range expected {
+		switch m := actual[token]; {
+		case m < n:
+			phErr.Missing = append(phErr.Missing, token)
+		case m > n:
+			phErr.Duplicated = append(phErr.Duplicated, token)
+		}
+	}
+
+	for token := range actual {
+		if _, ok := expected[token]; !ok {
+			phErr.Unexpected = append(phErr.Unexpected, token)
+		}
+	}
+
+	if len(phErr.Missing) == 0 && len(phErr.Duplicated) == 0 && len(phErr.Unexpected) == 0 {
+		return nil
+	}
+
+	sort.Strings(phErr.Missing)
+	sort.Strings(phErr.Duplicated)
+	sort.Strings(phErr.Unexpected)
+
+	return &phErr
+}
+
+// countPlaceholders returns the number of occurrences of every normalized placeholder in the text.
+func countPlaceholders(text string, placeholders []Placeholder) map[string]int {
+	byID := make(map[string]string, len(placeholders))
+	for _, ph := range placeholders {
+		if ph.ID != "" && ph.String != "" {
+			byID[ph.ID] = ph.String
+		}
+	}
+
+	counts := make(map[string]int)
+
+	text = placeholderRefRe.ReplaceAllStringFunc(text, func(ref string) string {
+		name := ref[1 : len(ref)-1]
+
+		verb, ok := byID[name]
+		if !ok {
+			counts[ref]++
+			return ""
+		}
+
+		for _, token := range parsePrintfVerbs(verb) {
+			counts[token]++
+		}
+
+		return ""
+	})
+
+	for _, token := range parsePrintfVerbs(text) {
+		counts[token]++
+	}
+
+	return counts
+}
+
+// parsePrintfVerbs returns the printf verbs of the text normalized to the explicit
+// argument index form, e.g. "%d %s" becomes ["%[1]d", "%[2]s"].
+func parsePrintfVerbs(text string) []string {
+	var tokens []string
+
+	argNum := 1
+
+	for _, m := range printfVerbRe.FindAllStringSubmatch(text, -1) {
+		if m[0] == "%%" {
+			continue
+		}
+
+		flags, width, prec, verb := m[1], m[3], m[4], m[6]
+
+		index := m[5]
+		if index == "" {
+			index = m[2]
+		}
+
+		if index != "" {
+			if n, err := strconv.Atoi(index); err == nil {
+				argNum = n
+			}
+		}
+
+		token := "%" + flags + width
+		if strings.Contains(m[0], ".") {
+			token += "." + prec
+		}
+
+		tokens = append(tokens, fmt.Sprintf("%s[%d]%s", token, argNum, verb))
+		argNum++
+	}
+
+	return tokens
+}
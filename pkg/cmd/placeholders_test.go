@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParsePrintfVerbs(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"no verbs here", nil},
+		{"100%% sure", nil},
+		{"100% sure", nil},
+		{"50 % off", nil},
+		{"20%ige", nil},
+		{"%d of %s", []string{"%[1]d", "%[2]s"}},
+		{"%[2]s then %[1]d", []string{"%[2]s", "%[1]d"}},
+		{"%[2]s then %d", []string{"%[2]s", "%[3]d"}},
+		{"%-5.2f", []string{"%-5.2[1]f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parsePrintfVerbs(tt.text))
+		})
+	}
+}
+
+func TestValidatePlaceholders(t *testing.T) {
+	placeholders := []Placeholder{
+		{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", ArgNum: 1},
+	}
+
+	tests := []struct {
+		name         string
+		source       string
+		translation  string
+		placeholders []Placeholder
+		errContains  string
+	}{
+		{
+			name:        "preserved reference",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "Пожалуйста, отправьте не более {MaxAllowedPhotos} фото",
+		},
+		{
+			name:        "reference replaced by equivalent verb",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "Пожалуйста, отправьте не более %[1]d фото",
+		},
+		{
+			name:        "missing reference",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "Пожалуйста, отправьте меньше фото",
+			errContains: "missing placeholders %[1]d",
+		},
+		{
+			name:        "duplicated reference",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "{MaxAllowedPhotos} фото, не более {MaxAllowedPhotos}",
+			errContains: "duplicated placeholders %[1]d",
+		},
+		{
+			name:        "altered verb",
+			source:      "You have %[1]d new messages",
+			translation: "У вас %[1]s новых сообщений",
+			errContains: "missing placeholders %[1]d",
+		},
+		{
+			name:         "altered verb without placeholders",
+			source:       "You have %[1]d new messages",
+			translation:  "У вас %[1]s новых сообщений",
+			placeholders: []Placeholder{},
+			errContains:  "unexpected placeholders %[1]s",
+		},
+		{
+			name:         "literal percent",
+			source:       "100% sure",
+			translation:  "Уверен на 100%",
+			placeholders: []Placeholder{},
+		},
+		{
+			name:         "literal percent before a word",
+			source:       "50 % off",
+			translation:  "50 % de réduction",
+			placeholders: []Placeholder{},
+		},
+		{
+			name:        "literal percent next to a reference",
+			source:      "Save {MaxAllowedPhotos}%",
+			translation: "{MaxAllowedPhotos}%ige Ersparnis",
+		},
+		{
+			name:        "added verb",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "Пожалуйста, отправьте не более {MaxAllowedPhotos} фото %s",
+			errContains: "unexpected placeholders %[1]s",
+		},
+		{
+			name:        "added indexed verb",
+			source:      "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			translation: "Пожалуйста, отправьте не более {MaxAllowedPhotos} из %[2]d фото",
+			errContains: "unexpected placeholders %[2]d",
+		},
+		{
+			name:        "escaped percent next to a reference",
+			source:      "Save {MaxAllowedPhotos}%%",
+			translation: "Sparen Sie {MaxAllowedPhotos}%%",
+		},
+		{
+			name:        "translated reference name",
+			source:      "Hello, {Name}",
+			translation: "Привет, {Имя}",
+			errContains: "missing placeholders {Name}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			phs := placeholders
+			if tt.placeholders != nil {
+				phs = tt.placeholders
+			}
+
+			err := validatePlaceholders(tt.source, tt.translation, phs)
+			if tt.errContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestTranslateMessage_RejectsBrokenPlaceholders(t *testing.T) {
+	globalArgs = &args{}
+
+	source := "Please, provide no more than {MaxAllowedPhotos} photo(s)"
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest(source, "ru-RU")).
+		Return("Пожалуйста, отправьте меньше фото", nil).Times(maxPlaceholderAttempts)
+
+	msg := &GotextMessage{
+		ID:      source,
+		Message: Text{Msg: source},
+		Placeholders: []Placeholder{
+			{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", ArgNum: 1},
+		},
+	}
+
+	err := translateMessage(context.Background(), mockTranslator, msg, translator.Request{TargetLang: "ru-RU"})
+	assert.Error(t, err)
+	assert.True(t, msg.Translation.IsEmpty())
+	assert.True(t, msg.Fuzzy)
+	assert.Contains(t, msg.TranslatorComment, "missing placeholders %[1]d")
+	mockTranslator.AssertNumberOfCalls(t, "Translate", maxPlaceholderAttempts)
+
+	// A valid retry clears the rejection
+	mockTranslator = new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest(source, "ru-RU")).
+		Return("Пожалуйста, отправьте меньше фото", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, translationRequest(source, "ru-RU")).
+		Return("Пожалуйста, отправьте не более {MaxAllowedPhotos} фото", nil).Once()
+
+	err = translateMessage(context.Background(), mockTranslator, msg, translator.Request{TargetLang: "ru-RU"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Пожалуйста, отправьте не более {MaxAllowedPhotos} фото", msg.Translation.Msg)
+	assert.False(t, msg.Fuzzy)
+	assert.Empty(t, msg.TranslatorComment)
+}
+
+func TestTranslateMessage_ForceRewriteKeepsTranslation(t *testing.T) {
+	globalArgs = &args{ForceRewrite: true}
+
+	source := "Please, provide no more than {MaxAllowedPhotos} photo(s)"
+	existing := "Пожалуйста, отправьте не более {MaxAllowedPhotos} фото"
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest(source, "ru-RU")).
+		Return("Пожалуйста, отправьте не более %[1]s фото", nil).Times(maxPlaceholderAttempts)
+
+	msg := &GotextMessage{
+		ID:          source,
+		Message:     Text{Msg: source},
+		Translation: Text{Msg: existing},
+		Placeholders: []Placeholder{
+			{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", ArgNum: 1},
+		},
+	}
+
+	err := translateMessage(context.Background(), mockTranslator, msg, translator.Request{TargetLang: "ru-RU"})
+	assert.Error(t, err)
+	assert.Equal(t, existing, msg.Translation.Msg)
+	assert.True(t, msg.Fuzzy)
+	assert.Contains(t, msg.TranslatorComment, rejectedCommentPrefix)
+	mockTranslator.AssertExpectations(t)
+}
+
+func TestTranslateMessage_BrokenMarkup(t *testing.T) {
+	globalArgs = &args{}
+
+	source := "Use /editprofile to update <b>your profile</b>"
+	masked := "Use ⟦1⟧ to update ⟦2⟧your profile⟦3⟧"
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest(masked, "de-DE")).
+		Return("Verwenden Sie /profilbearbeiten, um ⟦2⟧Ihr Profil⟦3⟧ zu aktualisieren", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, translationRequest(masked, "de-DE")).
+		Return("Verwenden Sie ⟦1⟧, um ⟦2⟧Ihr Profil⟦3⟧ zu aktualisieren", nil).Once()
+
+	msg := &GotextMessage{ID: source, Message: Text{Msg: source}}
+
+	// The first translation lost the command and is retried
+	err := translateMessage(context.Background(), translator.NewMarkupProtection(mockTranslator), msg, translator.Request{TargetLang: "de-DE"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Verwenden Sie /editprofile, um <b>Ihr Profil</b> zu aktualisieren", msg.Translation.Msg)
+
+	mockTranslator = new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest(masked, "de-DE")).
+		Return("Verwenden Sie ⟦1⟧, um ⟦3⟧Ihr Profil⟦2⟧ zu aktualisieren", nil).Times(maxPlaceholderAttempts)
+
+	msg = &GotextMessage{ID: source, Message: Text{Msg: source}}
+
+	err = translateMessage(context.Background(), translator.NewMarkupProtection(mockTranslator), msg, translator.Request{TargetLang: "de-DE"})
+	assert.Error(t, err)
+	assert.True(t, msg.Translation.IsEmpty())
+	assert.True(t, msg.Fuzzy)
+	assert.Equal(t, "Machine translation rejected: unbalanced tag </b>", msg.TranslatorComment)
+}
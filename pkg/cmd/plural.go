@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+const (
+	pluralFeature = "plural"
+	otherCategory = "other"
+
+	// pluralSampleSize is the amount of integers checked to find the plural categories
+	// used by a language. CLDR rules repeat well within this range.
+	pluralSampleSize = 1000
+)
+
+// pluralCategoryOrder lists CLDR plural categories in their canonical order
+var pluralCategoryOrder = []struct {
+	form plural.Form
+	name string
+}{
+	{plural.Zero, "zero"},
+	{plural.One, "one"},
+	{plural.Two, "two"},
+	{plural.Few, "few"},
+	{plural.Many, "many"},
+	{plural.Other, otherCategory},
+}
+
+// pluralCategories returns the CLDR cardinal plural categories used by the language
+// for integer values. The "other" category is always included as gotext requires it
+// as a fallback.
+func pluralCategories(lang string) ([]string, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return nil, fmt.Errorf("invalid language %q: %w", lang, err)
+	}
+
+	used := map[plural.Form]bool{plural.Other: true}
+	for i := 0; i < pluralSampleSize; i++ {
+		used[plural.Cardinal.MatchPlural(tag, i, 0, 0, 0, 0)] = true
+	}
+
+	categories := make([]string, 0, len(used))
+	for _, c := range pluralCategoryOrder {
+		if used[c.form] {
+			categories = append(categories, c.name)
+		}
+	}
+
+	return categories, nil
+}
+
+// isPluralCategory reports whether the select case is a CLDR plural category
+// rather than an explicit selector like "=0" or "<5".
+func isPluralCategory(c string) bool {
+	for _, cat := range pluralCategoryOrder {
+		if cat.name == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// translateText translates the source text to the target language. Plain messages are
+// translated as is, while for select messages every case is translated separately. For
+// plural selects the cases are rebuilt to match the plural categories of the target language.
+func translateText(ctx context.Context, trans translator.Translator, src Text, placeholders []Placeholder, req translator.Request) (Text, error) {
+	var dst Text
+
+	if src.Msg != "" {
+		msg, err := translateString(ctx, trans, src.Msg, placeholders, req)
+		if err != nil {
+			return Text{}, err
+		}
+
+		dst.Msg = msg
+	}
+
+	if src.Select != nil {
+		sel, err := translateSelect(ctx, trans, src.Select, placeholders, req)
+		if err != nil {
+			return Text{}, err
+		}
+
+		dst.Select = sel
+	}
+
+	if src.Var != nil {
+		dst.Var = make(map[string]Text, len(src.Var))
+
+		for name, v := range src.Var {
+			translated, err := translateText(ctx, trans, v, placeholders, req)
+			if err != nil {
+				return Text{}, fmt.Errorf("failed to translate variable %s: %w", name, err)
+			}
+
+			dst.Var[name] = translated
+		}
+	}
+
+	return dst, nil
+}
+
+// translateString translates a plain string and validates that the placeholders and the protected
+// markup survived the translation. Broken translations are retried up to maxPlaceholderAttempts
+// times before a PlaceholderError or translator.MarkupError is returned.
+func translateString(ctx context.Context, trans translator.Translator, text string, placeholders []Placeholder, req translator.Request) (string, error) {
+	var lastErr error
+
+	req.Text = text
+
+	// Ensembles drop the candidates that would be rejected below before selecting the translation
+	ctx = translator.WithValidator(ctx, func(r translator.Request, translation string) error {
+		return validatePlaceholders(r.Text, translation, placeholders)
+	})
+
+	for attempt := 1; attempt <= maxPlaceholderAttempts; attempt++ {
+		attemptCtx := ctx
+		if attempt > 1 {
+			// Do not serve the rejected translation from the cache again
+			attemptCtx = translator.BypassCache(ctx)
+		}
+
+		translation, err := trans.Translate(attemptCtx, req)
+
+		var markupErr *translator.MarkupError
+
+		switch {
+		case errors.As(err, &markupErr):
+			lastErr = err
+		case err != nil:
+			return "", err
+		default:
+			if lastErr = validatePlaceholders(text, translation, placeholders); lastErr == nil {
+				return translation, nil
+			}
+		}
+
+		slog.Debug("translation rejected",
+			slog.String("text", text),
+			slog.String("translation", translation),
+			slog.Int("attempt", attempt),
+			slog.String("reason", lastErr.Error()))
+	}
+
+	return "", lastErr
+}
+
+// translateSelect translates all cases of a select statement.
+func translateSelect(ctx context.Context, trans translator.Translator, src *Select, placeholders []Placeholder, req translator.Request) (*Select, error) {
+	sources, err := selectCaseSources(src, req.TargetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	dst := &Select{
+		Feature: src.Feature,
+		Arg:     src.Arg,
+		Cases:   make(map[string]Text, len(sources)),
+	}
+
+	for c, srcCase := range sources {
+		caseReq := req
+		if src.Feature == pluralFeature && isPluralCategory(c) {
+			caseReq.PluralCategory = c
+		}
+
+		translated, err := translateText(ctx, trans, src.Cases[srcCase], placeholders, caseReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to translate case %s: %w", c, err)
+		}
+
+		dst.Cases[c] = translated
+	}
+
+	return dst, nil
+}
+
+// selectCaseSources maps every case of the target select to the source case it is translated from.
+// For plural selects explicit selectors are kept and the CLDR categories of the target language
+// are filled from the matching source category, falling back to "other".
+func selectCaseSources(src *Select, targetLang string) (map[string]string, error) {
+	sources := make(map[string]string, len(src.Cases))
+
+	if src.Feature != pluralFeature {
+		for c := range src.Cases {
+			sources[c] = c
+		}
+
+		return sources, nil
+	}
+
+	categories, err := pluralCategories(targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := otherCategory
+	if _, ok := src.Cases[fallback]; !ok {
+		cases := make([]string, 0, len(src.Cases))
+		for c := range src.Cases {
+			cases = append(cases, c)
+		}
+
+		if len(cases) == 0 {
+			return nil, fmt.Errorf("plural select for %s has no cases", src.Arg)
+		}
+
+		sort.Strings(cases)
+		fallback = cases[0]
+	}
+
+	for c := range src.Cases {
+		if !isPluralCategory(c) {
+			sources[c] = c
+		}
+	}
+
+	for _, c := range categories {
+		if _, ok := src.Cases[c]; ok {
+			sources[c] = c
+		} else {
+			sources[c] = fallback
+		}
+	}
+
+	return sources, nil
+}
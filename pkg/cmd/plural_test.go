@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPluralCategories(t *testing.T) {
+	tests := []struct {
+		lang     string
+		expected []string
+	}{
+		{"en-GB", []string{"one", "other"}},
+		{"ru-RU", []string{"one", "few", "many", "other"}},
+		{"ja-JP", []string{"other"}},
+		{"ar", []string{"zero", "one", "two", "few", "many", "other"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			categories, err := pluralCategories(tt.lang)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, categories)
+		})
+	}
+
+	_, err := pluralCategories("not a language")
+	assert.Error(t, err)
+}
+
+func TestTranslateText_Plain(t *testing.T) {
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Unknown command", "ru-RU")).
+		Return("Неизвестная команда", nil)
+
+	result, err := translateText(context.Background(), mockTranslator, Text{Msg: "Unknown command"}, nil, translator.Request{TargetLang: "ru-RU"})
+	assert.NoError(t, err)
+	assert.Equal(t, Text{Msg: "Неизвестная команда"}, result)
+}
+
+func TestTranslateText_Plural(t *testing.T) {
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("No photos", "ru-RU")).
+		Return("Нет фотографий", nil)
+	mockTranslator.On("Translate", mock.Anything, pluralRequest("%[1]d photo", "ru-RU", "one")).
+		Return("%[1]d фотография", nil)
+	mockTranslator.On("Translate", mock.Anything, pluralRequest("%[1]d photos", "ru-RU", "few")).
+		Return("%[1]d фотографии", nil)
+	mockTranslator.On("Translate", mock.Anything, pluralRequest("%[1]d photos", "ru-RU", "many")).
+		Return("%[1]d фотографий", nil)
+	mockTranslator.On("Translate", mock.Anything, pluralRequest("%[1]d photos", "ru-RU", "other")).
+		Return("%[1]d фотографии", nil)
+
+	src := Text{
+		Select: &Select{
+			Feature: "plural",
+			Arg:     "Count",
+			Cases: map[string]Text{
+				"=0":    {Msg: "No photos"},
+				"one":   {Msg: "%[1]d photo"},
+				"other": {Msg: "%[1]d photos"},
+			},
+		},
+	}
+
+	result, err := translateText(context.Background(), mockTranslator, src, nil, translator.Request{TargetLang: "ru-RU"})
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Select)
+	assert.Equal(t, "plural", result.Select.Feature)
+	assert.Equal(t, "Count", result.Select.Arg)
+	assert.Len(t, result.Select.Cases, 5)
+	assert.Equal(t, "Нет фотографий", result.Select.Cases["=0"].Msg)
+	assert.Equal(t, "%[1]d фотография", result.Select.Cases["one"].Msg)
+	assert.Equal(t, "%[1]d фотографии", result.Select.Cases["few"].Msg)
+	assert.Equal(t, "%[1]d фотографий", result.Select.Cases["many"].Msg)
+	assert.Equal(t, "%[1]d фотографии", result.Select.Cases["other"].Msg)
+	mockTranslator.AssertExpectations(t)
+
+	// Languages without plural forms keep only the "other" category
+	mockTranslator = new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("No photos", "ja-JP")).
+		Return("写真はありません", nil)
+	mockTranslator.On("Translate", mock.Anything, pluralRequest("%[1]d photos", "ja-JP", "other")).
+		Return("%[1]d 枚の写真", nil)
+
+	result, err = translateText(context.Background(), mockTranslator, src, nil, translator.Request{TargetLang: "ja-JP"})
+	assert.NoError(t, err)
+	assert.Len(t, result.Select.Cases, 2)
+	assert.Contains(t, result.Select.Cases, "=0")
+	assert.Contains(t, result.Select.Cases, "other")
+}
+
+// pluralRequest matches the request of the text for the plural category of the target language
+func pluralRequest(text, targetLang, category string) interface{} {
+	return mock.MatchedBy(func(req translator.Request) bool {
+		return req.Text == text && req.TargetLang == targetLang && req.PluralCategory == category
+	})
+}
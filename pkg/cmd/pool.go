@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+)
+
+// parallel calls fn for every index in [0, n) using at most workers goroutines and waits for all
+// calls to finish. Indexes that were not started before ctx is cancelled are skipped and get the
+// context error. It returns the errors of the calls by index.
+func parallel(ctx context.Context, workers, n int, fn func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		if err := ctx.Err(); err != nil {
+			<-sem
+			errs[i] = err
+
+			continue
+		}
+
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+
+	wg.Wait()
+
+	return errs
+}
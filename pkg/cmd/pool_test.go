@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel(t *testing.T) {
+	var running, maxRunning atomic.Int32
+
+	results := make([]int, 10)
+
+	errs := parallel(context.Background(), 3, len(results), func(_ context.Context, i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		results[i] = i * i
+
+		if i == 7 {
+			return fmt.Errorf("test error")
+		}
+
+		return nil
+	})
+
+	assert.LessOrEqual(t, maxRunning.Load(), int32(3))
+	assert.Equal(t, []int{0, 1, 4, 9, 16, 25, 36, 49, 64, 81}, results)
+
+	for i, err := range errs {
+		if i == 7 {
+			assert.EqualError(t, err, "test error")
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestParallel_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var started atomic.Int32
+
+	errs := parallel(ctx, 1, 5, func(_ context.Context, i int) error {
+		started.Add(1)
+
+		if i == 1 {
+			cancel()
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, int32(2), started.Load())
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+
+	for _, err := range errs[2:] {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+)
+
+const (
+	// qualityCommentPrefix marks translator comments of messages whose translation scored low in the evaluation
+	qualityCommentPrefix = "Quality check failed: "
+	// defaultMinScore is the lowest score of any criterion that a translation needs to pass the evaluation
+	defaultMinScore = 3
+)
+
+// QualitySummary summarizes the evaluation of the translations of a file
+type QualitySummary struct {
+	// Evaluated is the number of scored translations
+	Evaluated int
+	// Flagged is the number of translations marked as fuzzy for their low scores
+	Flagged int
+	// Failed is the number of translations that could not be scored
+	Failed int
+	// Accuracy, Fluency, Placeholders and Terminology are the mean scores of the criteria
+	Accuracy, Fluency, Placeholders, Terminology float64
+}
+
+// prepareEvaluator creates the evaluator configured in cfg, it returns nil if the evaluation is disabled.
+// The judge keeps to its own rate limits and takes the slots of parallel requests shared with the
+// translator, as the evaluations of all languages, files and messages run in parallel.
+func prepareEvaluator(cfg *Config, slots *translator.ConcurrencySlots) (*translator.Evaluator, error) {
+	if cfg.Evaluation.Provider == "" {
+		return nil, nil
+	}
+
+	factory := translator.NewFactory()
+	translator.RegisterProviders(factory)
+
+	trans, err := factory.CreateTranslator(cfg.Evaluation.Provider, cfg.Evaluation.translatorConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize evaluation judge: %w", err)
+	}
+
+	// The judge answers the scoring prompt of the evaluator, which chains and ensembles can not pass on to
+	// their providers, and machine translation providers can only translate
+	judge, ok := trans.(translator.Completer)
+	if !ok {
+		return nil, fmt.Errorf("provider %s can not evaluate translations, the judge must be a single language model provider like anthropic, openai, gemini, ollama or openrouter", cfg.Evaluation.Provider)
+	}
+
+	judge = translator.NewCompleterRateLimit(judge, translator.RateLimits{
+		RequestsPerMinute: cfg.Evaluation.RequestsPerMinute,
+		TokensPerMinute:   cfg.Evaluation.TokensPerMinute,
+	})
+
+	return translator.NewEvaluator(translator.NewCompleterConcurrencyLimit(judge, slots)), nil
+}
+
+// evaluateTranslations scores the new translations of the messages at the pending indexes. Messages
+// scoring below minScore on any criterion keep their translation, but are marked as fuzzy with the
+// scores and the explanation of the judge recorded in the translator comment.
+func evaluateTranslations(ctx context.Context, p *pipeline, messages []GotextMessage, pending []int, sourceLang, targetLang string) QualitySummary {
+	var summary QualitySummary
+
+	if p.evaluator == nil {
+		return summary
+	}
+
+	var candidates []int
+
+	for _, idx := range pending {
+		msg := messages[idx]
+		if !msg.Translation.IsEmpty() && !strings.HasPrefix(msg.TranslatorComment, rejectedCommentPrefix) {
+			candidates = append(candidates, idx)
+		}
+	}
+
+	var mu sync.Mutex
+
+	_ = parallel(ctx, globalArgs.Concurrency, len(candidates), func(ctx context.Context, i int) error {
+		msg := &messages[candidates[i]]
+
+		req := newRequest(messages, candidates[i], sourceLang, targetLang)
+		req.Text = msg.Message.String()
+
+		if p.glossary != nil {
+			req.Glossary = p.glossary.Match(sourceLang, targetLang, req.Text)
+		}
+
+		eval, err := p.evaluator.Evaluate(ctx, req, msg.Translation.String())
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			summary.Failed++
+
+			slog.Warn("failed to evaluate translation", slog.String("id", msg.ID), slog.String("error", err.Error()))
+
+			return err
+		}
+
+		summary.Evaluated++
+		summary.Accuracy += float64(eval.Accuracy)
+		summary.Fluency += float64(eval.Fluency)
+		summary.Placeholders += float64(eval.Placeholders)
+		summary.Terminology += float64(eval.Terminology)
+
+		if eval.Score() >= p.minScore {
+			return nil
+		}
+
+		comment := fmt.Sprintf("%saccuracy %d, fluency %d, placeholders %d, terminology %d of 5: %s",
+			qualityCommentPrefix, eval.Accuracy, eval.Fluency, eval.Placeholders, eval.Terminology, eval.Explanation)
+
+		// Keep the missing glossary terms found before
+		if strings.HasPrefix(msg.TranslatorComment, glossaryCommentPrefix) {
+			comment = msg.TranslatorComment + "; " + comment
+		}
+
+		msg.Fuzzy = true
+		msg.TranslatorComment = comment
+		summary.Flagged++
+
+		slog.Warn("translation scored low",
+			slog.String("id", msg.ID),
+			slog.Int("score", eval.Score()),
+			slog.String("explanation", eval.Explanation))
+
+		return nil
+	})
+
+	if summary.Evaluated > 0 {
+		n := float64(summary.Evaluated)
+		summary.Accuracy /= n
+		summary.Fluency /= n
+		summary.Placeholders /= n
+		summary.Terminology /= n
+	}
+
+	return summary
+}
+
+// log writes the summary of the file, if any translation was evaluated
+func (s QualitySummary) log(file string) {
+	if s.Evaluated == 0 && s.Failed == 0 {
+		return
+	}
+
+	slog.Info("translation quality",
+		slog.String("file", file),
+		slog.Int("evaluated", s.Evaluated),
+		slog.Int("flagged", s.Flagged),
+		slog.Int("failed", s.Failed),
+		slog.String("accuracy", fmt.Sprintf("%.2f", s.Accuracy)),
+		slog.String("fluency", fmt.Sprintf("%.2f", s.Fluency)),
+		slog.String("placeholders", fmt.Sprintf("%.2f", s.Placeholders)),
+		slog.String("terminology", fmt.Sprintf("%.2f", s.Terminology)),
+	)
+}
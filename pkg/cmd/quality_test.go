@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateTranslations(t *testing.T) {
+	globalArgs = &args{Concurrency: 2}
+
+	judge := new(mocks.Completer)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Translation:\nHallo Welt")
+	})).Return(`{"accuracy": 5, "fluency": 5, "placeholders": 5, "terminology": 4, "explanation": ""}`, nil)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Translation:\nAuf Wiedersehen")
+	})).Return(`{"accuracy": 2, "fluency": 5, "placeholders": 5, "terminology": 5, "explanation": "means goodbye"}`, nil)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return strings.Contains(prompt, "Translation:\nEinstellungen")
+	})).Return("", errors.New("judge unavailable"))
+
+	messages := []GotextMessage{
+		{ID: "hello", Message: Text{Msg: "Hello world"}, Translation: Text{Msg: "Hallo Welt"}},
+		{ID: "welcome", Message: Text{Msg: "Welcome"}, Translation: Text{Msg: "Auf Wiedersehen"}},
+		{ID: "settings", Message: Text{Msg: "Settings"}, Translation: Text{Msg: "Einstellungen"}},
+		{ID: "rejected", Message: Text{Msg: "%d files"}, TranslatorComment: rejectedCommentPrefix + "missing placeholder", Fuzzy: true},
+	}
+
+	p := &pipeline{evaluator: translator.NewEvaluator(judge), minScore: 3}
+
+	summary := evaluateTranslations(context.Background(), p, messages, []int{0, 1, 2, 3}, "en-US", "de-DE")
+
+	assert.Equal(t, 2, summary.Evaluated)
+	assert.Equal(t, 1, summary.Flagged)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 3.5, summary.Accuracy)
+	assert.Equal(t, 4.5, summary.Terminology)
+
+	assert.False(t, messages[0].Fuzzy)
+	assert.Empty(t, messages[0].TranslatorComment)
+
+	assert.True(t, messages[1].Fuzzy)
+	assert.Equal(t, qualityCommentPrefix+"accuracy 2, fluency 5, placeholders 5, terminology 5 of 5: means goodbye", messages[1].TranslatorComment)
+	assert.Equal(t, "Auf Wiedersehen", messages[1].Translation.Msg)
+
+	assert.False(t, messages[2].Fuzzy)
+
+	// Without an evaluator nothing is scored
+	assert.Equal(t, QualitySummary{}, evaluateTranslations(context.Background(), &pipeline{}, messages, []int{0}, "en-US", "de-DE"))
+}
+
+func TestPrepareEvaluator(t *testing.T) {
+	slots := translator.NewConcurrencySlots(1)
+
+	evaluator, err := prepareEvaluator(&Config{}, slots)
+	assert.NoError(t, err)
+	assert.Nil(t, evaluator)
+
+	for _, provider := range []LLMConfig{
+		{Provider: "chain", Providers: []LLMConfig{{Provider: "openai", APIKey: "key"}}},
+		{Provider: "deepl", APIKey: "key"},
+	} {
+		_, err = prepareEvaluator(&Config{Evaluation: EvaluationConfig{LLMConfig: provider}}, slots)
+		assert.ErrorContains(t, err, "the judge must be a single language model provider", provider.Provider)
+	}
+}
+
+func TestEvaluateTranslations_Limits(t *testing.T) {
+	globalArgs = &args{Concurrency: 4}
+
+	var running, maxRunning, requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": `{"accuracy": 5, "fluency": 5, "placeholders": 5, "terminology": 5}`},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	judge := LLMConfig{Provider: "ollama", Model: "llama3", Options: map[string]string{"base_url": server.URL}}
+
+	messages := []GotextMessage{
+		{ID: "hello", Message: Text{Msg: "Hello"}, Translation: Text{Msg: "Hallo"}},
+		{ID: "world", Message: Text{Msg: "World"}, Translation: Text{Msg: "Welt"}},
+		{ID: "settings", Message: Text{Msg: "Settings"}, Translation: Text{Msg: "Einstellungen"}},
+		{ID: "help", Message: Text{Msg: "Help"}, Translation: Text{Msg: "Hilfe"}},
+	}
+
+	// The judge takes the slots shared with the translator
+	evaluator, err := prepareEvaluator(&Config{Evaluation: EvaluationConfig{LLMConfig: judge}}, translator.NewConcurrencySlots(1))
+	require.NoError(t, err)
+
+	summary := evaluateTranslations(context.Background(), &pipeline{evaluator: evaluator, minScore: 3}, messages, []int{0, 1, 2, 3}, "en-US", "de-DE")
+	assert.Equal(t, 4, summary.Evaluated)
+	assert.Equal(t, int32(1), maxRunning.Load())
+
+	// The judge keeps to the rate limits of the evaluation
+	judge.RequestsPerMinute = 1
+	requests.Store(0)
+
+	evaluator, err = prepareEvaluator(&Config{Evaluation: EvaluationConfig{LLMConfig: judge}}, translator.NewConcurrencySlots(4))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	summary = evaluateTranslations(ctx, &pipeline{evaluator: evaluator, minScore: 3}, messages, []int{0, 1, 2, 3}, "en-US", "de-DE")
+	assert.Equal(t, 1, summary.Evaluated)
+	assert.Equal(t, 3, summary.Failed)
+	assert.Equal(t, int32(1), requests.Load())
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// sourceCandidate is a language whose catalogs may be the source
+type sourceCandidate struct {
+	// lang is the language in the paths of the catalogs
+	lang  string
+	files []string
+	// langs are the languages declared by the catalogs
+	langs []string
+	// untranslated is set if no catalog has a translation different from its message
+	untranslated bool
+	// selfTranslated is set if the messages of the catalogs are translated to themselves, like gotext
+	// does for the catalogs of the source language
+	selfTranslated bool
+}
+
+// findSourceLang returns the language of the source catalogs, as found in their paths. The target
+// languages are never a source. If sourceLang is set, the source is the language named like it or
+// whose catalogs declare it as their language. Otherwise the source is the only language whose
+// catalogs translate every message to itself or, if there is none, the only language whose catalogs
+// are not translated. It is an error if the choice is ambiguous.
+func findSourceLang(catalogs []catalog, targetLangs []string, sourceLang string) (string, error) {
+	for _, lang := range targetLangs {
+		if sourceLang != "" && sameLang(lang, sourceLang) {
+			return "", fmt.Errorf("source language %s is also a target language", sourceLang)
+		}
+	}
+
+	var candidates []*sourceCandidate
+
+	byLang := make(map[string]*sourceCandidate)
+
+	for _, c := range catalogs {
+		if slices.ContainsFunc(targetLangs, func(lang string) bool { return sameLang(lang, c.lang) }) {
+			continue
+		}
+
+		candidate, ok := byLang[c.lang]
+		if !ok {
+			candidate = &sourceCandidate{lang: c.lang}
+			byLang[c.lang] = candidate
+			candidates = append(candidates, candidate)
+		}
+
+		candidate.files = append(candidate.files, c.file)
+	}
+
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no source language catalogs found")
+	}
+
+	for _, c := range candidates {
+		if err := c.inspect(); err != nil {
+			return "", err
+		}
+	}
+
+	var matches []string
+
+	for _, c := range candidates {
+		if sourceLang != "" {
+			if sameLang(c.lang, sourceLang) || slices.ContainsFunc(c.langs, func(lang string) bool { return sameLang(lang, sourceLang) }) {
+				matches = append(matches, c.lang)
+			}
+		} else if c.selfTranslated {
+			matches = append(matches, c.lang)
+		}
+	}
+
+	if sourceLang == "" && len(matches) == 0 {
+		for _, c := range candidates {
+			if c.untranslated {
+				matches = append(matches, c.lang)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case sourceLang != "" && len(matches) == 0:
+		return "", fmt.Errorf("no catalogs of source language %s found", sourceLang)
+	case sourceLang != "":
+		return "", fmt.Errorf("source language %s is ambiguous, it matches the languages %s", sourceLang, strings.Join(matches, ", "))
+	case len(matches) == 0:
+		return "", fmt.Errorf("no source catalogs found, set the source language with --source-lang")
+	default:
+		return "", fmt.Errorf("source language is ambiguous, it may be any of %s, set it with --source-lang", strings.Join(matches, ", "))
+	}
+}
+
+// inspect reads the catalogs of the candidate
+func (c *sourceCandidate) inspect() error {
+	c.untranslated = true
+	c.selfTranslated = true
+
+	messages := 0
+
+	for _, path := range c.files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read catalog: %w", err)
+		}
+
+		var file GotextFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return fmt.Errorf("failed to parse catalog %s: %w", path, err)
+		}
+
+		if file.Language != "" && !slices.Contains(c.langs, file.Language) {
+			c.langs = append(c.langs, file.Language)
+		}
+
+		for _, msg := range file.Messages {
+			messages++
+
+			if msg.Translation.IsEmpty() {
+				c.selfTranslated = false
+			} else if msg.Translation.String() != msg.Message.String() {
+				c.untranslated = false
+				c.selfTranslated = false
+			}
+		}
+	}
+
+	// A language without messages has nothing to translate from
+	if messages == 0 {
+		c.untranslated = false
+		c.selfTranslated = false
+	}
+
+	return nil
+}
+
+// sameLang reports whether the language tags are the same, ignoring case and separators
+func sameLang(a, b string) bool {
+	return strings.EqualFold(strings.ReplaceAll(a, "_", "-"), strings.ReplaceAll(b, "_", "-"))
+}
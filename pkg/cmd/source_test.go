@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCatalog writes a catalog of the language with a message and its translation to dir/name/messages.gotext.json
+func writeCatalog(t *testing.T, dir, name, lang, translation string) {
+	t.Helper()
+
+	data, err := json.Marshal(GotextFile{
+		Language: lang,
+		Messages: []GotextMessage{{ID: "greeting", Message: Text{Msg: "Hello"}, Translation: Text{Msg: translation}}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name, "messages.gotext.json"), data, 0644))
+}
+
+// findSourceLangIn finds the source language of the catalogs under dir/locales
+func findSourceLangIn(t *testing.T, dir string, targetLangs []string, sourceLang string) (string, error) {
+	t.Helper()
+
+	l, err := newLayout(dir, LayoutConfig{})
+	require.NoError(t, err)
+
+	catalogs, err := l.find()
+	require.NoError(t, err)
+
+	return findSourceLang(catalogs, targetLangs, sourceLang)
+}
+
+func TestFindSourceLang(t *testing.T) {
+	dir := t.TempDir()
+	locales := filepath.Join(dir, "locales")
+
+	// Extracted catalogs have no translations, or translations equal to the messages
+	writeCatalog(t, locales, "en-US", "en-US", "Hello")
+	writeCatalog(t, locales, "fr-FR", "fr-FR", "Bonjour")
+	writeCatalog(t, locales, "de-DE", "de-DE", "")
+
+	source, err := findSourceLangIn(t, dir, []string{"de-DE"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", source)
+
+	// Untranslated locales are sources only without a catalog translated to itself
+	writeCatalog(t, locales, "ja-JP", "ja-JP", "")
+
+	source, err = findSourceLangIn(t, dir, []string{"de-DE"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", source)
+
+	writeCatalog(t, locales, "en-GB", "en-GB", "Hello")
+
+	_, err = findSourceLangIn(t, dir, []string{"de-DE"}, "")
+	assert.ErrorContains(t, err, "source language is ambiguous")
+
+	source, err = findSourceLangIn(t, dir, []string{"de-DE"}, "en_us")
+	require.NoError(t, err)
+	assert.Equal(t, "en-US", source)
+
+	_, err = findSourceLangIn(t, dir, []string{"de-DE"}, "it-IT")
+	assert.ErrorContains(t, err, "no catalogs of source language it-IT")
+
+	_, err = findSourceLangIn(t, dir, []string{"de-DE"}, "de-DE")
+	assert.ErrorContains(t, err, "is also a target language")
+}
+
+func TestFindSourceLang_CatalogLanguage(t *testing.T) {
+	dir := t.TempDir()
+	locales := filepath.Join(dir, "locales")
+
+	writeCatalog(t, locales, "en", "en-GB", "")
+	writeCatalog(t, locales, "english", "en-GB", "")
+
+	_, err := findSourceLangIn(t, dir, []string{"ru-RU"}, "")
+	assert.ErrorContains(t, err, "source language is ambiguous")
+
+	_, err = findSourceLangIn(t, dir, []string{"ru-RU"}, "en-GB")
+	assert.ErrorContains(t, err, "source language en-GB is ambiguous")
+
+	require.NoError(t, os.RemoveAll(filepath.Join(locales, "english")))
+
+	source, err := findSourceLangIn(t, dir, []string{"ru-RU"}, "en-GB")
+	require.NoError(t, err)
+	assert.Equal(t, "en", source)
+
+	// The only untranslated catalogs are the source
+	source, err = findSourceLangIn(t, dir, []string{"ru-RU"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "en", source)
+
+	// Only translated catalogs are left without an extracted source
+	require.NoError(t, os.RemoveAll(filepath.Join(locales, "en")))
+	writeCatalog(t, locales, "fr-FR", "fr-FR", "Bonjour")
+
+	_, err = findSourceLangIn(t, dir, []string{"ru-RU"}, "")
+	assert.ErrorContains(t, err, "no source catalogs found")
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"encoding/json"
+)
+
+// Text represents a message or translation value in a gotext file. It is either
+// a plain string or a select statement (e.g. plural) with a text per case.
+type Text struct {
+	// Msg contains the message to be displayed. It may be used as a fallback
+	// value if none of the select cases match.
+	Msg    string  `json:"msg,omitempty"`
+	Select *Select `json:"select,omitempty"`
+
+	// Var defines a map of variables that may be substituted in the selected message.
+	Var map[string]Text `json:"var,omitempty"`
+
+	// Example contains an example message formatted with default values.
+	Example string `json:"example,omitempty"`
+}
+
+// Select selects a Text based on the feature value of a certain argument.
+type Select struct {
+	Feature string          `json:"feature"` // Name of the feature, e.g. plural
+	Arg     string          `json:"arg"`     // The placeholder ID
+	Cases   map[string]Text `json:"cases"`
+}
+
+// rawText erases the JSON methods of Text.
+type rawText Text
+
+// IsEmpty reports whether the text contains anything to display.
+func (t Text) IsEmpty() bool {
+	return t.Msg == "" && t.Select == nil && t.Var == nil
+}
+
+// String returns the plain message, or the JSON representation for complex texts.
+func (t Text) String() string {
+	if t.Select == nil && t.Var == nil {
+		return t.Msg
+	}
+
+	data, err := json.Marshal(rawText(t))
+	if err != nil {
+		return t.Msg
+	}
+
+	return string(data)
+}
+
+// UnmarshalJSON accepts both a plain JSON string and a select object.
+func (t *Text) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		*t = Text{}
+		return json.Unmarshal(b, &t.Msg)
+	}
+
+	return json.Unmarshal(b, (*rawText)(t))
+}
+
+// MarshalJSON writes plain texts as JSON strings and complex texts as objects.
+func (t Text) MarshalJSON() ([]byte, error) {
+	if t.Select == nil && t.Var == nil && t.Example == "" {
+		return json.Marshal(t.Msg)
+	}
+
+	return json.Marshal(rawText(t))
+}
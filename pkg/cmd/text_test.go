@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestText_UnmarshalJSON(t *testing.T) {
+	// Plain string
+	var plain Text
+	err := json.Unmarshal([]byte(`"Hello, World!"`), &plain)
+	assert.NoError(t, err)
+	assert.Equal(t, Text{Msg: "Hello, World!"}, plain)
+
+	// Plural select
+	var sel Text
+	err = json.Unmarshal([]byte(`{
+		"select": {
+			"feature": "plural",
+			"arg": "Count",
+			"cases": {
+				"one": {"msg": "%[1]d photo"},
+				"other": "%[1]d photos"
+			}
+		}
+	}`), &sel)
+	assert.NoError(t, err)
+	assert.NotNil(t, sel.Select)
+	assert.Equal(t, "plural", sel.Select.Feature)
+	assert.Equal(t, "Count", sel.Select.Arg)
+	assert.Equal(t, Text{Msg: "%[1]d photo"}, sel.Select.Cases["one"])
+	assert.Equal(t, Text{Msg: "%[1]d photos"}, sel.Select.Cases["other"])
+	assert.False(t, sel.IsEmpty())
+
+	// Null
+	var empty Text
+	err = json.Unmarshal([]byte(`null`), &empty)
+	assert.NoError(t, err)
+	assert.True(t, empty.IsEmpty())
+}
+
+func TestText_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Text{Msg: "Hello"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `"Hello"`, string(data))
+
+	data, err = json.Marshal(Text{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `""`, string(data))
+
+	data, err = json.Marshal(Text{
+		Select: &Select{
+			Feature: "plural",
+			Arg:     "Count",
+			Cases: map[string]Text{
+				"one":   {Msg: "%[1]d photo"},
+				"other": {Msg: "%[1]d photos"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"select":{"feature":"plural","arg":"Count","cases":{"one":"%[1]d photo","other":"%[1]d photos"}}}`, string(data))
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,20 +13,32 @@ import (
 	"github.com/ksysoev/gotext-translator/pkg/translator"
 )
 
+const (
+	// machineTranslatedComment marks messages rewritten by machine translation
+	machineTranslatedComment = "Machine translated"
+	// rejectedCommentPrefix marks translator comments of messages whose machine translation was rejected
+	rejectedCommentPrefix = "Machine translation rejected: "
+	// neighbourContextSize is the number of messages before and after a message given as context
+	neighbourContextSize = 2
+)
+
 type GotextMessage struct {
-	ID           string `json:"id"`
-	Message      string `json:"message"`
-	Translation  string `json:"translation"`
-	Placeholders []struct {
-		ID             string `json:"id"`
-		String         string `json:"string"`
-		Type           string `json:"type"`
-		UnderlyingType string `json:"underlyingType"`
-		Expr           string `json:"expr"`
-		ArgNum         int    `json:"argNum"`
-	} `json:"placeholders,omitempty"`
-	TranslatorComment string `json:"translatorComment,omitempty"`
-	Fuzzy             bool   `json:"fuzzy,omitempty"`
+	ID                string        `json:"id"`
+	Message           Text          `json:"message"`
+	Translation       Text          `json:"translation"`
+	Placeholders      []Placeholder `json:"placeholders,omitempty"`
+	Comment           string        `json:"comment,omitempty"`
+	TranslatorComment string        `json:"translatorComment,omitempty"`
+	Fuzzy             bool          `json:"fuzzy,omitempty"`
+}
+
+type Placeholder struct {
+	ID             string `json:"id"`
+	String         string `json:"string"`
+	Type           string `json:"type"`
+	UnderlyingType string `json:"underlyingType"`
+	Expr           string `json:"expr"`
+	ArgNum         int    `json:"argNum"`
 }
 
 type GotextFile struct {
@@ -33,13 +46,17 @@ type GotextFile struct {
 	Messages []GotextMessage `json:"messages"`
 }
 
-// runTranslation handles translation of a single file
+// langPlaceholder is replaced by the target language in the output path of the translate command
+const langPlaceholder = "{lang}"
+
+// runTranslation handles translation of a single file into every target language
 func runTranslation(ctx context.Context, cfg *Config) error {
 	// Prepare the translator
-	trans, err := prepareTranslator(ctx, cfg)
+	p, err := preparePipeline(ctx, cfg)
 	if err != nil {
 		return err
 	}
+	defer p.close()
 
 	// Process the file
 	sourceData, err := os.ReadFile(globalArgs.SourcePath)
@@ -47,177 +64,277 @@ func runTranslation(ctx context.Context, cfg *Config) error {
 		return fmt.Errorf("failed to read source file: %w", err)
 	}
 
+	langs := globalArgs.TargetLangs
+
+	outputPattern, err := outputPathPattern(globalArgs.SourcePath, globalArgs.OutputPath, len(langs))
+	if err != nil {
+		return err
+	}
+
+	summaries := make([]langSummary, len(langs))
+
+	// Languages are translated in parallel, sharing the translator, its cache and limits
+	errs := parallel(ctx, globalArgs.Concurrency, len(langs), func(ctx context.Context, i int) error {
+		outputPath := strings.ReplaceAll(outputPattern, langPlaceholder, langs[i])
+
+		summaries[i] = langSummary{Lang: langs[i], Files: 1}
+
+		processed, err := translateFile(ctx, p, sourceData, outputPath, langs[i])
+		if err != nil {
+			summaries[i].FailedFiles = 1
+			return fmt.Errorf("%s: %w", langs[i], err)
+		}
+
+		summaries[i].Messages = processed
+
+		return nil
+	})
+
+	if len(langs) > 1 {
+		logSummaries(summaries)
+	}
+
+	return errors.Join(errs...)
+}
+
+// outputPathPattern returns the output path of the translate command, with the {lang} placeholder
+// for the target language. The default is out.gotext.json in the source directory, or in a directory
+// named after the target language next to the source file when translating to several languages.
+func outputPathPattern(sourcePath, outputPath string, langs int) (string, error) {
+	if outputPath == "" {
+		dir := filepath.Dir(sourcePath)
+		if langs > 1 {
+			dir = filepath.Join(dir, langPlaceholder)
+		}
+
+		return filepath.Join(dir, "out.gotext.json"), nil
+	}
+
+	if langs > 1 && !strings.Contains(outputPath, langPlaceholder) {
+		return "", fmt.Errorf("output path must contain %s when translating to several languages", langPlaceholder)
+	}
+
+	return outputPath, nil
+}
+
+// translateFile translates the parsed source file to the target language and writes it to the output path
+func translateFile(ctx context.Context, p *pipeline, sourceData []byte, outputPath, targetLang string) (int, error) {
 	var gotextFile GotextFile
 	if err := json.Unmarshal(sourceData, &gotextFile); err != nil {
-		return fmt.Errorf("failed to parse source file: %w", err)
+		return 0, fmt.Errorf("failed to parse source file: %w", err)
 	}
 
-	gotextFile.Language = globalArgs.TargetLang
+	sourceLang := gotextFile.Language
+	gotextFile.Language = targetLang
 
 	// Process each message
 	slog.Info("starting translation",
 		slog.String("file", globalArgs.SourcePath),
-		slog.String("target_lang", globalArgs.TargetLang),
+		slog.String("target_lang", targetLang),
 		slog.Int("total_messages", len(gotextFile.Messages)),
 	)
 
-	processedCount := 0
-	for i := range gotextFile.Messages {
-		msg := &gotextFile.Messages[i]
-		if msg.Translation != "" && !globalArgs.ForceRewrite {
+	var pending []int
+	for i, msg := range gotextFile.Messages {
+		if !msg.Translation.IsEmpty() && !globalArgs.ForceRewrite {
 			slog.Debug("skipping translated message", slog.String("id", msg.ID))
 			continue
 		}
 
-		translation, err := trans.Translate(ctx, msg.Message, gotextFile.Language)
-		if err != nil {
-			slog.Error("failed to translate message",
-				slog.String("id", msg.ID),
-				slog.String("error", err.Error()))
-			continue
-		}
-
-		msg.Translation = translation
-		// If this was a forced rewrite, add a comment
-		if globalArgs.ForceRewrite && msg.Translation != "" {
-			msg.TranslatorComment = "Machine translated"
-		}
-
-		processedCount++
-		slog.Info("translated message",
-			slog.String("id", msg.ID),
-			slog.String("original", msg.Message),
-			slog.String("translation", translation))
+		pending = append(pending, i)
 	}
 
-	// Determine output path
-	outputPath := globalArgs.OutputPath
-	if outputPath == "" {
-		dir := filepath.Dir(globalArgs.SourcePath)
-		outputPath = filepath.Join(dir, "out.gotext.json")
-	}
+	ctx, provenance := translator.WithProvenance(ctx)
+
+	processedCount := translateMessages(ctx, p.trans, gotextFile.Messages, pending, sourceLang, gotextFile.Language)
+	checkGlossary(p.glossary, gotextFile.Messages, pending, sourceLang, gotextFile.Language)
+	evaluateTranslations(ctx, p, gotextFile.Messages, pending, sourceLang, gotextFile.Language).log(outputPath)
 
 	// Save result
 	output, err := json.MarshalIndent(gotextFile, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal output: %w", err)
+		return 0, fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	if err := os.WriteFile(outputPath, output, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+		return 0, fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if err := writeCandidatesReport(outputPath, gotextFile.Language, provenance); err != nil {
+		return 0, err
 	}
 
+	updateMemory(p.memory, gotextFile.Messages, sourceLang, gotextFile.Language)
+
 	slog.Info("translation completed",
 		slog.String("file", globalArgs.SourcePath),
 		slog.String("output", outputPath),
 		slog.Int("processed", processedCount),
 	)
 
-	return nil
+	return processedCount, nil
 }
 
-// runDirectoryTranslation handles translation of all files in a directory
+// runDirectoryTranslation handles translation of all files in a directory into every target language
 func runDirectoryTranslation(ctx context.Context, cfg *Config) error {
+	if cfg.Gotext.Enabled {
+		return runGotextUpdate(ctx, cfg)
+	}
+
+	l, err := newLayout(globalArgs.SourceDir, cfg.Layout)
+	if err != nil {
+		return err
+	}
+
 	// Prepare the translator
-	trans, err := prepareTranslator(ctx, cfg)
+	p, err := preparePipeline(ctx, cfg)
 	if err != nil {
 		return err
 	}
+	defer p.close()
 
-	// Find base language directory (usually en-US, en-GB, etc.)
-	baseDir := filepath.Join(globalArgs.SourceDir, "locales")
-	targetDir := filepath.Join(baseDir, globalArgs.TargetLang)
+	return translateDirectory(ctx, p, l, globalArgs.TargetLangs)
+}
 
-	// Ensure target directory exists
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf("failed to create target directory: %w", err)
+// translateDirectory translates the catalogs of the source language found by the layout into every
+// target language. Languages are translated in parallel, sharing the translator of the pipeline.
+// The source language is chosen by findSourceLang.
+func translateDirectory(ctx context.Context, p *pipeline, l *layout, langs []string) error {
+	catalogs, err := l.find()
+	if err != nil {
+		return err
 	}
 
-	// Find all source language directories
-	entries, err := os.ReadDir(baseDir)
+	sourceLang, err := findSourceLang(catalogs, langs, globalArgs.SourceLang)
 	if err != nil {
-		return fmt.Errorf("failed to read base directory: %w", err)
+		return err
 	}
 
-	var sourceEntries []os.DirEntry
-	for _, entry := range entries {
-		if entry.IsDir() && entry.Name() != globalArgs.TargetLang {
-			sourceEntries = append(sourceEntries, entry)
+	var sources []catalog
+
+	for _, c := range catalogs {
+		if c.lang == sourceLang {
+			sources = append(sources, c)
 		}
 	}
 
-	if len(sourceEntries) == 0 {
-		return fmt.Errorf("no source language directories found in %s", baseDir)
-	}
+	slog.Info("found source files", slog.String("source_lang", sourceLang), slog.Int("count", len(sources)))
 
-	// Automatically choose the first source directory
-	sourceSubdir := sourceEntries[0].Name()
-	sourceLangDir := filepath.Join(baseDir, sourceSubdir)
+	summaries := make([]langSummary, len(langs))
 
-	slog.Info("starting directory translation",
-		slog.String("source_dir", sourceLangDir),
-		slog.String("target_dir", targetDir),
-		slog.String("target_lang", globalArgs.TargetLang),
-	)
+	errs := parallel(ctx, globalArgs.Concurrency, len(langs), func(ctx context.Context, i int) error {
+		var err error
 
-	// Find all .gotext.json files in the source language directory
-	var sourceFiles []string
-	if err := filepath.Walk(sourceLangDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() && strings.HasSuffix(path, ".gotext.json") && !strings.HasSuffix(path, "out.gotext.json") {
-			sourceFiles = append(sourceFiles, path)
-		}
-		return nil
-	}); err != nil {
-		return fmt.Errorf("failed to find source files: %w", err)
+		summaries[i], err = translateLanguage(ctx, p, l, sources, langs[i])
+
+		return err
+	})
+
+	if len(langs) > 1 {
+		logSummaries(summaries)
 	}
 
-	slog.Info("found source files", slog.Int("count", len(sourceFiles)))
+	return errors.Join(errs...)
+}
+
+// translateLanguage translates the source catalogs into the catalogs of the target language at the
+// paths given by the layout
+func translateLanguage(ctx context.Context, p *pipeline, l *layout, sources []catalog, targetLang string) (langSummary, error) {
+	summary := langSummary{Lang: targetLang, Files: len(sources)}
 
-	// Process each source file
-	totalProcessedFiles := 0
-	totalProcessedMessages := 0
+	slog.Info("starting directory translation",
+		slog.String("root", l.root),
+		slog.String("target_lang", targetLang),
+	)
 
-	for _, sourceFile := range sourceFiles {
-		// Determine target file path
-		relPath, err := filepath.Rel(sourceLangDir, sourceFile)
-		if err != nil {
-			slog.Error("failed to get relative path", slog.String("file", sourceFile), slog.String("error", err.Error()))
-			continue
-		}
+	// Process the source files in parallel
+	processedCounts := make([]int, len(sources))
 
-		targetFile := filepath.Join(targetDir, relPath)
+	errs := parallel(ctx, globalArgs.Concurrency, len(sources), func(ctx context.Context, i int) error {
+		targetFile := l.targetPath(sources[i], targetLang)
 
 		// Create parent directories if they don't exist
 		if err := os.MkdirAll(filepath.Dir(targetFile), 0755); err != nil {
-			slog.Error("failed to create target directory", slog.String("dir", filepath.Dir(targetFile)), slog.String("error", err.Error()))
-			continue
+			return fmt.Errorf("failed to create target directory: %w", err)
 		}
 
 		// Process the file
-		processedCount, err := processFile(ctx, trans, sourceFile, targetFile, globalArgs.TargetLang)
+		var err error
+
+		processedCounts[i], err = processFile(ctx, p, sources[i].file, targetFile, targetLang)
+
+		return err
+	})
+
+	var fileErrs []error
+
+	for i, err := range errs {
 		if err != nil {
-			slog.Error("failed to process file", slog.String("file", sourceFile), slog.String("error", err.Error()))
+			slog.Error("failed to process file", slog.String("file", sources[i].file), slog.String("error", err.Error()))
+			fileErrs = append(fileErrs, fmt.Errorf("%s: %w", sources[i].file, err))
+
 			continue
 		}
 
-		totalProcessedFiles++
-		totalProcessedMessages += processedCount
+		summary.Messages += processedCounts[i]
 	}
 
+	summary.FailedFiles = len(fileErrs)
+
 	slog.Info("directory translation completed",
-		slog.String("target_lang", globalArgs.TargetLang),
-		slog.Int("processed_files", totalProcessedFiles),
-		slog.Int("processed_messages", totalProcessedMessages),
+		slog.String("target_lang", targetLang),
+		slog.Int("processed_files", summary.Files-summary.FailedFiles),
+		slog.Int("failed_files", summary.FailedFiles),
+		slog.Int("processed_messages", summary.Messages),
 	)
 
-	return nil
+	if len(fileErrs) > 0 {
+		return summary, fmt.Errorf("%s: failed to process %d of %d files: %w", targetLang, len(fileErrs), len(sources), errors.Join(fileErrs...))
+	}
+
+	return summary, nil
 }
 
-// processFile processes a single gotext file
-func processFile(ctx context.Context, trans translator.Translator, sourcePath, targetPath, targetLang string) (int, error) {
+// langSummary counts the files and messages translated into a target language
+type langSummary struct {
+	Lang        string
+	Files       int
+	FailedFiles int
+	Messages    int
+}
+
+// logSummaries logs the summary of every target language and their totals
+func logSummaries(summaries []langSummary) {
+	var total langSummary
+
+	for _, s := range summaries {
+		slog.Info("language summary",
+			slog.String("target_lang", s.Lang),
+			slog.Int("processed_files", s.Files-s.FailedFiles),
+			slog.Int("failed_files", s.FailedFiles),
+			slog.Int("processed_messages", s.Messages),
+		)
+
+		total.Files += s.Files
+		total.FailedFiles += s.FailedFiles
+		total.Messages += s.Messages
+	}
+
+	slog.Info("translation summary",
+		slog.Int("languages", len(summaries)),
+		slog.Int("processed_files", total.Files-total.FailedFiles),
+		slog.Int("failed_files", total.FailedFiles),
+		slog.Int("processed_messages", total.Messages),
+	)
+}
+
+// processFile processes a single gotext file. The translations written to the target file are
+// added to the translation memory of the pipeline, if it has one.
+func processFile(ctx context.Context, p *pipeline, sourcePath, targetPath, targetLang string) (int, error) {
 	// Read source file
 	sourceData, err := os.ReadFile(sourcePath)
 	if err != nil {
@@ -258,6 +375,7 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 				ID:           msg.ID,
 				Message:      msg.Message,
 				Placeholders: msg.Placeholders,
+				Comment:      msg.Comment,
 			}
 		}
 	}
@@ -275,7 +393,7 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 		slog.Int("total_messages", len(sourceFile.Messages)),
 	)
 
-	processedCount := 0
+	var pending []int
 
 	for _, srcMsg := range sourceFile.Messages {
 		// Find or create target message
@@ -287,6 +405,7 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 				ID:           srcMsg.ID,
 				Message:      srcMsg.Message,
 				Placeholders: srcMsg.Placeholders,
+				Comment:      srcMsg.Comment,
 			})
 			targetMsgMap[srcMsg.ID] = targetIdx
 		} else {
@@ -294,37 +413,26 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 			targetMsg := &targetFile.Messages[targetIdx]
 			targetMsg.Message = srcMsg.Message
 			targetMsg.Placeholders = srcMsg.Placeholders
+			targetMsg.Comment = srcMsg.Comment
 		}
 
 		targetMsg := &targetFile.Messages[targetIdx]
 
 		// Skip if already translated and not forced to rewrite
-		if targetMsg.Translation != "" && !globalArgs.ForceRewrite {
+		if !targetMsg.Translation.IsEmpty() && !globalArgs.ForceRewrite {
 			slog.Debug("skipping translated message", slog.String("id", targetMsg.ID))
 			continue
 		}
 
-		// Translate the message
-		translation, err := trans.Translate(ctx, targetMsg.Message, targetLang)
-		if err != nil {
-			slog.Error("failed to translate message",
-				slog.String("id", targetMsg.ID),
-				slog.String("error", err.Error()))
-			continue
-		}
+		pending = append(pending, targetIdx)
+	}
 
-		targetMsg.Translation = translation
-		// If this was a forced rewrite, add a comment
-		if globalArgs.ForceRewrite && targetMsg.Translation != "" {
-			targetMsg.TranslatorComment = "Machine translated"
-		}
+	// Translate the pending messages
+	ctx, provenance := translator.WithProvenance(ctx)
 
-		processedCount++
-		slog.Info("translated message",
-			slog.String("id", targetMsg.ID),
-			slog.String("original", targetMsg.Message),
-			slog.String("translation", translation))
-	}
+	processedCount := translateMessages(ctx, p.trans, targetFile.Messages, pending, sourceFile.Language, targetLang)
+	checkGlossary(p.glossary, targetFile.Messages, pending, sourceFile.Language, targetLang)
+	evaluateTranslations(ctx, p, targetFile.Messages, pending, sourceFile.Language, targetLang).log(targetPath)
 
 	// Save the target file
 	output, err := json.MarshalIndent(targetFile, "", "  ")
@@ -336,6 +444,12 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 		return 0, fmt.Errorf("failed to write output file: %w", err)
 	}
 
+	if err := writeCandidatesReport(targetPath, targetLang, provenance); err != nil {
+		return 0, err
+	}
+
+	updateMemory(p.memory, targetFile.Messages, sourceFile.Language, targetLang)
+
 	slog.Info("file processing completed",
 		slog.String("file", targetPath),
 		slog.Bool("new_file", !targetExists),
@@ -345,26 +459,240 @@ func processFile(ctx context.Context, trans translator.Translator, sourcePath, t
 	return processedCount, nil
 }
 
-// prepareTranslator creates and initializes a translator
-func prepareTranslator(ctx context.Context, cfg *Config) (translator.Translator, error) {
+// translateMessages translates the messages at the pending indexes in place and returns the number
+// of translated messages. Messages are translated in parallel by up to the configured number of
+// workers. Messages that fail to translate are logged and keep their previous translation.
+func translateMessages(ctx context.Context, trans translator.Translator, messages []GotextMessage, pending []int, sourceLang, targetLang string) int {
+	reqs := make([]translator.Request, len(pending))
+	for i, idx := range pending {
+		reqs[i] = newRequest(messages, idx, sourceLang, targetLang)
+	}
+
+	// Chains and ensembles of providers record which of them translated every message
+	provenance := translator.ProvenanceFromContext(ctx)
+
+	trans = prefetchTranslations(ctx, trans, messages, pending, reqs)
+
+	// Every worker updates its own message, so the order of messages is preserved
+	errs := parallel(ctx, globalArgs.Concurrency, len(pending), func(ctx context.Context, i int) error {
+		msg := &messages[pending[i]]
+
+		if err := translateMessage(ctx, trans, msg, reqs[i]); err != nil {
+			slog.Error("failed to translate message",
+				slog.String("id", msg.ID),
+				slog.String("error", err.Error()))
+			return err
+		}
+
+		attrs := []any{
+			slog.String("id", msg.ID),
+			slog.Any("original", msg.Message),
+			slog.Any("translation", msg.Translation),
+		}
+
+		if providers := provenance.Providers(msg.ID); len(providers) > 0 {
+			attrs = append(attrs, slog.String("provider", strings.Join(providers, ", ")))
+		}
+
+		slog.Info("translated message", attrs...)
+
+		return nil
+	})
+
+	processedCount := 0
+
+	for _, err := range errs {
+		if err == nil {
+			processedCount++
+		}
+	}
+
+	return processedCount
+}
+
+// translateMessage translates the message in place using req as the context of the translation.
+// If the translation breaks the placeholders or the protected markup of the message, it is discarded:
+// the message keeps its previous translation, if any, and is marked as fuzzy with the reason recorded
+// in the translator comment.
+func translateMessage(ctx context.Context, trans translator.Translator, msg *GotextMessage, req translator.Request) error {
+	translation, err := translateText(ctx, trans, msg.Message, msg.Placeholders, req)
+
+	var (
+		phErr     *PlaceholderError
+		markupErr *translator.MarkupError
+	)
+
+	if errors.As(err, &phErr) || errors.As(err, &markupErr) {
+		msg.Fuzzy = true
+		msg.TranslatorComment = rejectedCommentPrefix + err.Error()
+
+		return fmt.Errorf("translation rejected: %w", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	msg.Translation = translation
+
+	// Clear the marks left by a previously rejected or flagged translation
+	if strings.HasPrefix(msg.TranslatorComment, rejectedCommentPrefix) || strings.HasPrefix(msg.TranslatorComment, glossaryCommentPrefix) ||
+		strings.HasPrefix(msg.TranslatorComment, qualityCommentPrefix) {
+		msg.TranslatorComment = ""
+		msg.Fuzzy = false
+	}
+
+	// If this was a forced rewrite, add a comment
+	if globalArgs.ForceRewrite && !msg.Translation.IsEmpty() {
+		msg.TranslatorComment = machineTranslatedComment
+	}
+
+	return nil
+}
+
+// newRequest builds the translation request for the message at index idx, describing the message
+// and its neighbours in the file.
+func newRequest(messages []GotextMessage, idx int, sourceLang, targetLang string) translator.Request {
+	msg := messages[idx]
+
+	req := translator.Request{
+		SourceLang: sourceLang,
+		TargetLang: targetLang,
+		MessageID:  msg.ID,
+		Comment:    msg.Comment,
+	}
+
+	// Comments written by translators are useful context, unlike the ones left by this tool
+	if msg.TranslatorComment != "" && msg.TranslatorComment != machineTranslatedComment &&
+		!strings.HasPrefix(msg.TranslatorComment, rejectedCommentPrefix) && !strings.HasPrefix(msg.TranslatorComment, qualityCommentPrefix) {
+		req.Comment = strings.TrimSpace(req.Comment + "\n" + msg.TranslatorComment)
+	}
+
+	for _, ph := range msg.Placeholders {
+		req.Placeholders = append(req.Placeholders, translator.Placeholder{
+			ID:     ph.ID,
+			String: ph.String,
+			Type:   ph.Type,
+			Expr:   ph.Expr,
+		})
+	}
+
+	from := max(0, idx-neighbourContextSize)
+	to := min(len(messages), idx+neighbourContextSize+1)
+
+	for i := from; i < to; i++ {
+		if i == idx {
+			continue
+		}
+
+		req.Neighbours = append(req.Neighbours, translator.ContextMessage{
+			Text:        messages[i].Message.String(),
+			Translation: messages[i].Translation.String(),
+		})
+	}
+
+	return req
+}
+
+// pipeline holds the translator and the resources shared by all files of a run
+type pipeline struct {
+	trans    translator.Translator
+	memory   *translator.Memory
+	glossary *translator.Glossary
+	// evaluator scores the new translations, nil if the evaluation is disabled
+	evaluator *translator.Evaluator
+	minScore  int
+}
+
+// preparePipeline opens the translation memory and glossary configured in cfg and creates the translator
+// and the evaluator
+func preparePipeline(ctx context.Context, cfg *Config) (*pipeline, error) {
+	mem, err := openMemory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	gloss, err := loadGlossary(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Translations and evaluations share the limit of parallel requests
+	slots := translator.NewConcurrencySlots(globalArgs.Concurrency)
+
+	trans, err := prepareTranslator(ctx, cfg, mem, gloss, slots)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluator, err := prepareEvaluator(cfg, slots)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pipeline{
+		trans:     trans,
+		memory:    mem,
+		glossary:  gloss,
+		evaluator: evaluator,
+		minScore:  cfg.Evaluation.MinScore,
+	}, nil
+}
+
+// close stores the changes of the translation memory
+func (p *pipeline) close() {
+	saveMemory(p.memory)
+}
+
+// prepareTranslator creates and initializes a translator, its calls take the slots of parallel requests
+func prepareTranslator(ctx context.Context, cfg *Config, mem *translator.Memory, gloss *translator.Glossary, slots *translator.ConcurrencySlots) (translator.Translator, error) {
+	if len(cfg.LLM.Providers) > 0 && (cfg.LLM.RequestsPerMinute > 0 || cfg.LLM.TokensPerMinute > 0) {
+		return nil, fmt.Errorf("rate limits of the %s provider are set on each of its providers", cfg.LLM.Provider)
+	}
+
 	// Initialize translator factory
 	factory := translator.NewFactory()
 	translator.RegisterProviders(factory)
 
 	// Create translator instance
-	config := map[string]interface{}{
-		"api_key": cfg.LLM.APIKey,
-		"model":   cfg.LLM.Model,
+	trans, err := factory.CreateTranslator(cfg.LLM.Provider, cfg.LLM.translatorConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize translator: %w", err)
 	}
 
-	// Add any additional options from config
-	for k, v := range cfg.LLM.Options {
-		config[k] = v
+	// Workers translating files and messages share the same rate limits and limit of parallel requests.
+	// The providers of chains and ensembles are limited by the factory, each with its own budgets.
+	trans = translator.NewRateLimit(trans, translator.RateLimits{
+		RequestsPerMinute: cfg.LLM.RequestsPerMinute,
+		TokensPerMinute:   cfg.LLM.TokensPerMinute,
+	})
+
+	trans = translator.NewSharedConcurrencyLimit(trans, slots)
+
+	if globalArgs.ProtectMarkup {
+		trans = translator.NewMarkupProtection(trans)
 	}
 
-	trans, err := factory.CreateTranslator(cfg.LLM.Provider, config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize translator: %w", err)
+	if !globalArgs.NoCache {
+		cache, err := translator.OpenCache(globalArgs.CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open translation cache: %w", err)
+		}
+
+		slog.Debug("translation cache opened", slog.String("dir", globalArgs.CacheDir), slog.Int("entries", cache.Len()))
+
+		// Cached translations are served without waiting for the limits
+		trans = translator.NewCachedTranslator(trans, cache, cfg.LLM.Provider, cfg.LLM.cacheModel())
+	}
+
+	// Glossary terms are part of the cache key, so they are added before the cache is consulted
+	if gloss != nil {
+		trans = translator.NewGlossaryTranslator(trans, gloss)
+	}
+
+	// Approved translations of the memory take precedence over cached machine translations
+	if mem != nil {
+		trans = translator.NewMemoryTranslator(trans, mem, cfg.Memory.FuzzyThreshold)
 	}
 
 	return trans, nil
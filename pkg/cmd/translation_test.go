@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/ksysoev/gotext-translator/pkg/translator"
 	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,9 +24,9 @@ func TestProcessFile(t *testing.T) {
 
 	// Create a mock translator
 	mockTranslator := new(mocks.Translator)
-	mockTranslator.On("Translate", mock.Anything, "Hello, World!", "ru-RU").
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Hello, World!", "ru-RU")).
 		Return("Привет, Мир!", nil)
-	mockTranslator.On("Translate", mock.Anything, "Welcome to the app!", "ru-RU").
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Welcome to the app!", "ru-RU")).
 		Return("Добро пожаловать в приложение!", nil)
 
 	// Create a source file
@@ -34,11 +35,11 @@ func TestProcessFile(t *testing.T) {
 		Messages: []GotextMessage{
 			{
 				ID:      "greeting",
-				Message: "Hello, World!",
+				Message: Text{Msg: "Hello, World!"},
 			},
 			{
 				ID:      "welcome",
-				Message: "Welcome to the app!",
+				Message: Text{Msg: "Welcome to the app!"},
 			},
 		},
 	}
@@ -51,7 +52,7 @@ func TestProcessFile(t *testing.T) {
 
 	// Process the file
 	targetPath := filepath.Join(tempDir, "out.gotext.json")
-	count, err := processFile(context.Background(), mockTranslator, sourcePath, targetPath, "ru-RU")
+	count, err := processFile(context.Background(), &pipeline{trans: mockTranslator}, sourcePath, targetPath, "ru-RU")
 	assert.NoError(t, err)
 	assert.Equal(t, 2, count)
 
@@ -65,11 +66,11 @@ func TestProcessFile(t *testing.T) {
 	assert.Equal(t, "ru-RU", targetFile.Language)
 	assert.Len(t, targetFile.Messages, 2)
 	assert.Equal(t, "greeting", targetFile.Messages[0].ID)
-	assert.Equal(t, "Hello, World!", targetFile.Messages[0].Message)
-	assert.Equal(t, "Привет, Мир!", targetFile.Messages[0].Translation)
+	assert.Equal(t, "Hello, World!", targetFile.Messages[0].Message.Msg)
+	assert.Equal(t, "Привет, Мир!", targetFile.Messages[0].Translation.Msg)
 	assert.Equal(t, "welcome", targetFile.Messages[1].ID)
-	assert.Equal(t, "Welcome to the app!", targetFile.Messages[1].Message)
-	assert.Equal(t, "Добро пожаловать в приложение!", targetFile.Messages[1].Translation)
+	assert.Equal(t, "Welcome to the app!", targetFile.Messages[1].Message.Msg)
+	assert.Equal(t, "Добро пожаловать в приложение!", targetFile.Messages[1].Translation.Msg)
 
 	// Test with an existing file - only translate missing translations
 	existingFile := GotextFile{
@@ -77,12 +78,12 @@ func TestProcessFile(t *testing.T) {
 		Messages: []GotextMessage{
 			{
 				ID:          "greeting",
-				Message:     "Hello, World!",
-				Translation: "Привет, Мир!", // Already translated
+				Message:     Text{Msg: "Hello, World!"},
+				Translation: Text{Msg: "Привет, Мир!"}, // Already translated
 			},
 			{
 				ID:      "welcome",
-				Message: "Welcome to the app!",
+				Message: Text{Msg: "Welcome to the app!"},
 				// Missing translation
 			},
 		},
@@ -95,7 +96,7 @@ func TestProcessFile(t *testing.T) {
 	assert.NoError(t, err)
 
 	// Process the file
-	count, err = processFile(context.Background(), mockTranslator, sourcePath, existingPath, "ru-RU")
+	count, err = processFile(context.Background(), &pipeline{trans: mockTranslator}, sourcePath, existingPath, "ru-RU")
 	assert.NoError(t, err)
 	assert.Equal(t, 1, count) // Only one message should be translated
 
@@ -109,23 +110,23 @@ func TestProcessFile(t *testing.T) {
 	assert.Equal(t, "ru-RU", updatedFile.Language)
 	assert.Len(t, updatedFile.Messages, 2)
 	assert.Equal(t, "greeting", updatedFile.Messages[0].ID)
-	assert.Equal(t, "Hello, World!", updatedFile.Messages[0].Message)
-	assert.Equal(t, "Привет, Мир!", updatedFile.Messages[0].Translation)
+	assert.Equal(t, "Hello, World!", updatedFile.Messages[0].Message.Msg)
+	assert.Equal(t, "Привет, Мир!", updatedFile.Messages[0].Translation.Msg)
 	assert.Equal(t, "welcome", updatedFile.Messages[1].ID)
-	assert.Equal(t, "Welcome to the app!", updatedFile.Messages[1].Message)
-	assert.Equal(t, "Добро пожаловать в приложение!", updatedFile.Messages[1].Translation)
+	assert.Equal(t, "Welcome to the app!", updatedFile.Messages[1].Message.Msg)
+	assert.Equal(t, "Добро пожаловать в приложение!", updatedFile.Messages[1].Translation.Msg)
 
 	// Test with force rewrite
 	globalArgs.ForceRewrite = true
 
 	// Create updated expectations for force rewrite
 	mockTranslator = new(mocks.Translator)
-	mockTranslator.On("Translate", mock.Anything, "Hello, World!", "ru-RU").
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Hello, World!", "ru-RU")).
 		Return("Привет, Мир! (updated)", nil)
-	mockTranslator.On("Translate", mock.Anything, "Welcome to the app!", "ru-RU").
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Welcome to the app!", "ru-RU")).
 		Return("Добро пожаловать в приложение! (updated)", nil)
 
-	count, err = processFile(context.Background(), mockTranslator, sourcePath, existingPath, "ru-RU")
+	count, err = processFile(context.Background(), &pipeline{trans: mockTranslator}, sourcePath, existingPath, "ru-RU")
 	assert.NoError(t, err)
 	assert.Equal(t, 2, count) // Both messages should be translated
 
@@ -139,15 +140,61 @@ func TestProcessFile(t *testing.T) {
 	assert.Equal(t, "ru-RU", forceRewriteFile.Language)
 	assert.Len(t, forceRewriteFile.Messages, 2)
 	assert.Equal(t, "greeting", forceRewriteFile.Messages[0].ID)
-	assert.Equal(t, "Hello, World!", forceRewriteFile.Messages[0].Message)
-	assert.Equal(t, "Привет, Мир! (updated)", forceRewriteFile.Messages[0].Translation)
+	assert.Equal(t, "Hello, World!", forceRewriteFile.Messages[0].Message.Msg)
+	assert.Equal(t, "Привет, Мир! (updated)", forceRewriteFile.Messages[0].Translation.Msg)
 	assert.Equal(t, "Machine translated", forceRewriteFile.Messages[0].TranslatorComment)
 	assert.Equal(t, "welcome", forceRewriteFile.Messages[1].ID)
-	assert.Equal(t, "Welcome to the app!", forceRewriteFile.Messages[1].Message)
-	assert.Equal(t, "Добро пожаловать в приложение! (updated)", forceRewriteFile.Messages[1].Translation)
+	assert.Equal(t, "Welcome to the app!", forceRewriteFile.Messages[1].Message.Msg)
+	assert.Equal(t, "Добро пожаловать в приложение! (updated)", forceRewriteFile.Messages[1].Translation.Msg)
 	assert.Equal(t, "Machine translated", forceRewriteFile.Messages[1].TranslatorComment)
 }
 
+func TestNewRequest(t *testing.T) {
+	messages := []GotextMessage{
+		{ID: "first", Message: Text{Msg: "First"}, Translation: Text{Msg: "Первый"}},
+		{ID: "second", Message: Text{Msg: "Second"}},
+		{
+			ID:                "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+			Message:           Text{Msg: "Please, provide no more than {MaxAllowedPhotos} photo(s)"},
+			Comment:           "Shown when too many photos are attached",
+			TranslatorComment: machineTranslatedComment,
+			Placeholders: []Placeholder{
+				{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", Expr: "maxAllowedPhotos", ArgNum: 1},
+			},
+		},
+		{ID: "fourth", Message: Text{Msg: "Fourth"}},
+		{ID: "fifth", Message: Text{Msg: "Fifth"}},
+		{ID: "sixth", Message: Text{Msg: "Sixth"}},
+	}
+
+	req := newRequest(messages, 2, "en-GB", "ru-RU")
+
+	assert.Equal(t, "en-GB", req.SourceLang)
+	assert.Equal(t, "ru-RU", req.TargetLang)
+	assert.Equal(t, messages[2].ID, req.MessageID)
+	assert.Equal(t, "Shown when too many photos are attached", req.Comment)
+	assert.Equal(t, []translator.Placeholder{
+		{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", Expr: "maxAllowedPhotos"},
+	}, req.Placeholders)
+	assert.Equal(t, []translator.ContextMessage{
+		{Text: "First", Translation: "Первый"},
+		{Text: "Second"},
+		{Text: "Fourth"},
+		{Text: "Fifth"},
+	}, req.Neighbours)
+
+	// Neighbours are limited by the file boundaries
+	req = newRequest(messages, 0, "en-GB", "ru-RU")
+	assert.Len(t, req.Neighbours, 2)
+}
+
+// translationRequest matches a translation request for the text and target language
+func translationRequest(text, targetLang string) interface{} {
+	return mock.MatchedBy(func(req translator.Request) bool {
+		return req.Text == text && req.TargetLang == targetLang
+	})
+}
+
 // Reset globalArgs after tests
 func TestMain(m *testing.M) {
 	// Setup
@@ -161,3 +208,145 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+func TestProcessFile_UpdatesMemory(t *testing.T) {
+	globalArgs = &args{}
+
+	tempDir := t.TempDir()
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Hello, World!", "ru-RU")).
+		Return("Привет, Мир!", nil)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("%d files", "ru-RU")).
+		Return("файлы", nil)
+
+	sourceFile := GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{
+			{ID: "greeting", Message: Text{Msg: "Hello, World!"}},
+			{ID: "files", Message: Text{Msg: "%d files"}},
+			{ID: "review", Message: Text{Msg: "Review"}},
+		},
+	}
+
+	targetFile := GotextFile{
+		Language: "ru-RU",
+		Messages: []GotextMessage{
+			{ID: "review", Message: Text{Msg: "Review"}, Translation: Text{Msg: "Обзор"}, Fuzzy: true},
+		},
+	}
+
+	sourcePath := filepath.Join(tempDir, "messages.gotext.json")
+	sourceData, err := json.Marshal(sourceFile)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(sourcePath, sourceData, 0644))
+
+	targetPath := filepath.Join(tempDir, "ru-RU.gotext.json")
+	targetData, err := json.Marshal(targetFile)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(targetPath, targetData, 0644))
+
+	mem := translator.NewMemory()
+
+	count, err := processFile(context.Background(), &pipeline{trans: mockTranslator, memory: mem}, sourcePath, targetPath, "ru-RU")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// Accepted translations are stored, rejected and fuzzy ones are not
+	translation, ok := mem.Lookup("en-US", "ru-RU", "Hello, World!")
+	assert.True(t, ok)
+	assert.Equal(t, "Привет, Мир!", translation)
+
+	_, ok = mem.Lookup("en-US", "ru-RU", "%d files")
+	assert.False(t, ok)
+
+	_, ok = mem.Lookup("en-US", "ru-RU", "Review")
+	assert.False(t, ok)
+}
+
+func TestTranslateDirectory_MultipleLanguages(t *testing.T) {
+	globalArgs = &args{Concurrency: 2}
+
+	dir := t.TempDir()
+	sourceDir := filepath.Join(dir, "locales", "en-US")
+	assert.NoError(t, os.MkdirAll(sourceDir, 0755))
+
+	sourceData, err := json.Marshal(GotextFile{
+		Language: "en-US",
+		Messages: []GotextMessage{{ID: "greeting", Message: Text{Msg: "Hello"}}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(sourceDir, "messages.gotext.json"), sourceData, 0644))
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Hello", "de-DE")).Return("Hallo", nil)
+	mockTranslator.On("Translate", mock.Anything, translationRequest("Hello", "fr-FR")).Return("Bonjour", nil)
+
+	l, err := newLayout(dir, LayoutConfig{})
+	assert.NoError(t, err)
+
+	err = translateDirectory(context.Background(), &pipeline{trans: mockTranslator}, l, []string{"de-DE", "fr-FR"})
+	assert.NoError(t, err)
+
+	for lang, want := range map[string]string{"de-DE": "Hallo", "fr-FR": "Bonjour"} {
+		data, err := os.ReadFile(filepath.Join(dir, "locales", lang, "messages.gotext.json"))
+		assert.NoError(t, err)
+
+		var file GotextFile
+		assert.NoError(t, json.Unmarshal(data, &file))
+		assert.Equal(t, lang, file.Language)
+		assert.Equal(t, want, file.Messages[0].Translation.Msg)
+	}
+
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 2)
+}
+
+func TestOutputPathPattern(t *testing.T) {
+	path, err := outputPathPattern("locales/en-US/messages.gotext.json", "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("locales", "en-US", "out.gotext.json"), path)
+
+	path, err = outputPathPattern("locales/en-US/messages.gotext.json", "", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join("locales", "en-US", "{lang}", "out.gotext.json"), path)
+
+	path, err = outputPathPattern("messages.gotext.json", "locales/{lang}/messages.gotext.json", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "locales/{lang}/messages.gotext.json", path)
+
+	_, err = outputPathPattern("messages.gotext.json", "out.gotext.json", 2)
+	assert.ErrorContains(t, err, "must contain {lang}")
+}
+
+func TestResolveTargetLangs(t *testing.T) {
+	a := &args{}
+	assert.NoError(t, resolveTargetLangs(a, &Config{TargetLangs: []string{"de-DE", "fr-FR"}}))
+	assert.Equal(t, []string{"de-DE", "fr-FR"}, a.TargetLangs)
+
+	// Flags take precedence over the config file
+	a = &args{TargetLangs: []string{"ru-RU"}}
+	assert.NoError(t, resolveTargetLangs(a, &Config{TargetLangs: []string{"de-DE"}}))
+	assert.Equal(t, []string{"ru-RU"}, a.TargetLangs)
+
+	assert.ErrorContains(t, resolveTargetLangs(&args{}, &Config{}), "target language is required")
+	assert.ErrorContains(t, resolveTargetLangs(&args{TargetLangs: []string{"de-DE", "de-DE"}}, &Config{}), "invalid target languages")
+}
+
+func TestPrepareTranslator_ChainRateLimits(t *testing.T) {
+	globalArgs = &args{Concurrency: 1, NoCache: true}
+
+	cfg := &Config{LLM: LLMConfig{
+		Provider:          "chain",
+		RequestsPerMinute: 100,
+		Providers:         []LLMConfig{{Provider: "openai", APIKey: "key"}},
+	}}
+
+	_, err := prepareTranslator(context.Background(), cfg, nil, nil, translator.NewConcurrencySlots(1))
+	assert.ErrorContains(t, err, "rate limits of the chain provider are set on each of its providers")
+
+	cfg.LLM.RequestsPerMinute = 0
+	cfg.LLM.Providers[0].RequestsPerMinute = 100
+
+	_, err = prepareTranslator(context.Background(), cfg, nil, nil, translator.NewConcurrencySlots(1))
+	assert.NoError(t, err)
+}
@@ -12,6 +12,10 @@ import (
 
 const (
 	anthropicAPIURL = "https://api.anthropic.com/v1/messages"
+	// anthropicMaxTokens leaves room for the responses of batch translations
+	anthropicMaxTokens = 4096
+	// defaultAnthropicModel is the model used when none is configured
+	defaultAnthropicModel = "claude-3-haiku-20240307"
 )
 
 // AnthropicProvider provides translation using Anthropic Claude API
@@ -31,12 +35,19 @@ func (p *AnthropicProvider) CreateTranslator(config map[string]interface{}) (Tra
 
 	model, ok := config["model"].(string)
 	if !ok || model == "" {
-		model = "claude-3-haiku-20240307" // Default model
+		model = defaultAnthropicModel
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
 	}
 
 	return &AnthropicTranslator{
 		apiKey: apiKey,
 		model:  model,
+		url:    anthropicAPIURL,
+		retry:  retry,
 	}, nil
 }
 
@@ -44,6 +55,8 @@ func (p *AnthropicProvider) CreateTranslator(config map[string]interface{}) (Tra
 type AnthropicTranslator struct {
 	apiKey string
 	model  string
+	url    string
+	retry  RetryPolicy
 }
 
 // AnthropicRequest represents a request to the Anthropic API
@@ -67,23 +80,46 @@ type AnthropicResponse struct {
 		Text string `json:"text"`
 	} `json:"content"`
 	Error *struct {
+		Type    string `json:"type"`
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
-// Translate translates text to the specified target language
-func (t *AnthropicTranslator) Translate(ctx context.Context, text string, targetLang string) (string, error) {
-	systemPrompt := "You are a professional translator. Your task is to translate text accurately while preserving all formatting, placeholders, and special characters."
-	userPrompt := fmt.Sprintf("Please translate the following text to %s, preserving all formatting and placeholders:\n\n%s", targetLang, text)
+// Translate translates the text of the request to its target language
+func (t *AnthropicTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	translation, err := t.Complete(ctx, systemPrompt, buildUserPrompt(req))
+	if err != nil {
+		return "", err
+	}
+
+	// Sometimes the model might include extraneous text about the translation,
+	// so we try to extract just the translated content.
+	if strings.Contains(translation, "\n\n") {
+		parts := strings.SplitN(translation, "\n\n", 2)
+		if len(parts) > 1 && (strings.Contains(parts[0], "translation") || strings.Contains(parts[0], "Translation")) {
+			// If the first part looks like an explanation, return the second part
+			return strings.TrimSpace(parts[1]), nil
+		}
+	}
+
+	return translation, nil
+}
+
+// TranslateBatch translates all requests in a single call
+func (t *AnthropicTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateBatch(ctx, t, reqs)
+}
 
+// Complete returns the model response for the system and user prompts
+func (t *AnthropicTranslator) Complete(ctx context.Context, system, user string) (string, error) {
 	requestBody := AnthropicRequest{
 		Model:     t.model,
-		MaxTokens: 1024,
-		System:    systemPrompt,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
 		Messages: []AnthropicMessage{
 			{
 				Role:    "user",
-				Content: userPrompt,
+				Content: user,
 			},
 		},
 	}
@@ -93,32 +129,53 @@ func (t *AnthropicTranslator) Translate(ctx context.Context, text string, target
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIURL, bytes.NewBuffer(jsonData))
+	var translation string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translation, err = t.send(ctx, jsonData)
+		return err
+	})
+
+	return translation, err
+}
+
+// send sends a single request to the Anthropic API and returns the text of the response
+func (t *AnthropicTranslator) send(ctx context.Context, jsonData []byte) (string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", t.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", t.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
 	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", newRequestError(ctx, "Anthropic", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", newRequestError(ctx, "Anthropic", err)
 	}
 
 	var response AnthropicResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		var message string
+		if response.Error != nil {
+			message = response.Error.Message
+		}
+
+		return "", newStatusError("Anthropic", resp.StatusCode, resp.Header, message)
+	}
+
 	if response.Error != nil {
 		return "", fmt.Errorf("Anthropic API error: %s", response.Error.Message)
 	}
@@ -136,15 +193,5 @@ func (t *AnthropicTranslator) Translate(ctx context.Context, text string, target
 		return "", fmt.Errorf("empty or invalid response from Anthropic API")
 	}
 
-	// Sometimes the model might include extraneous text about the translation,
-	// so we try to extract just the translated content.
-	if strings.Contains(translation, "\n\n") {
-		parts := strings.SplitN(translation, "\n\n", 2)
-		if len(parts) > 1 && (strings.Contains(parts[0], "translation") || strings.Contains(parts[0], "Translation")) {
-			// If the first part looks like an explanation, return the second part
-			return strings.TrimSpace(parts[1]), nil
-		}
-	}
-
 	return translation, nil
 }
@@ -0,0 +1,65 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// azureOpenAIAPIVersion is the default version of the Azure OpenAI API
+const azureOpenAIAPIVersion = "2024-06-01"
+
+// AzureOpenAIProvider provides translation using deployments of Azure OpenAI
+type AzureOpenAIProvider struct{}
+
+// GetName returns the name of the provider
+func (p *AzureOpenAIProvider) GetName() string {
+	return "azure-openai"
+}
+
+// CreateTranslator creates a translator instance. The endpoint of the resource, the deployment
+// (or the model, if the deployment is named after it) and either an API key or a Microsoft Entra ID
+// bearer token are required.
+func (p *AzureOpenAIProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	endpoint, ok := config["endpoint"].(string)
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("Azure OpenAI endpoint is required")
+	}
+
+	deployment, ok := config["deployment"].(string)
+	if !ok || deployment == "" {
+		if deployment, ok = config["model"].(string); !ok || deployment == "" {
+			return nil, fmt.Errorf("Azure OpenAI deployment is required")
+		}
+	}
+
+	apiKey, _ := config["api_key"].(string)
+	token, _ := config["bearer_token"].(string)
+
+	if apiKey == "" && token == "" {
+		return nil, fmt.Errorf("Azure OpenAI API key or bearer token is required")
+	}
+
+	apiVersion, ok := config["api_version"].(string)
+	if !ok || apiVersion == "" {
+		apiVersion = azureOpenAIAPIVersion
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := openai.DefaultAzureConfig(apiKey, endpoint)
+
+	// The bearer token takes precedence, since it is given explicitly for the provider
+	if token != "" {
+		cfg = openai.DefaultAzureConfig(token, endpoint)
+		cfg.APIType = openai.APITypeAzureAD
+	}
+
+	cfg.APIVersion = apiVersion
+	cfg.AzureModelMapperFunc = func(string) string { return deployment }
+
+	return newOpenAITranslator(cfg, deployment, retry), nil
+}
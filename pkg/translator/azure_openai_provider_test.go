@@ -0,0 +1,113 @@
+package translator_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureOpenAIProvider_GetName(t *testing.T) {
+	provider := &translator.AzureOpenAIProvider{}
+	assert.Equal(t, "azure-openai", provider.GetName())
+}
+
+func TestAzureOpenAIProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.AzureOpenAIProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{
+		"endpoint":   "https://example.openai.azure.com",
+		"deployment": "gpt-4o-translations",
+		"api_key":    "key",
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, isBatch := trans.(translator.BatchTranslator)
+	assert.True(t, isBatch)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"deployment": "gpt-4o", "api_key": "key"})
+	assert.ErrorContains(t, err, "endpoint is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"endpoint": "https://example.openai.azure.com", "api_key": "key"})
+	assert.ErrorContains(t, err, "deployment is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"endpoint": "https://example.openai.azure.com", "deployment": "gpt-4o"})
+	assert.ErrorContains(t, err, "API key or bearer token is required")
+}
+
+func TestAzureOpenAITranslator_APIKey(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK,
+		`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`)
+
+	trans, err := (&translator.AzureOpenAIProvider{}).CreateTranslator(map[string]interface{}{
+		"endpoint":    srv.URL,
+		"deployment":  "gpt-4o-translations",
+		"api_version": "2024-10-21",
+		"api_key":     "azure-key",
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+
+	req := requests[0]
+	assert.Equal(t, "/openai/deployments/gpt-4o-translations/chat/completions", req.URL.Path)
+	assert.Equal(t, "2024-10-21", req.URL.Query().Get("api-version"))
+	assert.Equal(t, "azure-key", req.Header.Get("api-key"))
+	assert.Empty(t, req.Header.Get("Authorization"))
+}
+
+func TestAzureOpenAITranslator_BearerToken(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK,
+		`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`)
+
+	// The deployment is named after the model
+	trans, err := (&translator.AzureOpenAIProvider{}).CreateTranslator(map[string]interface{}{
+		"endpoint":     srv.URL + "/",
+		"model":        "gpt-4o",
+		"bearer_token": "entra-token",
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+
+	req := requests[0]
+	assert.Equal(t, "/openai/deployments/gpt-4o/chat/completions", req.URL.Path)
+	assert.Equal(t, "2024-06-01", req.URL.Query().Get("api-version"))
+	assert.Equal(t, "Bearer entra-token", req.Header.Get("Authorization"))
+	assert.Empty(t, req.Header.Get("api-key"))
+}
+
+func TestAzureOpenAITranslator_ContentFilter(t *testing.T) {
+	srv := newProviderServer(t, http.StatusBadRequest,
+		`{"error": {"code": "content_filter", "message": "The response was filtered", "status": 400}}`)
+
+	trans, err := (&translator.AzureOpenAIProvider{}).CreateTranslator(map[string]interface{}{
+		"endpoint":   srv.URL,
+		"deployment": "gpt-4o",
+		"api_key":    "azure-key",
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The response was filtered")
+
+	var apiErr *translator.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, translator.ErrorKindBadRequest, apiErr.Kind)
+	assert.Len(t, srv.Requests(), 1)
+}
@@ -0,0 +1,313 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	batchSystemPrompt = "You are a professional translator of software user interfaces. You translate JSON arrays of texts accurately while preserving all formatting, placeholders, and special characters. You always respond with a single valid JSON object and nothing else."
+
+	// charsPerToken is a rough estimate of the number of characters per token
+	charsPerToken = 4
+)
+
+// BatchOptions limits the size of the batches sent to a BatchTranslator
+type BatchOptions struct {
+	// MaxItems is the maximum number of requests in a batch, values below 2 disable batching
+	MaxItems int
+	// MaxTokens is the estimated maximum number of tokens of the texts in a batch, 0 means no limit
+	MaxTokens int
+	// Concurrency is the number of batches translated at the same time, values below 1 mean one
+	Concurrency int
+}
+
+// Result is the outcome of translating a single request
+type Result struct {
+	Translation string
+	Err         error
+}
+
+// batchItem is a request as sent to the model in a batch
+type batchItem struct {
+	Key            string             `json:"key"`
+	Text           string             `json:"text"`
+	MessageID      string             `json:"message_id,omitempty"`
+	Comment        string             `json:"comment,omitempty"`
+	Placeholders   []batchPlaceholder `json:"placeholders,omitempty"`
+	PluralCategory string             `json:"plural_category,omitempty"`
+	References     []batchReference   `json:"references,omitempty"`
+	Glossary       []batchTerm        `json:"glossary,omitempty"`
+}
+
+// batchTerm is a glossary term with its mandated translation as sent to the model in a batch
+type batchTerm struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Note   string `json:"note,omitempty"`
+}
+
+// batchReference is a similar text with an approved translation as sent to the model in a batch
+type batchReference struct {
+	Source      string `json:"source"`
+	Translation string `json:"translation"`
+}
+
+// batchPlaceholder is a placeholder description as sent to the model in a batch
+type batchPlaceholder struct {
+	ID     string `json:"id"`
+	String string `json:"string,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Expr   string `json:"expr,omitempty"`
+}
+
+// TranslateAll translates all requests. If the translator implements BatchTranslator, the requests
+// are sent in batches limited by opts, and the requests missing from a batch response are translated
+// one by one. Otherwise every request is translated separately. Up to opts.Concurrency batches or
+// requests are translated at the same time. The results are in the order of reqs, once the context
+// is done the requests not sent yet fail with the error of the context.
+func TranslateAll(ctx context.Context, t Translator, reqs []Request, opts BatchOptions) []Result {
+	results := make([]Result, len(reqs))
+
+	var batches [][]int
+
+	bt, ok := t.(BatchTranslator)
+	if ok && opts.MaxItems >= 2 {
+		batches = splitBatches(reqs, opts)
+	} else {
+		for i := range reqs {
+			batches = append(batches, []int{i})
+		}
+	}
+
+	workers := max(1, opts.Concurrency)
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+
+	for n, batch := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		// A slot may be freed by a worker stopped by the context
+		if err := ctx.Err(); err != nil {
+			for _, batch := range batches[n:] {
+				for _, i := range batch {
+					results[i].Err = err
+				}
+			}
+
+			break
+		}
+
+		wg.Add(1)
+
+		go func(batch []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			translateBatchItems(ctx, t, bt, reqs, batch, results)
+		}(batch)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// translateBatchItems translates the requests at the batch indexes and stores them in results.
+// Single requests and the ones missing from the batch response are translated one by one.
+func translateBatchItems(ctx context.Context, t Translator, bt BatchTranslator, reqs []Request, batch []int, results []Result) {
+	if len(batch) == 1 || bt == nil {
+		for _, i := range batch {
+			results[i].Translation, results[i].Err = t.Translate(ctx, reqs[i])
+		}
+
+		return
+	}
+
+	batchReqs := make([]Request, len(batch))
+	for j, i := range batch {
+		batchReqs[j] = reqs[i]
+	}
+
+	translations, err := bt.TranslateBatch(ctx, batchReqs)
+	if err != nil {
+		slog.Warn("batch translation failed, translating messages one by one",
+			slog.Int("size", len(batch)),
+			slog.String("error", err.Error()))
+	}
+
+	for j, i := range batch {
+		if err == nil && j < len(translations) && translations[j] != "" {
+			results[i].Translation = translations[j]
+			continue
+		}
+
+		results[i].Translation, results[i].Err = t.Translate(ctx, reqs[i])
+	}
+}
+
+// splitBatches groups the indexes of the requests into batches limited by opts. Requests of a
+// batch always share the same source and target languages.
+func splitBatches(reqs []Request, opts BatchOptions) [][]int {
+	var (
+		batches [][]int
+		current []int
+		tokens  int
+	)
+
+	for i, req := range reqs {
+		reqTokens := estimateTokens(req.Text)
+
+		if len(current) > 0 {
+			first := reqs[current[0]]
+
+			full := len(current) >= opts.MaxItems ||
+				(opts.MaxTokens > 0 && tokens+reqTokens > opts.MaxTokens) ||
+				first.SourceLang != req.SourceLang || first.TargetLang != req.TargetLang
+
+			if full {
+				batches = append(batches, current)
+				current, tokens = nil, 0
+			}
+		}
+
+		current = append(current, i)
+		tokens += reqTokens
+	}
+
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// estimateTokens returns a rough estimate of the number of tokens in the text
+func estimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// translateBatch translates the requests with a single completion, asking the model for a JSON
+// object that maps the key of every request to its translation. It is shared by the providers
+// implementing BatchTranslator.
+func translateBatch(ctx context.Context, c Completer, reqs []Request) ([]string, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+
+	prompt, err := buildBatchPrompt(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := c.Complete(ctx, batchSystemPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	translated, err := parseBatchResponse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	translations := make([]string, len(reqs))
+	for i := range reqs {
+		translations[i] = translated[strconv.Itoa(i+1)]
+	}
+
+	return translations, nil
+}
+
+// buildBatchPrompt renders the requests into a prompt asking for their translation as JSON
+func buildBatchPrompt(reqs []Request) (string, error) {
+	items := make([]batchItem, len(reqs))
+
+	for i, req := range reqs {
+		items[i] = batchItem{
+			Key:            strconv.Itoa(i + 1),
+			Text:           req.Text,
+			Comment:        req.Comment,
+			PluralCategory: req.PluralCategory,
+		}
+
+		if req.MessageID != req.Text {
+			items[i].MessageID = req.MessageID
+		}
+
+		for _, ph := range req.Placeholders {
+			items[i].Placeholders = append(items[i].Placeholders, batchPlaceholder(ph))
+		}
+
+		for _, ref := range req.References {
+			items[i].References = append(items[i].References, batchReference{Source: ref.Source, Translation: ref.Translation})
+		}
+
+		for _, term := range req.Glossary {
+			items[i].Glossary = append(items[i].Glossary, batchTerm(term))
+		}
+	}
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	var sb strings.Builder
+
+	if reqs[0].SourceLang != "" {
+		fmt.Fprintf(&sb, "Translate the \"text\" of every item of the following JSON array from %s to %s.", reqs[0].SourceLang, reqs[0].TargetLang)
+	} else {
+		fmt.Fprintf(&sb, "Translate the \"text\" of every item of the following JSON array to %s.", reqs[0].TargetLang)
+	}
+
+	sb.WriteString(` Preserve any formatting, placeholders, and special characters.
+Items may have a "message_id", a "comment" from the developers, the "placeholders" used in the text, which must be kept exactly once and unchanged, a "plural_category" telling which grammatical plural form the text is used for, "references" with approved translations of similar texts whose terminology and style should be followed, and "glossary" terms that must be translated exactly as the given "target", which equals the "source" for terms that must not be translated. Use them as context only.
+Texts may contain tokens like ⟦1⟧ that stand for HTML tags, links, emails and commands. Keep each of them exactly once and unchanged, next to the words they belong to.
+
+Respond with a single JSON object mapping the "key" of every item to its translation, e.g. {"1": "translation of item 1", "2": "translation of item 2"}.
+
+`)
+	sb.Write(data)
+
+	return sb.String(), nil
+}
+
+// parseBatchResponse extracts the key to translation map from the model response. Values that are
+// not strings are skipped, so their requests are translated again separately.
+func parseBatchResponse(content string) (map[string]string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in batch response")
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+
+	translations := make(map[string]string, len(raw))
+
+	for key, value := range raw {
+		var translation string
+		if err := json.Unmarshal(value, &translation); err != nil {
+			slog.Debug("skipping invalid batch item", slog.String("key", key), slog.String("error", err.Error()))
+			continue
+		}
+
+		translations[key] = translation
+	}
+
+	return translations, nil
+}
@@ -0,0 +1,178 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeCompleter returns a fixed response and records the prompts it receives
+type fakeCompleter struct {
+	response string
+	err      error
+	prompts  []string
+}
+
+func (c *fakeCompleter) Complete(_ context.Context, _ string, user string) (string, error) {
+	c.prompts = append(c.prompts, user)
+	return c.response, c.err
+}
+
+// fakeBatchTranslator translates texts by upper-casing them, dropping batch items listed in skip
+type fakeBatchTranslator struct {
+	skip    map[string]bool
+	batches [][]string
+	single  []string
+}
+
+func (t *fakeBatchTranslator) Translate(_ context.Context, req Request) (string, error) {
+	t.single = append(t.single, req.Text)
+	return strings.ToUpper(req.Text), nil
+}
+
+func (t *fakeBatchTranslator) TranslateBatch(_ context.Context, reqs []Request) ([]string, error) {
+	texts := make([]string, len(reqs))
+	translations := make([]string, len(reqs))
+
+	for i, req := range reqs {
+		texts[i] = req.Text
+		if !t.skip[req.Text] {
+			translations[i] = strings.ToUpper(req.Text)
+		}
+	}
+
+	t.batches = append(t.batches, texts)
+
+	return translations, nil
+}
+
+func TestSplitBatches(t *testing.T) {
+	reqs := []Request{
+		{Text: "one", TargetLang: "ru-RU"},
+		{Text: "two", TargetLang: "ru-RU"},
+		{Text: "three", TargetLang: "ru-RU"},
+		{Text: strings.Repeat("long text ", 10), TargetLang: "ru-RU"},
+		{Text: "five", TargetLang: "fr-FR"},
+	}
+
+	// Limited by items and languages
+	batches := splitBatches(reqs, BatchOptions{MaxItems: 2})
+	assert.Equal(t, [][]int{{0, 1}, {2, 3}, {4}}, batches)
+
+	// Limited by tokens
+	batches = splitBatches(reqs, BatchOptions{MaxItems: 10, MaxTokens: 10})
+	assert.Equal(t, [][]int{{0, 1, 2}, {3}, {4}}, batches)
+}
+
+func TestParseBatchResponse(t *testing.T) {
+	translations, err := parseBatchResponse("```json\n{\"1\": \"Привет\", \"2\": \"Мир\", \"3\": 42}\n```")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"1": "Привет", "2": "Мир"}, translations)
+
+	_, err = parseBatchResponse("Sorry, I cannot help with that")
+	assert.Error(t, err)
+
+	_, err = parseBatchResponse("{\"1\": \"broken}")
+	assert.Error(t, err)
+}
+
+func TestTranslateBatch(t *testing.T) {
+	completer := &fakeCompleter{response: `{"1": "Привет", "3": "Неизвестная команда"}`}
+
+	reqs := []Request{
+		{Text: "Hello", SourceLang: "en-GB", TargetLang: "ru-RU", MessageID: "Hello"},
+		{Text: "%[1]d photos", SourceLang: "en-GB", TargetLang: "ru-RU", MessageID: "photos", PluralCategory: "few",
+			Placeholders: []Placeholder{{ID: "Count", String: "%[1]d", Type: "int"}}},
+		{Text: "Unknown command", SourceLang: "en-GB", TargetLang: "ru-RU"},
+	}
+
+	translations, err := translateBatch(context.Background(), completer, reqs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Привет", "", "Неизвестная команда"}, translations)
+
+	// The prompt contains the items as JSON
+	assert.Len(t, completer.prompts, 1)
+	prompt := completer.prompts[0]
+	assert.Contains(t, prompt, "from en-GB to ru-RU")
+
+	var items []batchItem
+	err = json.Unmarshal([]byte(prompt[strings.Index(prompt, "["):]), &items)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "1", items[0].Key)
+	assert.Empty(t, items[0].MessageID)
+	assert.Equal(t, "photos", items[1].MessageID)
+	assert.Equal(t, "few", items[1].PluralCategory)
+	assert.Equal(t, []batchPlaceholder{{ID: "Count", String: "%[1]d", Type: "int"}}, items[1].Placeholders)
+
+	// Errors of the completion are returned
+	completer = &fakeCompleter{err: fmt.Errorf("test error")}
+	_, err = translateBatch(context.Background(), completer, reqs)
+	assert.Error(t, err)
+}
+
+func TestTranslateAll(t *testing.T) {
+	reqs := []Request{
+		{Text: "one", TargetLang: "ru-RU"},
+		{Text: "two", TargetLang: "ru-RU"},
+		{Text: "three", TargetLang: "ru-RU"},
+	}
+
+	// Items missing from the batch response are translated one by one
+	trans := &fakeBatchTranslator{skip: map[string]bool{"two": true}}
+	results := TranslateAll(context.Background(), trans, reqs, BatchOptions{MaxItems: 10})
+
+	assert.Equal(t, []Result{{Translation: "ONE"}, {Translation: "TWO"}, {Translation: "THREE"}}, results)
+	assert.Equal(t, [][]string{{"one", "two", "three"}}, trans.batches)
+	assert.Equal(t, []string{"two"}, trans.single)
+
+	// Batching is disabled
+	trans = &fakeBatchTranslator{}
+	results = TranslateAll(context.Background(), trans, reqs, BatchOptions{MaxItems: 1})
+
+	assert.Equal(t, []Result{{Translation: "ONE"}, {Translation: "TWO"}, {Translation: "THREE"}}, results)
+	assert.Empty(t, trans.batches)
+	assert.Equal(t, []string{"one", "two", "three"}, trans.single)
+}
+
+// blockingTranslator translates until the context is done
+type blockingTranslator struct {
+	started chan struct{}
+	calls   atomic.Int32
+}
+
+func (t *blockingTranslator) Translate(ctx context.Context, _ Request) (string, error) {
+	if t.calls.Add(1) == 1 {
+		close(t.started)
+	}
+
+	<-ctx.Done()
+
+	return "", ctx.Err()
+}
+
+func TestTranslateAll_Cancelled(t *testing.T) {
+	reqs := []Request{{Text: "one"}, {Text: "two"}, {Text: "three"}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	trans := &blockingTranslator{started: make(chan struct{})}
+
+	go func() {
+		<-trans.started
+		cancel()
+	}()
+
+	results := TranslateAll(ctx, trans, reqs, BatchOptions{Concurrency: 1})
+
+	// The requests waiting for a worker are not sent once the context is done
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+
+	assert.Equal(t, int32(1), trans.calls.Load())
+}
@@ -0,0 +1,331 @@
+package translator
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// cacheFileName is the name of the file storing the cached translations in the cache directory
+const cacheFileName = "translations.jsonl"
+
+// cacheEntry is a line of the cache file
+type cacheEntry struct {
+	Key         string `json:"key"`
+	Translation string `json:"translation"`
+}
+
+// Cache is a persistent store of translations. Entries are appended to a single JSON lines file,
+// so the cache survives interrupted runs and can be shared by sequential runs.
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]string
+}
+
+// CacheStats describes the content of a cache directory
+type CacheStats struct {
+	Path    string
+	Entries int
+	Size    int64
+}
+
+// OpenCache loads the cache stored in dir, creating the directory if it does not exist
+func OpenCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	path := filepath.Join(dir, cacheFileName)
+
+	entries, err := readCacheFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cache{
+		path:    path,
+		entries: entries,
+	}, nil
+}
+
+// Get returns the cached translation for the key
+func (c *Cache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	translation, ok := c.entries[key]
+
+	return translation, ok
+}
+
+// Put stores the translation for the key
+func (c *Cache) Put(key, translation string) error {
+	data, err := json.Marshal(cacheEntry{Key: key, Translation: translation})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open cache file: %w", err)
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	c.entries[key] = translation
+
+	return nil
+}
+
+// Len returns the number of cached translations
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// GetCacheStats returns the statistics of the cache stored in dir
+func GetCacheStats(dir string) (CacheStats, error) {
+	path := filepath.Join(dir, cacheFileName)
+	stats := CacheStats{Path: path}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return stats, nil
+	} else if err != nil {
+		return stats, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	entries, err := readCacheFile(path)
+	if err != nil {
+		return stats, err
+	}
+
+	stats.Entries = len(entries)
+	stats.Size = info.Size()
+
+	return stats, nil
+}
+
+// ClearCache removes all translations cached in dir
+func ClearCache(dir string) error {
+	err := os.Remove(filepath.Join(dir, cacheFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove cache file: %w", err)
+	}
+
+	return nil
+}
+
+// readCacheFile reads the entries of the cache file, later entries override earlier ones.
+// Lines that cannot be parsed, e.g. a line cut by an interrupted write, are skipped.
+func readCacheFile(path string) (map[string]string, error) {
+	entries := make(map[string]string)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open cache file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var entry cacheEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Key == "" {
+			slog.Debug("skipping invalid cache entry", slog.String("path", path))
+			continue
+		}
+
+		entries[entry.Key] = entry.Translation
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// CacheKey returns the cache key of the request translated by the model of the provider. The comment
+// and placeholders of the message are part of the key, as they tell apart messages with the same text.
+func CacheKey(provider, model string, req Request) string {
+	h := sha256.New()
+
+	for _, part := range []string{promptVersion, provider, model, req.SourceLang, req.TargetLang, req.PluralCategory, req.Comment, req.Text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	for _, ph := range req.Placeholders {
+		h.Write([]byte(ph.ID))
+		h.Write([]byte{0})
+		h.Write([]byte(ph.String))
+		h.Write([]byte{0})
+	}
+
+	// Glossary terms mandate parts of the translation, so a changed glossary must not reuse translations
+	for _, term := range req.Glossary {
+		h.Write([]byte(term.Source))
+		h.Write([]byte{0})
+		h.Write([]byte(term.Target))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bypassCacheKey is the context key marking requests that must not be served from the cache
+type bypassCacheKey struct{}
+
+// BypassCache returns a context for requests that must be translated again even if they are cached
+// or in the translation memory, e.g. because the stored translation was rejected. The new translation
+// replaces the cached one.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// cacheBypassed reports whether the context was created by BypassCache
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// cachedTranslator serves translations from the cache and stores new translations in it
+type cachedTranslator struct {
+	translator Translator
+	cache      *Cache
+	provider   string
+	model      string
+}
+
+// batchCachedTranslator serves batch translations from the cache and stores new translations in it
+type batchCachedTranslator struct {
+	*cachedTranslator
+	batch BatchTranslator
+}
+
+// NewCachedTranslator wraps the translator so that texts already translated by the same model of
+// the provider are served from the cache. The returned translator implements BatchTranslator if t does.
+func NewCachedTranslator(t Translator, cache *Cache, provider, model string) Translator {
+	cached := &cachedTranslator{
+		translator: t,
+		cache:      cache,
+		provider:   provider,
+		model:      model,
+	}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchCachedTranslator{
+			cachedTranslator: cached,
+			batch:            bt,
+		}
+	}
+
+	return cached
+}
+
+// Translate returns the cached translation of the request or translates it with the wrapped translator
+func (t *cachedTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	key := CacheKey(t.provider, t.model, req)
+
+	if translation, ok := t.lookup(ctx, key); ok {
+		return translation, nil
+	}
+
+	translation, err := t.translator.Translate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	t.store(key, translation)
+
+	return translation, nil
+}
+
+// TranslateBatch serves the cached translations and translates the rest with the wrapped translator
+func (t *batchCachedTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	translations := make([]string, len(reqs))
+	keys := make([]string, len(reqs))
+
+	var (
+		missing []int
+		misses  []Request
+	)
+
+	for i, req := range reqs {
+		keys[i] = CacheKey(t.provider, t.model, req)
+
+		if translation, ok := t.lookup(ctx, keys[i]); ok {
+			translations[i] = translation
+			continue
+		}
+
+		missing = append(missing, i)
+		misses = append(misses, req)
+	}
+
+	if len(misses) == 0 {
+		return translations, nil
+	}
+
+	translated, err := t.batch.TranslateBatch(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		if j >= len(translated) || translated[j] == "" {
+			continue
+		}
+
+		translations[i] = translated[j]
+		t.store(keys[i], translated[j])
+	}
+
+	return translations, nil
+}
+
+// lookup returns the cached translation unless the context bypasses the cache
+func (t *cachedTranslator) lookup(ctx context.Context, key string) (string, bool) {
+	if cacheBypassed(ctx) {
+		return "", false
+	}
+
+	return t.cache.Get(key)
+}
+
+// store saves the translation in the cache. Failures are logged only, since the translation
+// itself succeeded.
+func (t *cachedTranslator) store(key, translation string) {
+	if translation == "" {
+		return
+	}
+
+	if err := t.cache.Put(key, translation); err != nil {
+		slog.Warn("failed to cache translation", slog.String("error", err.Error()))
+	}
+}
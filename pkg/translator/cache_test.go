@@ -0,0 +1,158 @@
+package translator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKey(t *testing.T) {
+	req := translator.Request{Text: "Hello", SourceLang: "en", TargetLang: "fr"}
+	key := translator.CacheKey("openai", "gpt-4", req)
+
+	// The ID of the message does not change the key
+	withID := req
+	withID.MessageID = "greeting"
+	assert.Equal(t, key, translator.CacheKey("openai", "gpt-4", withID))
+
+	// Messages with the same text are told apart by their comments and placeholders
+	other := req
+	other.Comment = "Greeting"
+	assert.NotEqual(t, key, translator.CacheKey("openai", "gpt-4", other))
+
+	other = req
+	other.Placeholders = []translator.Placeholder{{ID: "Name", String: "%[1]s"}}
+	assert.NotEqual(t, key, translator.CacheKey("openai", "gpt-4", other))
+
+	other = req
+	other.TargetLang = "de"
+	assert.NotEqual(t, key, translator.CacheKey("openai", "gpt-4", other))
+
+	other = req
+	other.PluralCategory = "few"
+	assert.NotEqual(t, key, translator.CacheKey("openai", "gpt-4", other))
+
+	assert.NotEqual(t, key, translator.CacheKey("openai", "gpt-4o", req))
+	assert.NotEqual(t, key, translator.CacheKey("anthropic", "gpt-4", req))
+}
+
+func TestCache_Persistence(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+
+	cache, err := translator.OpenCache(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Len())
+
+	require.NoError(t, cache.Put("a", "first"))
+	require.NoError(t, cache.Put("b", "second"))
+	require.NoError(t, cache.Put("a", "updated"))
+
+	// A line cut by an interrupted write is skipped
+	f, err := os.OpenFile(filepath.Join(dir, "translations.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"key": "c", "transl`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	reopened, err := translator.OpenCache(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reopened.Len())
+
+	translation, ok := reopened.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "updated", translation)
+
+	stats, err := translator.GetCacheStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Positive(t, stats.Size)
+
+	require.NoError(t, translator.ClearCache(dir))
+	require.NoError(t, translator.ClearCache(dir))
+
+	stats, err = translator.GetCacheStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Entries)
+}
+
+func TestNewCachedTranslator(t *testing.T) {
+	cache, err := translator.OpenCache(t.TempDir())
+	require.NoError(t, err)
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, mock.Anything).Return("Bonjour", nil).Once()
+
+	cached := translator.NewCachedTranslator(mockTranslator, cache, "openai", "gpt-4")
+
+	_, isBatch := cached.(translator.BatchTranslator)
+	assert.False(t, isBatch)
+
+	req := translator.Request{Text: "Hello", TargetLang: "fr"}
+
+	for range 2 {
+		result, err := cached.Translate(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "Bonjour", result)
+	}
+
+	mockTranslator.AssertExpectations(t)
+
+	// Bypassing the cache translates again and replaces the cached translation
+	mockTranslator.On("Translate", mock.Anything, mock.Anything).Return("Salut", nil).Once()
+
+	result, err := cached.Translate(translator.BypassCache(context.Background()), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Salut", result)
+
+	result, err = cached.Translate(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "Salut", result)
+
+	mockTranslator.AssertExpectations(t)
+}
+
+func TestNewCachedTranslator_Batch(t *testing.T) {
+	cache, err := translator.OpenCache(t.TempDir())
+	require.NoError(t, err)
+
+	hello := translator.Request{Text: "Hello", TargetLang: "fr"}
+	bye := translator.Request{Text: "Bye", TargetLang: "fr"}
+	thanks := translator.Request{Text: "Thanks", TargetLang: "fr"}
+
+	require.NoError(t, cache.Put(translator.CacheKey("openai", "gpt-4", hello), "Bonjour"))
+
+	mockTranslator := new(mocks.BatchTranslator)
+	mockTranslator.On("TranslateBatch", mock.Anything, []translator.Request{bye, thanks}).
+		Return([]string{"Au revoir", ""}, nil).Once()
+
+	cached := translator.NewCachedTranslator(mockTranslator, cache, "openai", "gpt-4")
+
+	batch, ok := cached.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{hello, bye, thanks})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "Au revoir", ""}, translations)
+
+	// Missing translations are not cached
+	translation, ok := cache.Get(translator.CacheKey("openai", "gpt-4", bye))
+	assert.True(t, ok)
+	assert.Equal(t, "Au revoir", translation)
+
+	_, ok = cache.Get(translator.CacheKey("openai", "gpt-4", thanks))
+	assert.False(t, ok)
+
+	// Fully cached batches do not call the translator
+	translations, err = batch.TranslateBatch(context.Background(), []translator.Request{hello, bye})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "Au revoir"}, translations)
+
+	mockTranslator.AssertExpectations(t)
+}
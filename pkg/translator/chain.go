@@ -0,0 +1,266 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const (
+	// ChainProviderName is the name of the composite provider trying a list of providers in order
+	ChainProviderName = "chain"
+	// FallbackProviderName is an alias of ChainProviderName
+	FallbackProviderName = "fallback"
+)
+
+// ChainLink is a translator of a chain with the name it is recorded under
+type ChainLink struct {
+	// Name identifies the provider and model, e.g. "anthropic/claude-3-haiku-20240307"
+	Name       string
+	Translator Translator
+}
+
+// chainTranslator tries its links in order until one of them translates the request
+type chainTranslator struct {
+	links []ChainLink
+}
+
+// batchChainTranslator is a chain whose first link translates batches
+type batchChainTranslator struct {
+	*chainTranslator
+}
+
+// NewChain creates a translator that tries the links in order, falling through to the next link
+// when a link fails with a retryable error, e.g. an overloaded API, or does not support the
+// languages of the request. Other errors are returned immediately. The link that produced a
+// translation is recorded in the Provenance of the context. The returned translator implements
+// BatchTranslator if the first link does.
+func NewChain(links []ChainLink) Translator {
+	ct := &chainTranslator{links: links}
+
+	if len(links) > 0 {
+		if _, ok := links[0].Translator.(BatchTranslator); ok {
+			return &batchChainTranslator{chainTranslator: ct}
+		}
+	}
+
+	return ct
+}
+
+// Translate translates the request with the first link that succeeds
+func (t *chainTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	var lastErr error
+
+	for i, link := range t.links {
+		translation, err := link.Translator.Translate(ctx, req)
+		if err == nil {
+			recordProvenance(ctx, req, link.Name)
+			return translation, nil
+		}
+
+		if !t.fallThrough(ctx, i, err) {
+			return "", err
+		}
+
+		lastErr = err
+	}
+
+	return "", fmt.Errorf("all providers of the chain failed: %w", lastErr)
+}
+
+// TranslateBatch translates the requests with the first link that succeeds. Links that don't
+// translate batches end the chain with an error, so the requests are translated one by one.
+func (t *batchChainTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	var lastErr error
+
+	for i, link := range t.links {
+		bt, ok := link.Translator.(BatchTranslator)
+		if !ok {
+			return nil, fmt.Errorf("provider %s of the chain does not translate batches: %w", link.Name, lastErr)
+		}
+
+		translations, err := bt.TranslateBatch(ctx, reqs)
+		if err == nil {
+			for j, req := range reqs {
+				if j < len(translations) && translations[j] != "" {
+					recordProvenance(ctx, req, link.Name)
+				}
+			}
+
+			return translations, nil
+		}
+
+		if !t.fallThrough(ctx, i, err) {
+			return nil, err
+		}
+
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("all providers of the chain failed: %w", lastErr)
+}
+
+// fallThrough reports whether the error of the link at index i lets the next link try
+func (t *chainTranslator) fallThrough(ctx context.Context, i int, err error) bool {
+	var langErr *UnsupportedLanguageError
+	if ctx.Err() != nil || (!IsRetryable(err) && !errors.As(err, &langErr)) {
+		return false
+	}
+
+	if i+1 < len(t.links) {
+		slog.Warn("provider failed, falling through to the next provider of the chain",
+			slog.String("provider", t.links[i].Name),
+			slog.String("next", t.links[i+1].Name),
+			slog.String("error", err.Error()))
+	}
+
+	return true
+}
+
+// newChainLinks creates the links of a chain from the "providers" list of the config. Every entry
+// is the config of a provider, with the name of the provider under "provider", and is limited by its
+// own "requests_per_minute" and "tokens_per_minute".
+func newChainLinks(config map[string]interface{}, create func(providerName string, config map[string]interface{}) (Translator, error)) ([]ChainLink, error) {
+	var entries []map[string]interface{}
+
+	switch providers := config["providers"].(type) {
+	case []map[string]interface{}:
+		entries = providers
+	case []interface{}:
+		for _, p := range providers {
+			entry, ok := p.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid chain provider config %v", p)
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("chain provider requires a list of providers")
+	}
+
+	links := make([]ChainLink, 0, len(entries))
+
+	for i, entry := range entries {
+		name, _ := entry["provider"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("provider of chain entry %d is required", i+1)
+		}
+
+		trans, err := create(name, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %s of the chain: %w", name, err)
+		}
+
+		// Every provider is called on its own, so it keeps its own budgets
+		limits, err := newRateLimits(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %s of the chain: %w", name, err)
+		}
+
+		trans = NewRateLimit(trans, limits)
+
+		if model, _ := entry["model"].(string); model != "" {
+			name += "/" + model
+		}
+
+		links = append(links, ChainLink{Name: name, Translator: trans})
+	}
+
+	return links, nil
+}
+
+// provenanceKey is the context key of the Provenance of translations
+type provenanceKey struct{}
+
+// Provenance records which providers of a chain or an ensemble produced the translations of messages
+type Provenance struct {
+	mu        sync.Mutex
+	providers map[string][]string
+	ensembles []EnsembleResult
+}
+
+// WithProvenance returns a context recording the providers that produce the translations of requests
+// made with it
+func WithProvenance(ctx context.Context) (context.Context, *Provenance) {
+	p := &Provenance{providers: make(map[string][]string)}
+	return context.WithValue(ctx, provenanceKey{}, p), p
+}
+
+// ProvenanceFromContext returns the Provenance of the context, nil if it has none
+func ProvenanceFromContext(ctx context.Context) *Provenance {
+	p, _ := ctx.Value(provenanceKey{}).(*Provenance)
+	return p
+}
+
+// Providers returns the names of the providers that produced translations of the message, in the
+// order of their first translation. It is empty if no translation was produced by a chain.
+func (p *Provenance) Providers(messageID string) []string {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.providers[messageID]...)
+}
+
+// recordProvenance records the provider that produced the translation of the request, if the
+// context was created by WithProvenance
+func recordProvenance(ctx context.Context, req Request, provider string) {
+	p, ok := ctx.Value(provenanceKey{}).(*Provenance)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, name := range p.providers[req.MessageID] {
+		if name == provider {
+			return
+		}
+	}
+
+	p.providers[req.MessageID] = append(p.providers[req.MessageID], provider)
+}
+
+// Ensembles returns the candidates of the translations selected by an ensemble, in the order of the
+// first selection for every text. A text translated again, e.g. after its translation was rejected,
+// keeps only the result of the last selection.
+func (p *Provenance) Ensembles() []EnsembleResult {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]EnsembleResult(nil), p.ensembles...)
+}
+
+// recordEnsemble records the candidates of a translation selected by an ensemble, if the context was
+// created by WithProvenance. It replaces the result of an earlier selection for the same text.
+func recordEnsemble(ctx context.Context, result EnsembleResult) {
+	p, ok := ctx.Value(provenanceKey{}).(*Provenance)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, prev := range p.ensembles {
+		if prev.MessageID == result.MessageID && prev.PluralCategory == result.PluralCategory && prev.Text == result.Text {
+			p.ensembles[i] = result
+			return
+		}
+	}
+
+	p.ensembles = append(p.ensembles, result)
+}
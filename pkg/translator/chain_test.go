@@ -0,0 +1,193 @@
+package translator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errOverloaded = &translator.APIError{Provider: "Anthropic", Kind: translator.ErrorKindOverloaded, Message: "Overloaded"}
+
+func TestChain_Translate(t *testing.T) {
+	anthropic := new(mocks.Translator)
+	anthropic.On("Translate", mock.Anything, mock.Anything).Return("", errOverloaded)
+
+	openRouter := new(mocks.Translator)
+	openRouter.On("Translate", mock.Anything, mock.Anything).Return("Bonjour", nil)
+
+	openAI := new(mocks.Translator)
+
+	chain := translator.NewChain([]translator.ChainLink{
+		{Name: "anthropic", Translator: anthropic},
+		{Name: "openrouter", Translator: openRouter},
+		{Name: "openai", Translator: openAI},
+	})
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	result, err := chain.Translate(ctx, translator.Request{MessageID: "Hello", Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, []string{"openrouter"}, provenance.Providers("Hello"))
+	assert.Empty(t, provenance.Providers("Goodbye"))
+
+	openAI.AssertNotCalled(t, "Translate", mock.Anything, mock.Anything)
+}
+
+func TestChain_Translate_Errors(t *testing.T) {
+	badRequest := &translator.APIError{Provider: "Anthropic", Kind: translator.ErrorKindBadRequest, Message: "invalid model"}
+
+	first := new(mocks.Translator)
+	first.On("Translate", mock.Anything, mock.Anything).Return("", badRequest).Once()
+
+	second := new(mocks.Translator)
+
+	chain := translator.NewChain([]translator.ChainLink{
+		{Name: "first", Translator: first},
+		{Name: "second", Translator: second},
+	})
+
+	// Errors that are not retryable are not hidden by the next provider
+	_, err := chain.Translate(context.Background(), translator.Request{Text: "Hello"})
+	assert.Equal(t, badRequest, err)
+	second.AssertNotCalled(t, "Translate", mock.Anything, mock.Anything)
+
+	// Unsupported languages fall through like retryable errors
+	first.On("Translate", mock.Anything, mock.Anything).Return("", &translator.UnsupportedLanguageError{Provider: "DeepL", Lang: "tlh"}).Once()
+	second.On("Translate", mock.Anything, mock.Anything).Return("", errOverloaded).Once()
+
+	_, err = chain.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "tlh"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all providers of the chain failed")
+	assert.True(t, errors.Is(err, errOverloaded))
+}
+
+func TestChain_TranslateBatch(t *testing.T) {
+	first := new(mocks.BatchTranslator)
+	first.On("TranslateBatch", mock.Anything, mock.Anything).Return(nil, errOverloaded)
+
+	second := new(mocks.BatchTranslator)
+	second.On("TranslateBatch", mock.Anything, mock.Anything).Return([]string{"Un", ""}, nil)
+
+	chain := translator.NewChain([]translator.ChainLink{
+		{Name: "first", Translator: first},
+		{Name: "second", Translator: second},
+	})
+
+	batch, ok := chain.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	translations, err := batch.TranslateBatch(ctx, []translator.Request{{MessageID: "One", Text: "One"}, {MessageID: "Two", Text: "Two"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Un", ""}, translations)
+	assert.Equal(t, []string{"second"}, provenance.Providers("One"))
+	assert.Empty(t, provenance.Providers("Two"))
+
+	// A chain starting with a provider without batches translates the requests one by one
+	_, ok = translator.NewChain([]translator.ChainLink{{Name: "single", Translator: new(mocks.Translator)}}).(translator.BatchTranslator)
+	assert.False(t, ok)
+}
+
+func TestChain_TranslateBatch_SingleFallback(t *testing.T) {
+	first := new(mocks.BatchTranslator)
+	first.On("TranslateBatch", mock.Anything, mock.Anything).Return(nil, errOverloaded)
+
+	chain := translator.NewChain([]translator.ChainLink{
+		{Name: "first", Translator: first},
+		{Name: "single", Translator: new(mocks.Translator)},
+	})
+
+	_, err := chain.(translator.BatchTranslator).TranslateBatch(context.Background(), []translator.Request{{Text: "One"}})
+	assert.ErrorContains(t, err, "provider single of the chain does not translate batches")
+}
+
+func TestDefaultFactory_CreateTranslator_Chain(t *testing.T) {
+	factory := translator.NewFactory()
+
+	anthropic := new(mocks.Translator)
+	anthropic.On("Translate", mock.Anything, mock.Anything).Return("", errOverloaded)
+
+	openAI := new(mocks.Translator)
+	openAI.On("Translate", mock.Anything, mock.Anything).Return("Bonjour", nil)
+
+	for name, trans := range map[string]translator.Translator{"anthropic": anthropic, "openai": openAI} {
+		provider := new(mocks.Provider)
+		provider.On("GetName").Return(name)
+		provider.On("CreateTranslator", mock.MatchedBy(func(config map[string]interface{}) bool {
+			return config["provider"] == name
+		})).Return(trans, nil)
+
+		require.NoError(t, factory.RegisterProvider(provider))
+	}
+
+	trans, err := factory.CreateTranslator("fallback", map[string]interface{}{
+		"providers": []interface{}{
+			map[string]interface{}{"provider": "anthropic", "model": "claude-3-haiku"},
+			map[string]interface{}{"provider": "openai"},
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	result, err := trans.Translate(ctx, translator.Request{MessageID: "Hello", Text: "Hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, []string{"openai"}, provenance.Providers("Hello"))
+
+	_, err = factory.CreateTranslator("chain", map[string]interface{}{})
+	assert.ErrorContains(t, err, "requires a list of providers")
+
+	_, err = factory.CreateTranslator("chain", map[string]interface{}{
+		"providers": []map[string]interface{}{{"provider": "anthropic"}, {"provider": "unknown"}},
+	})
+	assert.ErrorContains(t, err, "provider unknown not registered")
+
+	_, err = factory.CreateTranslator("chain", map[string]interface{}{
+		"providers": []map[string]interface{}{{"model": "gpt-4o"}},
+	})
+	assert.ErrorContains(t, err, "provider of chain entry 1 is required")
+}
+
+func TestDefaultFactory_CreateTranslator_ChainRateLimits(t *testing.T) {
+	factory := translator.NewFactory()
+
+	anthropic := new(mocks.Translator)
+	anthropic.On("Translate", mock.Anything, mock.Anything).Return("Bonjour", nil).Once()
+
+	provider := new(mocks.Provider)
+	provider.On("GetName").Return("anthropic")
+	provider.On("CreateTranslator", mock.Anything).Return(anthropic, nil)
+	require.NoError(t, factory.RegisterProvider(provider))
+
+	trans, err := factory.CreateTranslator("chain", map[string]interface{}{
+		"providers": []map[string]interface{}{{"provider": "anthropic", "requests_per_minute": 1}},
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello"})
+	require.NoError(t, err)
+
+	// The budget of the provider is spent
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = trans.Translate(ctx, translator.Request{Text: "Hello"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	anthropic.AssertExpectations(t)
+
+	_, err = factory.CreateTranslator("chain", map[string]interface{}{
+		"providers": []map[string]interface{}{{"provider": "anthropic", "tokens_per_minute": "lots"}},
+	})
+	assert.ErrorContains(t, err, "invalid tokens_per_minute lots")
+}
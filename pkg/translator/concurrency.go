@@ -0,0 +1,117 @@
+package translator
+
+import (
+	"context"
+)
+
+// ConcurrencySlots limit the number of calls running at the same time across all translators and
+// completers sharing them
+type ConcurrencySlots struct {
+	sem chan struct{}
+}
+
+// NewConcurrencySlots creates limit slots, at least one
+func NewConcurrencySlots(limit int) *ConcurrencySlots {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &ConcurrencySlots{sem: make(chan struct{}, limit)}
+}
+
+// concurrencyLimitedTranslator limits the number of concurrent calls to the wrapped translator
+type concurrencyLimitedTranslator struct {
+	*ConcurrencySlots
+	translator Translator
+}
+
+// batchConcurrencyLimitedTranslator limits the number of concurrent calls to the wrapped batch translator
+type batchConcurrencyLimitedTranslator struct {
+	*concurrencyLimitedTranslator
+	batch BatchTranslator
+}
+
+// concurrencyLimitedCompleter limits the number of concurrent calls to the wrapped completer
+type concurrencyLimitedCompleter struct {
+	*ConcurrencySlots
+	completer Completer
+}
+
+// NewConcurrencyLimit wraps the translator so that at most limit calls run at the same time, no matter
+// how many workers share it. The returned translator implements BatchTranslator if t does.
+func NewConcurrencyLimit(t Translator, limit int) Translator {
+	return NewSharedConcurrencyLimit(t, NewConcurrencySlots(limit))
+}
+
+// NewSharedConcurrencyLimit wraps the translator so that its calls take one of the slots, which may be
+// shared with other translators and completers. The returned translator implements BatchTranslator if
+// t does.
+func NewSharedConcurrencyLimit(t Translator, slots *ConcurrencySlots) Translator {
+	limited := &concurrencyLimitedTranslator{
+		ConcurrencySlots: slots,
+		translator:       t,
+	}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchConcurrencyLimitedTranslator{
+			concurrencyLimitedTranslator: limited,
+			batch:                        bt,
+		}
+	}
+
+	return limited
+}
+
+// NewCompleterConcurrencyLimit wraps the completer of a judge so that its calls take one of the slots,
+// which may be shared with translators
+func NewCompleterConcurrencyLimit(c Completer, slots *ConcurrencySlots) Completer {
+	return &concurrencyLimitedCompleter{
+		ConcurrencySlots: slots,
+		completer:        c,
+	}
+}
+
+// Translate translates the request once a slot is available
+func (t *concurrencyLimitedTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	if err := t.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer t.release()
+
+	return t.translator.Translate(ctx, req)
+}
+
+// TranslateBatch translates the requests once a slot is available
+func (t *batchConcurrencyLimitedTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	if err := t.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer t.release()
+
+	return t.batch.TranslateBatch(ctx, reqs)
+}
+
+// Complete sends the prompts once a slot is available
+func (c *concurrencyLimitedCompleter) Complete(ctx context.Context, system, user string) (string, error) {
+	if err := c.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.release()
+
+	return c.completer.Complete(ctx, system, user)
+}
+
+// acquire waits for a free slot or for the context to be done
+func (s *ConcurrencySlots) acquire(ctx context.Context) error {
+	select {
+	case s.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot
+func (s *ConcurrencySlots) release() {
+	<-s.sem
+}
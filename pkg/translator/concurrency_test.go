@@ -0,0 +1,167 @@
+package translator_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewConcurrencyLimit(t *testing.T) {
+	var running, maxRunning atomic.Int32
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, req translator.Request) (string, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+
+			return req.Text, nil
+		})
+
+	limited := translator.NewConcurrencyLimit(mockTranslator, 2)
+
+	_, isBatch := limited.(translator.BatchTranslator)
+	assert.False(t, isBatch)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			translation, err := limited.Translate(context.Background(), translator.Request{Text: "Hello"})
+			assert.NoError(t, err)
+			assert.Equal(t, "Hello", translation)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 10)
+}
+
+func TestNewConcurrencyLimit_Batch(t *testing.T) {
+	mockTranslator := new(mocks.BatchTranslator)
+	mockTranslator.On("TranslateBatch", mock.Anything, mock.Anything).
+		Return([]string{"Привет"}, nil)
+
+	limited := translator.NewConcurrencyLimit(mockTranslator, 1)
+
+	batch, ok := limited.(translator.BatchTranslator)
+	assert.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{{Text: "Hello"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Привет"}, translations)
+
+	// Cancelled context while waiting for a slot
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	release := make(chan struct{})
+
+	mockTranslator = new(mocks.BatchTranslator)
+	mockTranslator.On("TranslateBatch", mock.Anything, mock.Anything).
+		Return(func(context.Context, []translator.Request) ([]string, error) {
+			close(done)
+			<-release
+
+			return nil, nil
+		})
+
+	blocked := translator.NewConcurrencyLimit(mockTranslator, 1).(translator.BatchTranslator)
+
+	go func() {
+		_, _ = blocked.TranslateBatch(context.Background(), nil)
+	}()
+
+	<-done
+
+	_, err = blocked.TranslateBatch(ctx, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+}
+
+func TestNewSharedConcurrencyLimit(t *testing.T) {
+	var running, maxRunning atomic.Int32
+
+	track := func() {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, mock.Anything).
+		Return(func(_ context.Context, req translator.Request) (string, error) {
+			track()
+			return req.Text, nil
+		})
+
+	mockJudge := new(mocks.Completer)
+	mockJudge.On("Complete", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(context.Context, string, string) (string, error) {
+			track()
+			return "{}", nil
+		})
+
+	slots := translator.NewConcurrencySlots(2)
+	limited := translator.NewSharedConcurrencyLimit(mockTranslator, slots)
+	judge := translator.NewCompleterConcurrencyLimit(mockJudge, slots)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := limited.Translate(context.Background(), translator.Request{Text: "Hello"})
+			assert.NoError(t, err)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, err := judge.Complete(context.Background(), "system", "user")
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	// Translations and evaluations take the same slots
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 10)
+	mockJudge.AssertNumberOfCalls(t, "Complete", 10)
+}
@@ -0,0 +1,402 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	deepLBaseURL     = "https://api.deepl.com"
+	deepLFreeBaseURL = "https://api-free.deepl.com"
+	// deepLMaxTexts is the maximum number of texts of a single DeepL request
+	deepLMaxTexts = 50
+	// deepLKeepTag is the XML tag wrapping placeholders, so DeepL leaves them as is
+	deepLKeepTag = "x"
+)
+
+var (
+	// deepLLanguages are the base languages DeepL translates from and to
+	deepLLanguages = map[string]bool{
+		"ar": true, "bg": true, "cs": true, "da": true, "de": true, "el": true, "en": true, "es": true,
+		"et": true, "fi": true, "fr": true, "he": true, "hu": true, "id": true, "it": true, "ja": true,
+		"ko": true, "lt": true, "lv": true, "nb": true, "nl": true, "pl": true, "pt": true, "ro": true,
+		"ru": true, "sk": true, "sl": true, "sv": true, "th": true, "tr": true, "uk": true, "vi": true,
+		"zh": true,
+	}
+
+	// deepLFormalities are the accepted values of the formality option
+	deepLFormalities = map[string]bool{
+		"default": true, "more": true, "less": true, "prefer_more": true, "prefer_less": true,
+	}
+
+	// mtPlaceholderRe matches placeholders like {Count}, printf verbs like %[1]d and markup tokens,
+	// which machine translation engines must keep as is
+	mtPlaceholderRe = regexp.MustCompile(`\{[A-Za-z_][A-Za-z0-9_]*\}|%(?:\[\d+\])?[+\-#0]*\d*(?:\.\d+)?[a-zA-Z]|⟦\d+⟧`)
+
+	deepLKeepXMLRe = regexp.MustCompile(`<` + deepLKeepTag + `>(.*?)</` + deepLKeepTag + `>`)
+	// mtKeepHTMLRe matches the placeholders marked as not translatable in HTML texts
+	mtKeepHTMLRe = regexp.MustCompile(`<span translate="no">(.*?)</span>`)
+)
+
+// DeepLProvider provides translation using the DeepL API
+type DeepLProvider struct{}
+
+// GetName returns the name of the provider
+func (p *DeepLProvider) GetName() string {
+	return "deepl"
+}
+
+// CreateTranslator creates a translator instance. Keys of the free API, ending with ":fx", use
+// the free API endpoint unless base_url is set.
+func (p *DeepLProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	apiKey, ok := config["api_key"].(string)
+	if !ok || apiKey == "" {
+		return nil, fmt.Errorf("DeepL API key is required")
+	}
+
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		baseURL = deepLBaseURL
+		if strings.HasSuffix(apiKey, ":fx") {
+			baseURL = deepLFreeBaseURL
+		}
+	}
+
+	formality, _ := config["formality"].(string)
+	if formality != "" && !deepLFormalities[formality] {
+		return nil, fmt.Errorf("invalid DeepL formality %q, use default, more, less, prefer_more or prefer_less", formality)
+	}
+
+	tagHandling, _ := config["tag_handling"].(string)
+	if tagHandling != "" && tagHandling != "xml" && tagHandling != "html" {
+		return nil, fmt.Errorf("invalid DeepL tag_handling %q, use xml or html", tagHandling)
+	}
+
+	glossaryID, _ := config["glossary_id"].(string)
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeepLTranslator{
+		apiKey:      apiKey,
+		url:         strings.TrimRight(baseURL, "/") + "/v2/translate",
+		formality:   formality,
+		glossaryID:  glossaryID,
+		tagHandling: tagHandling,
+		retry:       retry,
+	}, nil
+}
+
+// DeepLTranslator implements the Translator interface using the DeepL API
+type DeepLTranslator struct {
+	apiKey      string
+	url         string
+	formality   string
+	glossaryID  string
+	tagHandling string
+	retry       RetryPolicy
+}
+
+// DeepLRequest represents a request to the translate endpoint of the DeepL API
+type DeepLRequest struct {
+	Text        []string `json:"text"`
+	SourceLang  string   `json:"source_lang,omitempty"`
+	TargetLang  string   `json:"target_lang"`
+	Context     string   `json:"context,omitempty"`
+	Formality   string   `json:"formality,omitempty"`
+	GlossaryID  string   `json:"glossary_id,omitempty"`
+	TagHandling string   `json:"tag_handling,omitempty"`
+	IgnoreTags  []string `json:"ignore_tags,omitempty"`
+}
+
+// DeepLResponse represents a response of the translate endpoint of the DeepL API
+type DeepLResponse struct {
+	Translations []struct {
+		DetectedSourceLanguage string `json:"detected_source_language"`
+		Text                   string `json:"text"`
+	} `json:"translations"`
+	Message string `json:"message,omitempty"`
+}
+
+// deepLTargetLang returns the DeepL code of the target language, DeepL requires the variant
+// of English, Portuguese and Chinese
+func deepLTargetLang(lang string) (string, error) {
+	tag := parseLangTag(lang)
+	if tag.base == "no" {
+		tag.base = "nb"
+	}
+
+	if !deepLLanguages[tag.base] {
+		return "", &UnsupportedLanguageError{Provider: "DeepL", Lang: lang}
+	}
+
+	switch tag.base {
+	case "en":
+		if tag.region == "gb" {
+			return "EN-GB", nil
+		}
+
+		return "EN-US", nil
+	case "pt":
+		if tag.region == "br" {
+			return "PT-BR", nil
+		}
+
+		return "PT-PT", nil
+	case "zh":
+		if tag.traditionalChinese() {
+			return "ZH-HANT", nil
+		}
+
+		return "ZH-HANS", nil
+	case "es":
+		if tag.region == "419" {
+			return "ES-419", nil
+		}
+	}
+
+	return strings.ToUpper(tag.base), nil
+}
+
+// deepLSourceLang returns the DeepL code of the source language, DeepL detects the language if it is empty
+func deepLSourceLang(lang string) (string, error) {
+	if lang == "" {
+		return "", nil
+	}
+
+	tag := parseLangTag(lang)
+	if tag.base == "no" {
+		tag.base = "nb"
+	}
+
+	if !deepLLanguages[tag.base] {
+		return "", &UnsupportedLanguageError{Provider: "DeepL", Lang: lang, Source: true}
+	}
+
+	return strings.ToUpper(tag.base), nil
+}
+
+// Translate translates the text of the request to its target language. The comment of the message
+// is given to DeepL as context.
+func (t *DeepLTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	translations, err := t.translate(ctx, req.SourceLang, req.TargetLang, req.Comment, []string{req.Text})
+	if err != nil {
+		return "", err
+	}
+
+	return translations[0], nil
+}
+
+// TranslateBatch translates the requests with as few calls as possible, grouping the texts
+// by their languages
+func (t *DeepLTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateGrouped(reqs, deepLMaxTexts, func(sourceLang, targetLang string, texts []string) ([]string, error) {
+		return t.translate(ctx, sourceLang, targetLang, "", texts)
+	})
+}
+
+// translate translates the texts in a single call, the comment is given to DeepL as context
+func (t *DeepLTranslator) translate(ctx context.Context, sourceLang, targetLang, comment string, texts []string) ([]string, error) {
+	target, err := deepLTargetLang(targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := deepLSourceLang(sourceLang)
+	if err != nil {
+		return nil, err
+	}
+
+	requestBody := DeepLRequest{
+		SourceLang: source,
+		TargetLang: target,
+		Context:    comment,
+		Formality:  t.formality,
+		GlossaryID: t.glossaryID,
+	}
+
+	var escaped bool
+
+	requestBody.Text, requestBody.TagHandling, escaped = t.protect(texts)
+	if requestBody.TagHandling == "xml" {
+		requestBody.IgnoreTags = []string{deepLKeepTag}
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var translations []string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translations, err = t.send(ctx, jsonData)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(translations) != len(texts) {
+		return nil, fmt.Errorf("DeepL returned %d translations for %d texts", len(translations), len(texts))
+	}
+
+	for i := range translations {
+		translations[i] = t.unprotect(translations[i], requestBody.TagHandling, escaped)
+	}
+
+	return translations, nil
+}
+
+// protect marks the placeholders of the texts as not translatable with the tag handling of DeepL.
+// Plain texts with placeholders are escaped and sent as XML. It returns the texts, the tag handling
+// and whether the texts were escaped.
+func (t *DeepLTranslator) protect(texts []string) ([]string, string, bool) {
+	tagHandling := t.tagHandling
+
+	hasPlaceholders := false
+	for _, text := range texts {
+		if mtPlaceholderRe.MatchString(text) {
+			hasPlaceholders = true
+			break
+		}
+	}
+
+	if !hasPlaceholders {
+		return texts, tagHandling, false
+	}
+
+	escaped := tagHandling == ""
+	if escaped {
+		tagHandling = "xml"
+	}
+
+	protected := make([]string, len(texts))
+
+	for i, text := range texts {
+		if escaped {
+			text = html.EscapeString(text)
+		}
+
+		protected[i] = mtPlaceholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+			if tagHandling == "html" {
+				return `<span translate="no">` + placeholder + `</span>`
+			}
+
+			return "<" + deepLKeepTag + ">" + placeholder + "</" + deepLKeepTag + ">"
+		})
+	}
+
+	return protected, tagHandling, escaped
+}
+
+// unprotect removes the tags added by protect from the translation
+func (t *DeepLTranslator) unprotect(translation, tagHandling string, escaped bool) string {
+	if tagHandling == "html" {
+		translation = mtKeepHTMLRe.ReplaceAllString(translation, "$1")
+	} else {
+		translation = deepLKeepXMLRe.ReplaceAllString(translation, "$1")
+	}
+
+	if escaped {
+		translation = html.UnescapeString(translation)
+	}
+
+	return translation
+}
+
+// send sends a single request to the DeepL API and returns the translations of the response
+func (t *DeepLTranslator) send(ctx context.Context, jsonData []byte) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "DeepL-Auth-Key "+t.apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, newRequestError(ctx, "DeepL", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newRequestError(ctx, "DeepL", err)
+	}
+
+	var response DeepLResponse
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// 456 means the character quota of the account is used up
+		return nil, newStatusError("DeepL", resp.StatusCode, resp.Header, response.Message)
+	}
+
+	translations := make([]string, len(response.Translations))
+	for i, translation := range response.Translations {
+		translations[i] = translation.Text
+	}
+
+	return translations, nil
+}
+
+// translateGrouped translates the requests in groups of up to size texts with the same languages.
+// The first failing group fails the batch.
+func translateGrouped(reqs []Request, size int, translate func(sourceLang, targetLang string, texts []string) ([]string, error)) ([]string, error) {
+	type langPair struct {
+		source, target string
+	}
+
+	groups := make(map[langPair][]int)
+
+	var order []langPair
+
+	for i, req := range reqs {
+		pair := langPair{source: req.SourceLang, target: req.TargetLang}
+		if _, ok := groups[pair]; !ok {
+			order = append(order, pair)
+		}
+
+		groups[pair] = append(groups[pair], i)
+	}
+
+	translations := make([]string, len(reqs))
+
+	for _, pair := range order {
+		indexes := groups[pair]
+
+		for start := 0; start < len(indexes); start += size {
+			chunk := indexes[start:min(start+size, len(indexes))]
+
+			texts := make([]string, len(chunk))
+			for j, i := range chunk {
+				texts[j] = reqs[i].Text
+			}
+
+			translated, err := translate(pair.source, pair.target, texts)
+			if err != nil {
+				return nil, err
+			}
+
+			for j, i := range chunk {
+				translations[i] = translated[j]
+			}
+		}
+	}
+
+	return translations, nil
+}
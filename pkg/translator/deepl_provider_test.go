@@ -0,0 +1,211 @@
+package translator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deepLServer starts a stand-in DeepL server that translates the texts with translate
+func deepLServer(t *testing.T, translate func(text string) string) *providerServer {
+	t.Helper()
+
+	return newProviderServerFunc(t, func(r recordedRequest) (int, string) {
+		assert.Equal(t, "/v2/translate", r.URL.Path)
+		assert.Equal(t, "DeepL-Auth-Key deepl-key", r.Header.Get("Authorization"))
+
+		var req translator.DeepLRequest
+		assert.NoError(t, json.Unmarshal(r.Body, &req))
+
+		var resp translator.DeepLResponse
+		for _, text := range req.Text {
+			resp.Translations = append(resp.Translations, struct {
+				DetectedSourceLanguage string `json:"detected_source_language"`
+				Text                   string `json:"text"`
+			}{DetectedSourceLanguage: "EN", Text: translate(text)})
+		}
+
+		data, err := json.Marshal(resp)
+		assert.NoError(t, err)
+
+		return http.StatusOK, string(data)
+	})
+}
+
+func newDeepLTranslator(t *testing.T, baseURL string, options map[string]interface{}) translator.Translator {
+	t.Helper()
+
+	config := map[string]interface{}{"api_key": "deepl-key", "base_url": baseURL}
+	for k, v := range options {
+		config[k] = v
+	}
+
+	trans, err := (&translator.DeepLProvider{}).CreateTranslator(config)
+	require.NoError(t, err)
+
+	return trans
+}
+
+func TestDeepLProvider_GetName(t *testing.T) {
+	provider := &translator.DeepLProvider{}
+	assert.Equal(t, "deepl", provider.GetName())
+}
+
+func TestDeepLProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.DeepLProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{"api_key": "key:fx"})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{})
+	assert.ErrorContains(t, err, "API key is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"api_key": "key", "formality": "polite"})
+	assert.ErrorContains(t, err, "invalid DeepL formality")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"api_key": "key", "tag_handling": "markdown"})
+	assert.ErrorContains(t, err, "invalid DeepL tag_handling")
+}
+
+func TestDeepLTranslator_Translate(t *testing.T) {
+	srv := deepLServer(t, func(string) string { return "Hallo" })
+
+	trans := newDeepLTranslator(t, srv.URL, map[string]interface{}{
+		"formality":   "prefer_less",
+		"glossary_id": "gloss-1",
+	})
+
+	result, err := trans.Translate(context.Background(), translator.Request{
+		Text:       "Hello",
+		SourceLang: "en-GB",
+		TargetLang: "de-DE",
+		Comment:    "Greeting on the home screen",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Hallo", result)
+
+	requests := decodeRequests[translator.DeepLRequest](t, srv.Requests())
+	require.Len(t, requests, 1)
+	assert.Equal(t, translator.DeepLRequest{
+		Text:       []string{"Hello"},
+		SourceLang: "EN",
+		TargetLang: "DE",
+		Context:    "Greeting on the home screen",
+		Formality:  "prefer_less",
+		GlossaryID: "gloss-1",
+	}, requests[0])
+}
+
+func TestDeepLTranslator_LanguageCodes(t *testing.T) {
+	srv := deepLServer(t, func(text string) string { return text })
+
+	trans := newDeepLTranslator(t, srv.URL, nil)
+
+	tests := map[string]string{
+		"en-GB":      "EN-GB",
+		"en_US":      "EN-US",
+		"en":         "EN-US",
+		"pt-BR":      "PT-BR",
+		"pt":         "PT-PT",
+		"zh-CN":      "ZH-HANS",
+		"zh-TW":      "ZH-HANT",
+		"zh-Hant-HK": "ZH-HANT",
+		"es-419":     "ES-419",
+		"ru-RU":      "RU",
+		"no":         "NB",
+	}
+
+	for tag, code := range tests {
+		_, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: tag})
+		require.NoError(t, err, tag)
+		requests := decodeRequests[translator.DeepLRequest](t, srv.Requests())
+		assert.Equal(t, code, requests[len(requests)-1].TargetLang, tag)
+		assert.Empty(t, requests[len(requests)-1].SourceLang, tag)
+	}
+
+	_, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "ka-GE"})
+
+	var langErr *translator.UnsupportedLanguageError
+	require.True(t, errors.As(err, &langErr))
+	assert.Equal(t, `DeepL does not support the target language "ka-GE"`, err.Error())
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", SourceLang: "hy", TargetLang: "de"})
+	assert.EqualError(t, err, `DeepL does not support the source language "hy"`)
+
+	assert.Len(t, srv.Requests(), len(tests))
+}
+
+func TestDeepLTranslator_Placeholders(t *testing.T) {
+	// The stand-in server translates the text outside of the ignored tags
+	srv := deepLServer(t, func(text string) string {
+		text = strings.ReplaceAll(text, "You have", "Sie haben")
+		return strings.ReplaceAll(text, "messages &amp; alerts", "Nachrichten &amp; Warnungen")
+	})
+
+	trans := newDeepLTranslator(t, srv.URL, nil)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "You have {Count} messages & alerts", TargetLang: "de-DE"})
+	require.NoError(t, err)
+	assert.Equal(t, "Sie haben {Count} Nachrichten & Warnungen", result)
+
+	requests := decodeRequests[translator.DeepLRequest](t, srv.Requests())
+	require.Len(t, requests, 1)
+
+	req := requests[0]
+	assert.Equal(t, []string{"You have <x>{Count}</x> messages &amp; alerts"}, req.Text)
+	assert.Equal(t, "xml", req.TagHandling)
+	assert.Equal(t, []string{"x"}, req.IgnoreTags)
+
+	// HTML tag handling keeps the markup of the text and marks the placeholders as not translatable
+	trans = newDeepLTranslator(t, srv.URL, map[string]interface{}{"tag_handling": "html"})
+
+	result, err = trans.Translate(context.Background(), translator.Request{Text: "You have <b>%d</b> messages", TargetLang: "de-DE"})
+	require.NoError(t, err)
+	assert.Equal(t, "Sie haben <b>%d</b> messages", result)
+
+	requests = decodeRequests[translator.DeepLRequest](t, srv.Requests())
+	require.Len(t, requests, 2)
+
+	req = requests[1]
+	assert.Equal(t, []string{`You have <b><span translate="no">%d</span></b> messages`}, req.Text)
+	assert.Equal(t, "html", req.TagHandling)
+	assert.Empty(t, req.IgnoreTags)
+}
+
+func TestDeepLTranslator_TranslateBatch(t *testing.T) {
+	srv := deepLServer(t, func(text string) string { return "[" + text + "]" })
+
+	batch, ok := newDeepLTranslator(t, srv.URL, nil).(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{
+		{Text: "One", SourceLang: "en", TargetLang: "fr-FR"},
+		{Text: "Two", SourceLang: "en", TargetLang: "de-DE"},
+		{Text: "Three", SourceLang: "en", TargetLang: "fr-FR"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"[One]", "[Two]", "[Three]"}, translations)
+
+	requests := decodeRequests[translator.DeepLRequest](t, srv.Requests())
+	require.Len(t, requests, 2)
+	assert.Equal(t, []string{"One", "Three"}, requests[0].Text)
+	assert.Equal(t, "FR", requests[0].TargetLang)
+	assert.Equal(t, []string{"Two"}, requests[1].Text)
+}
+
+func TestDeepLTranslator_QuotaExceeded(t *testing.T) {
+	srv := newProviderServer(t, 456, `{"message": "Quota Exceeded"}`)
+
+	_, err := newDeepLTranslator(t, srv.URL, nil).Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "de"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Quota Exceeded")
+	assert.False(t, translator.IsRetryable(err))
+}
@@ -0,0 +1,327 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// EnsembleProviderName is the name of the composite provider selecting the best of the translations
+// of several providers
+const EnsembleProviderName = "ensemble"
+
+const judgeSystemPrompt = "You are a senior reviewer of software translations. You compare candidate translations of the same text and choose the most accurate and fluent one that keeps all placeholders, tokens and formatting unchanged. Respond with JSON only."
+
+// Candidate is the translation of a request by one of the providers of an ensemble
+type Candidate struct {
+	Provider    string  `json:"provider"`
+	Translation string  `json:"translation,omitempty"`
+	Error       string  `json:"error,omitempty"`
+	Agreement   float64 `json:"agreement"`
+}
+
+// EnsembleResult describes how the translation of a request was selected from the candidates
+type EnsembleResult struct {
+	MessageID      string      `json:"id"`
+	PluralCategory string      `json:"pluralCategory,omitempty"`
+	Text           string      `json:"text,omitempty"`
+	Selected       string      `json:"selected"`
+	Reason         string      `json:"reason,omitempty"`
+	Candidates     []Candidate `json:"candidates"`
+}
+
+// Validator checks a translation of the request, returning why it must be rejected
+type Validator func(req Request, translation string) error
+
+// validatorKey is the context key of the Validator of the candidates of ensembles
+type validatorKey struct{}
+
+// WithValidator returns a context whose ensembles drop the candidates rejected by validate before
+// selecting the best translation
+func WithValidator(ctx context.Context, validate Validator) context.Context {
+	return context.WithValue(ctx, validatorKey{}, validate)
+}
+
+// ensembleTranslator translates every request with all links and selects the best translation
+type ensembleTranslator struct {
+	links []ChainLink
+	judge Completer
+	// match selects the message IDs translated by the ensemble, other messages are translated by the first link
+	match *regexp.Regexp
+}
+
+// NewEnsemble creates a translator requesting the translation of every request from all links in
+// parallel. The best translation is chosen by the judge, if one is given, or else by agreement:
+// the candidate most similar to the other candidates wins, the earlier link on a tie. The candidates
+// are recorded in the Provenance of the context for reviewers. If match is not nil, only the requests
+// whose message ID matches it are translated by the ensemble, the others by the first link.
+func NewEnsemble(links []ChainLink, judge Completer, match *regexp.Regexp) Translator {
+	return &ensembleTranslator{links: links, judge: judge, match: match}
+}
+
+// Translate translates the request with all links and returns the best translation
+func (t *ensembleTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	if t.match != nil && !t.match.MatchString(req.MessageID) {
+		translation, err := t.links[0].Translator.Translate(ctx, req)
+		if err == nil {
+			recordProvenance(ctx, req, t.links[0].Name)
+		}
+
+		return translation, err
+	}
+
+	candidates := make([]Candidate, len(t.links))
+	errs := make([]error, len(t.links))
+
+	var wg sync.WaitGroup
+
+	for i, link := range t.links {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			candidates[i].Provider = link.Name
+			candidates[i].Translation, errs[i] = link.Translator.Translate(ctx, req)
+
+			if errs[i] != nil {
+				candidates[i].Error = errs[i].Error()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	var succeeded []int
+
+	for i, err := range errs {
+		if err == nil {
+			succeeded = append(succeeded, i)
+		}
+	}
+
+	if len(succeeded) == 0 {
+		return "", fmt.Errorf("all providers of the ensemble failed: %w", errs[0])
+	}
+
+	valid := validCandidates(ctx, req, candidates, succeeded)
+
+	scoreAgreement(candidates, valid)
+
+	best, reason := t.selectCandidate(ctx, req, candidates, valid)
+	if len(valid) == 1 && len(succeeded) > 1 {
+		reason = "the only candidate passing validation"
+	}
+
+	recordProvenance(ctx, req, candidates[best].Provider)
+	recordEnsemble(ctx, EnsembleResult{
+		MessageID:      req.MessageID,
+		PluralCategory: req.PluralCategory,
+		Text:           req.Text,
+		Selected:       candidates[best].Provider,
+		Reason:         reason,
+		Candidates:     candidates,
+	})
+
+	return candidates[best].Translation, nil
+}
+
+// validCandidates returns the successful candidates accepted by the Validator of the context, so that
+// broken translations are neither scored nor shown to the judge. The rejected candidates keep their
+// translation for reviewers, with the reason in their error. If every candidate is rejected, all of
+// them are returned and the caller rejects the selected translation.
+func validCandidates(ctx context.Context, req Request, candidates []Candidate, succeeded []int) []int {
+	validate, ok := ctx.Value(validatorKey{}).(Validator)
+	if !ok {
+		return succeeded
+	}
+
+	var valid []int
+
+	for _, i := range succeeded {
+		if err := validate(req, candidates[i].Translation); err != nil {
+			candidates[i].Error = "rejected: " + err.Error()
+			continue
+		}
+
+		valid = append(valid, i)
+	}
+
+	if len(valid) == 0 {
+		return succeeded
+	}
+
+	return valid
+}
+
+// scoreAgreement sets the agreement of every successful candidate to its mean similarity to the
+// other successful candidates
+func scoreAgreement(candidates []Candidate, succeeded []int) {
+	if len(succeeded) == 1 {
+		candidates[succeeded[0]].Agreement = 1
+		return
+	}
+
+	for _, i := range succeeded {
+		var sum float64
+
+		for _, j := range succeeded {
+			if i != j {
+				sum += similarity(candidates[i].Translation, candidates[j].Translation)
+			}
+		}
+
+		candidates[i].Agreement = sum / float64(len(succeeded)-1)
+	}
+}
+
+// selectCandidate returns the index of the best candidate and the reason of the choice
+func (t *ensembleTranslator) selectCandidate(ctx context.Context, req Request, candidates []Candidate, succeeded []int) (int, string) {
+	best := succeeded[0]
+
+	for _, i := range succeeded[1:] {
+		if candidates[i].Agreement > candidates[best].Agreement {
+			best = i
+		}
+	}
+
+	distinct := make(map[string]bool)
+	for _, i := range succeeded {
+		distinct[candidates[i].Translation] = true
+	}
+
+	if len(distinct) == 1 {
+		return best, "all providers agree"
+	}
+
+	if t.judge == nil {
+		return best, "highest agreement with the other candidates"
+	}
+
+	choice, reason, err := t.askJudge(ctx, req, candidates, succeeded)
+	if err != nil {
+		slog.Warn("judge failed, selecting the translation by agreement",
+			slog.String("id", req.MessageID),
+			slog.String("error", err.Error()))
+
+		return best, "highest agreement with the other candidates, the judge failed"
+	}
+
+	return choice, reason
+}
+
+// askJudge asks the judge model to choose the best of the successful candidates
+func (t *ensembleTranslator) askJudge(ctx context.Context, req Request, candidates []Candidate, succeeded []int) (int, string, error) {
+	var sb strings.Builder
+
+	if req.SourceLang != "" {
+		fmt.Fprintf(&sb, "The following text was translated from %s to %s.\n", req.SourceLang, req.TargetLang)
+	} else {
+		fmt.Fprintf(&sb, "The following text was translated to %s.\n", req.TargetLang)
+	}
+
+	if req.Comment != "" {
+		fmt.Fprintf(&sb, "\nNote from the developers: %s\n", req.Comment)
+	}
+
+	if len(req.Placeholders) > 0 {
+		sb.WriteString("\nPlaceholders that must be kept exactly once and unchanged:")
+
+		for _, ph := range req.Placeholders {
+			fmt.Fprintf(&sb, " {%s}", ph.ID)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	if len(req.Glossary) > 0 {
+		sb.WriteString("\nGlossary terms that must be translated as given:\n")
+
+		for _, term := range req.Glossary {
+			fmt.Fprintf(&sb, "- %q: %q\n", term.Source, term.Target)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\nText:\n%s\n\nCandidate translations:\n", req.Text)
+
+	for n, i := range succeeded {
+		fmt.Fprintf(&sb, "%d. %s\n", n+1, candidates[i].Translation)
+	}
+
+	sb.WriteString("\nChoose the best candidate. Respond with a JSON object like {\"best\": 1, \"reason\": \"short explanation\"}.")
+
+	content, err := t.judge.Complete(ctx, judgeSystemPrompt, sb.String())
+	if err != nil {
+		return 0, "", err
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+
+	if start < 0 || end < start {
+		return 0, "", fmt.Errorf("no JSON object in judge response")
+	}
+
+	var verdict struct {
+		Best   int    `json:"best"`
+		Reason string `json:"reason"`
+	}
+
+	if err := json.Unmarshal([]byte(content[start:end+1]), &verdict); err != nil {
+		return 0, "", fmt.Errorf("failed to parse judge response: %w", err)
+	}
+
+	if verdict.Best < 1 || verdict.Best > len(succeeded) {
+		return 0, "", fmt.Errorf("judge chose unknown candidate %d", verdict.Best)
+	}
+
+	return succeeded[verdict.Best-1], verdict.Reason, nil
+}
+
+// newEnsemble creates an ensemble from the "providers" list of the config, the optional "judge"
+// provider config and the optional "match" regular expression of message IDs
+func newEnsemble(config map[string]interface{}, create func(providerName string, config map[string]interface{}) (Translator, error)) (Translator, error) {
+	links, err := newChainLinks(config, create)
+	if err != nil {
+		return nil, err
+	}
+
+	var judge Completer
+
+	if judgeConfig, ok := config["judge"].(map[string]interface{}); ok {
+		name, _ := judgeConfig["provider"].(string)
+		if name == "" {
+			return nil, fmt.Errorf("provider of the judge is required")
+		}
+
+		trans, err := create(name, judgeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create judge: %w", err)
+		}
+
+		if judge, ok = trans.(Completer); !ok {
+			return nil, fmt.Errorf("provider %s can not be a judge", name)
+		}
+
+		limits, err := newRateLimits(judgeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create judge: %w", err)
+		}
+
+		judge = NewCompleterRateLimit(judge, limits)
+	}
+
+	var match *regexp.Regexp
+
+	if expr, _ := config["match"].(string); expr != "" {
+		if match, err = regexp.Compile(expr); err != nil {
+			return nil, fmt.Errorf("invalid ensemble match: %w", err)
+		}
+	}
+
+	return NewEnsemble(links, judge, match), nil
+}
@@ -0,0 +1,220 @@
+package translator_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// ensembleLinks creates links named after the translations their mocks return
+func ensembleLinks(translations map[string]string) []translator.ChainLink {
+	var links []translator.ChainLink
+
+	for _, name := range []string{"anthropic", "openai", "gemini"} {
+		translation, ok := translations[name]
+		if !ok {
+			continue
+		}
+
+		trans := new(mocks.Translator)
+		if translation == "" {
+			trans.On("Translate", mock.Anything, mock.Anything).Return("", errOverloaded)
+		} else {
+			trans.On("Translate", mock.Anything, mock.Anything).Return(translation, nil)
+		}
+
+		links = append(links, translator.ChainLink{Name: name, Translator: trans})
+	}
+
+	return links
+}
+
+func TestEnsemble_Agreement(t *testing.T) {
+	ensemble := translator.NewEnsemble(ensembleLinks(map[string]string{
+		"anthropic": "Conditions générales d'utilisation",
+		"openai":    "Conditions générales",
+		"gemini":    "Conditions générales d'utilisation.",
+	}), nil, nil)
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	result, err := ensemble.Translate(ctx, translator.Request{MessageID: "Terms", Text: "Terms and Conditions", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Conditions générales d'utilisation", result)
+	assert.Equal(t, []string{"anthropic"}, provenance.Providers("Terms"))
+
+	results := provenance.Ensembles()
+	require.Len(t, results, 1)
+	assert.Equal(t, "Terms", results[0].MessageID)
+	assert.Equal(t, "anthropic", results[0].Selected)
+	assert.Equal(t, "highest agreement with the other candidates", results[0].Reason)
+	require.Len(t, results[0].Candidates, 3)
+	assert.Equal(t, "openai", results[0].Candidates[1].Provider)
+	assert.Greater(t, results[0].Candidates[0].Agreement, results[0].Candidates[1].Agreement)
+}
+
+func TestEnsemble_FailedProvider(t *testing.T) {
+	ensemble := translator.NewEnsemble(ensembleLinks(map[string]string{
+		"anthropic": "",
+		"openai":    "Conditions générales",
+	}), nil, nil)
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	result, err := ensemble.Translate(ctx, translator.Request{MessageID: "Terms", Text: "Terms and Conditions"})
+	require.NoError(t, err)
+	assert.Equal(t, "Conditions générales", result)
+
+	candidates := provenance.Ensembles()[0].Candidates
+	assert.Contains(t, candidates[0].Error, "Overloaded")
+	assert.Equal(t, 1.0, candidates[1].Agreement)
+
+	// The ensemble fails only if every provider fails
+	ensemble = translator.NewEnsemble(ensembleLinks(map[string]string{"anthropic": "", "openai": ""}), nil, nil)
+
+	_, err = ensemble.Translate(context.Background(), translator.Request{Text: "Terms and Conditions"})
+	assert.ErrorContains(t, err, "all providers of the ensemble failed")
+	assert.True(t, errors.Is(err, errOverloaded))
+}
+
+func TestEnsemble_Judge(t *testing.T) {
+	links := ensembleLinks(map[string]string{
+		"anthropic": "Conditions générales",
+		"openai":    "Termes et conditions",
+	})
+
+	judge := mocks.NewCompleter(t)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return assert.Contains(t, prompt, "1. Conditions générales\n2. Termes et conditions\n") &&
+			assert.Contains(t, prompt, "from en to fr-FR")
+	})).Return(`{"best": 2, "reason": "literal legal term"}`, nil).Once()
+
+	ensemble := translator.NewEnsemble(links, judge, nil)
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	req := translator.Request{MessageID: "Terms", Text: "Terms and Conditions", SourceLang: "en", TargetLang: "fr-FR"}
+
+	result, err := ensemble.Translate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Termes et conditions", result)
+	assert.Equal(t, "literal legal term", provenance.Ensembles()[0].Reason)
+
+	// An invalid verdict falls back to agreement, the first candidate wins the tie
+	judge.On("Complete", mock.Anything, mock.Anything, mock.Anything).Return(`{"best": 7}`, nil).Once()
+
+	result, err = ensemble.Translate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Conditions générales", result)
+	// The second selection of the same text replaces the first one
+	results := provenance.Ensembles()
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Reason, "the judge failed")
+}
+
+func TestEnsemble_Validator(t *testing.T) {
+	links := ensembleLinks(map[string]string{
+		"anthropic": "Vous avez %[1]s messages",
+		"openai":    "Vous avez %[1]s messages",
+		"gemini":    "Vous avez %[1]d messages",
+	})
+
+	validate := func(req translator.Request, translation string) error {
+		if !strings.Contains(translation, "%[1]d") {
+			return errors.New("missing placeholder %[1]d")
+		}
+
+		return nil
+	}
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+	ctx = translator.WithValidator(ctx, validate)
+
+	req := translator.Request{MessageID: "Messages", Text: "You have %[1]d messages", TargetLang: "fr-FR"}
+
+	// The broken candidates agree with each other, but are not scored
+	result, err := translator.NewEnsemble(links, nil, nil).Translate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Vous avez %[1]d messages", result)
+
+	results := provenance.Ensembles()
+	require.Len(t, results, 1)
+	assert.Equal(t, "gemini", results[0].Selected)
+	assert.Equal(t, "the only candidate passing validation", results[0].Reason)
+	assert.Equal(t, "rejected: missing placeholder %[1]d", results[0].Candidates[0].Error)
+	assert.Equal(t, "Vous avez %[1]s messages", results[0].Candidates[0].Translation)
+	assert.Zero(t, results[0].Candidates[0].Agreement)
+
+	// The judge is shown only the valid candidates
+	links = ensembleLinks(map[string]string{
+		"anthropic": "Vous avez %[1]s messages",
+		"openai":    "Vous avez %[1]d messages",
+		"gemini":    "Vous avez %[1]d nouveaux messages",
+	})
+
+	judge := mocks.NewCompleter(t)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return assert.Contains(t, prompt, "1. Vous avez %[1]d messages\n2. Vous avez %[1]d nouveaux messages\n") &&
+			assert.NotContains(t, prompt, "%[1]s")
+	})).Return(`{"best": 2, "reason": "closer to the source"}`, nil).Once()
+
+	result, err = translator.NewEnsemble(links, judge, nil).Translate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Vous avez %[1]d nouveaux messages", result)
+
+	// If every candidate is rejected, the caller gets one of them to reject
+	links = ensembleLinks(map[string]string{"anthropic": "Vous avez %[1]s messages"})
+
+	result, err = translator.NewEnsemble(links, nil, nil).Translate(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Vous avez %[1]s messages", result)
+}
+
+func TestEnsemble_Match(t *testing.T) {
+	links := ensembleLinks(map[string]string{"anthropic": "Bonjour", "openai": "Salut"})
+
+	ensemble := translator.NewEnsemble(links, nil, regexp.MustCompile(`^Terms`))
+
+	ctx, provenance := translator.WithProvenance(context.Background())
+
+	result, err := ensemble.Translate(ctx, translator.Request{MessageID: "Hello", Text: "Hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, []string{"anthropic"}, provenance.Providers("Hello"))
+	assert.Empty(t, provenance.Ensembles())
+
+	links[1].Translator.(*mocks.Translator).AssertNotCalled(t, "Translate", mock.Anything, mock.Anything)
+}
+
+func TestDefaultFactory_CreateTranslator_Ensemble(t *testing.T) {
+	factory := translator.NewFactory()
+
+	provider := new(mocks.Provider)
+	provider.On("GetName").Return("openai")
+	provider.On("CreateTranslator", mock.Anything).Return(new(mocks.Translator), nil)
+	require.NoError(t, factory.RegisterProvider(provider))
+
+	providers := []map[string]interface{}{{"provider": "openai", "model": "gpt-4o"}, {"provider": "openai", "model": "gpt-4o-mini"}}
+
+	trans, err := factory.CreateTranslator("ensemble", map[string]interface{}{"providers": providers, "match": "^Terms"})
+	require.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = factory.CreateTranslator("ensemble", map[string]interface{}{"providers": providers, "match": "("})
+	assert.ErrorContains(t, err, "invalid ensemble match")
+
+	// Judges must complete raw prompts
+	_, err = factory.CreateTranslator("ensemble", map[string]interface{}{
+		"providers": providers,
+		"judge":     map[string]interface{}{"provider": "openai"},
+	})
+	assert.ErrorContains(t, err, "provider openai can not be a judge")
+}
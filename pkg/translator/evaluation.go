@@ -0,0 +1,117 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const evaluationSystemPrompt = "You are a senior reviewer of software translations. You score translations on a fixed rubric and respond with JSON only."
+
+const evaluationRubric = `Score the translation on every criterion from 1 (unusable) to 5 (perfect):
+- "accuracy": the meaning of the text is preserved, nothing is added or omitted
+- "fluency": the translation is natural and grammatical in the target language and fits a user interface
+- "placeholders": placeholders, tokens, markup and formatting are kept exactly once and unchanged
+- "terminology": glossary terms are translated as given and product terms are translated consistently
+
+Respond with a single JSON object like {"accuracy": 5, "fluency": 4, "placeholders": 5, "terminology": 5, "explanation": "short explanation of the lowest scores"}.`
+
+// Evaluation is the score of a translation on the rubric of the Evaluator, every criterion from
+// 1 (unusable) to 5 (perfect)
+type Evaluation struct {
+	Accuracy     int    `json:"accuracy"`
+	Fluency      int    `json:"fluency"`
+	Placeholders int    `json:"placeholders"`
+	Terminology  int    `json:"terminology"`
+	Explanation  string `json:"explanation"`
+}
+
+// Score returns the lowest score of the criteria, a translation is as good as its weakest aspect
+func (e Evaluation) Score() int {
+	return min(e.Accuracy, e.Fluency, e.Placeholders, e.Terminology)
+}
+
+// Evaluator scores translations with a judge model
+type Evaluator struct {
+	judge Completer
+}
+
+// NewEvaluator creates an evaluator asking the judge to score translations
+func NewEvaluator(judge Completer) *Evaluator {
+	return &Evaluator{judge: judge}
+}
+
+// Evaluate scores the translation of the request text for accuracy, fluency, placeholder fidelity
+// and terminology
+func (e *Evaluator) Evaluate(ctx context.Context, req Request, translation string) (Evaluation, error) {
+	content, err := e.judge.Complete(ctx, evaluationSystemPrompt, buildEvaluationPrompt(req, translation))
+	if err != nil {
+		return Evaluation{}, err
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+
+	if start < 0 || end < start {
+		return Evaluation{}, fmt.Errorf("no JSON object in evaluation response")
+	}
+
+	var eval Evaluation
+	if err := json.Unmarshal([]byte(content[start:end+1]), &eval); err != nil {
+		return Evaluation{}, fmt.Errorf("failed to parse evaluation response: %w", err)
+	}
+
+	for _, score := range []int{eval.Accuracy, eval.Fluency, eval.Placeholders, eval.Terminology} {
+		if score < 1 || score > 5 {
+			return Evaluation{}, fmt.Errorf("invalid evaluation score %d", score)
+		}
+	}
+
+	return eval, nil
+}
+
+// buildEvaluationPrompt renders the request and its translation into a prompt for the judge
+func buildEvaluationPrompt(req Request, translation string) string {
+	var sb strings.Builder
+
+	if req.SourceLang != "" {
+		fmt.Fprintf(&sb, "Review the following translation from %s to %s.\n", req.SourceLang, req.TargetLang)
+	} else {
+		fmt.Fprintf(&sb, "Review the following translation to %s.\n", req.TargetLang)
+	}
+
+	if req.Comment != "" {
+		fmt.Fprintf(&sb, "\nNote from the developers: %s\n", req.Comment)
+	}
+
+	if len(req.Placeholders) > 0 {
+		sb.WriteString("\nPlaceholders of the text:")
+
+		for _, ph := range req.Placeholders {
+			fmt.Fprintf(&sb, " {%s}", ph.ID)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	if len(req.Glossary) > 0 {
+		sb.WriteString("\nGlossary terms:\n")
+
+		for _, term := range req.Glossary {
+			if term.DoNotTranslate() {
+				fmt.Fprintf(&sb, "- %q must not be translated\n", term.Source)
+			} else {
+				fmt.Fprintf(&sb, "- %q must be translated as %q\n", term.Source, term.Target)
+			}
+		}
+	}
+
+	if strings.HasPrefix(req.Text, `{"`) {
+		sb.WriteString("\nThe text is a JSON description of a plural or select message, compare the translation of every case.\n")
+	}
+
+	fmt.Fprintf(&sb, "\nText:\n%s\n\nTranslation:\n%s\n\n%s", req.Text, translation, evaluationRubric)
+
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package translator_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	judge := mocks.NewCompleter(t)
+	judge.On("Complete", mock.Anything, mock.Anything, mock.MatchedBy(func(prompt string) bool {
+		return assert.Contains(t, prompt, "from en-US to de-DE") &&
+			assert.Contains(t, prompt, "Placeholders of the text: {Count}") &&
+			assert.Contains(t, prompt, `"Workspace" must be translated as "Arbeitsbereich"`) &&
+			assert.Contains(t, prompt, "Text:\n{Count} workspaces\n\nTranslation:\n{Count} Workspaces")
+	})).Return("Scores:\n```json\n"+`{"accuracy": 5, "fluency": 4, "placeholders": 5, "terminology": 2, "explanation": "Workspace is not translated as Arbeitsbereich"}`+"\n```", nil)
+
+	evaluator := translator.NewEvaluator(judge)
+
+	eval, err := evaluator.Evaluate(context.Background(), translator.Request{
+		Text:         "{Count} workspaces",
+		SourceLang:   "en-US",
+		TargetLang:   "de-DE",
+		Placeholders: []translator.Placeholder{{ID: "Count"}},
+		Glossary:     []translator.Term{{Source: "Workspace", Target: "Arbeitsbereich"}},
+	}, "{Count} Workspaces")
+	require.NoError(t, err)
+
+	assert.Equal(t, translator.Evaluation{
+		Accuracy:     5,
+		Fluency:      4,
+		Placeholders: 5,
+		Terminology:  2,
+		Explanation:  "Workspace is not translated as Arbeitsbereich",
+	}, eval)
+	assert.Equal(t, 2, eval.Score())
+}
+
+func TestEvaluator_InvalidResponse(t *testing.T) {
+	tests := map[string]string{
+		"no JSON object":           "The translation is fine",
+		"failed to parse":          `{"accuracy": "high"}`,
+		"invalid evaluation score": `{"accuracy": 5, "fluency": 5, "placeholders": 0, "terminology": 5}`,
+	}
+
+	for want, response := range tests {
+		judge := new(mocks.Completer)
+		judge.On("Complete", mock.Anything, mock.Anything, mock.Anything).Return(response, nil)
+
+		_, err := translator.NewEvaluator(judge).Evaluate(context.Background(), translator.Request{Text: "Hello"}, "Hallo")
+		assert.ErrorContains(t, err, want)
+	}
+}
@@ -0,0 +1,445 @@
+package translator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// execDefaultTimeout is the time a command has to answer a request unless configured otherwise
+const execDefaultTimeout = time.Minute
+
+// ExecProvider provides translation using an external command. The command is started once and
+// receives translation requests as JSON lines on its standard input, answering every request with
+// a JSON line on its standard output:
+//
+//	→ {"id": 1, "text": "Hello", "source_lang": "en-US", "target_lang": "fr-FR", ...}
+//	← {"id": 1, "translation": "Bonjour"}
+//	← {"id": 1, "error": "unsupported language", "retryable": false}
+//
+// Requests are sent one at a time, the next request is sent only after the response to the previous
+// one. The command must exit when its standard input is closed. Its standard error is passed through.
+// A command that exits, or does not read or answer a request in time, is killed and started again for
+// the next request.
+type ExecProvider struct{}
+
+// GetName returns the name of the provider
+func (p *ExecProvider) GetName() string {
+	return "exec"
+}
+
+// CreateTranslator creates a translator instance. The command is a list of the program and its
+// arguments, or a string split into them at spaces like a shell does, with quotes keeping spaces.
+func (p *ExecProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	var command []string
+
+	switch c := config["command"].(type) {
+	case string:
+		var err error
+		if command, err = splitCommand(c); err != nil {
+			return nil, err
+		}
+	case []string:
+		command = c
+	case []interface{}:
+		for _, arg := range c {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid exec provider command argument %v", arg)
+			}
+
+			command = append(command, s)
+		}
+	}
+
+	if len(command) == 0 {
+		return nil, fmt.Errorf("exec provider command is required")
+	}
+
+	timeout := execDefaultTimeout
+
+	if v, ok := config["timeout"]; ok {
+		d, err := toDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid timeout %v", v)
+		}
+
+		timeout = d
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// The model and the API key are passed to the command, so it can be configured like other providers
+	env := os.Environ()
+
+	if model, ok := config["model"].(string); ok && model != "" {
+		env = append(env, "GOTEXT_TRANSLATOR_MODEL="+model)
+	}
+
+	if apiKey, ok := config["api_key"].(string); ok && apiKey != "" {
+		env = append(env, "GOTEXT_TRANSLATOR_API_KEY="+apiKey)
+	}
+
+	return &ExecTranslator{
+		command: command,
+		env:     env,
+		timeout: timeout,
+		retry:   retry,
+	}, nil
+}
+
+// splitCommand splits the command line into the program and its arguments at spaces. Single quotes
+// keep the text between them as is, double quotes keep spaces and backslash escapes, like in a shell.
+func splitCommand(line string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in exec provider command %q", line)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
+
+// ExecTranslator implements the Translator interface using an external command
+type ExecTranslator struct {
+	command []string
+	env     []string
+	timeout time.Duration
+	retry   RetryPolicy
+
+	// mu serializes the requests, since the command answers one request at a time
+	mu     sync.Mutex
+	proc   *execProcess
+	lastID int64
+}
+
+// ExecRequest is a translation request sent to the command
+type ExecRequest struct {
+	ID             int64                `json:"id"`
+	Text           string               `json:"text"`
+	SourceLang     string               `json:"source_lang,omitempty"`
+	TargetLang     string               `json:"target_lang"`
+	MessageID      string               `json:"message_id,omitempty"`
+	Comment        string               `json:"comment,omitempty"`
+	Placeholders   []ExecPlaceholder    `json:"placeholders,omitempty"`
+	PluralCategory string               `json:"plural_category,omitempty"`
+	Context        []ExecContextMessage `json:"context,omitempty"`
+	References     []ExecReference      `json:"references,omitempty"`
+	Glossary       []ExecTerm           `json:"glossary,omitempty"`
+}
+
+// ExecPlaceholder describes a placeholder of the text sent to the command
+type ExecPlaceholder struct {
+	ID     string `json:"id"`
+	String string `json:"string,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Expr   string `json:"expr,omitempty"`
+}
+
+// ExecContextMessage is a surrounding message sent to the command as context
+type ExecContextMessage struct {
+	Text        string `json:"text"`
+	Translation string `json:"translation,omitempty"`
+}
+
+// ExecReference is a similar text with its approved translation sent to the command
+type ExecReference struct {
+	Source      string  `json:"source"`
+	Translation string  `json:"translation"`
+	Score       float64 `json:"score"`
+}
+
+// ExecTerm is a glossary term sent to the command
+type ExecTerm struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Note   string `json:"note,omitempty"`
+}
+
+// ExecResponse is the answer of the command to a request
+type ExecResponse struct {
+	ID          int64  `json:"id"`
+	Translation string `json:"translation,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Retryable   bool   `json:"retryable,omitempty"`
+}
+
+// execProcess is a running command
+type execProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	// lines receives the lines of the standard output, it is closed when the output ends
+	lines chan []byte
+	// stopped is set when the command is killed on purpose
+	stopped atomic.Bool
+}
+
+// newExecRequest converts the request to the protocol of the command
+func newExecRequest(id int64, req Request) ExecRequest {
+	er := ExecRequest{
+		ID:             id,
+		Text:           req.Text,
+		SourceLang:     req.SourceLang,
+		TargetLang:     req.TargetLang,
+		MessageID:      req.MessageID,
+		Comment:        req.Comment,
+		PluralCategory: req.PluralCategory,
+	}
+
+	for _, p := range req.Placeholders {
+		er.Placeholders = append(er.Placeholders, ExecPlaceholder{ID: p.ID, String: p.String, Type: p.Type, Expr: p.Expr})
+	}
+
+	for _, m := range req.Neighbours {
+		er.Context = append(er.Context, ExecContextMessage{Text: m.Text, Translation: m.Translation})
+	}
+
+	for _, r := range req.References {
+		er.References = append(er.References, ExecReference{Source: r.Source, Translation: r.Translation, Score: r.Score})
+	}
+
+	for _, term := range req.Glossary {
+		er.Glossary = append(er.Glossary, ExecTerm{Source: term.Source, Target: term.Target, Note: term.Note})
+	}
+
+	return er
+}
+
+// Translate translates the text of the request to its target language
+func (t *ExecTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	var translation string
+
+	err := t.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+
+		translation, err = t.send(ctx, req)
+
+		return err
+	})
+
+	return translation, err
+}
+
+// send sends a single request to the command and waits for its response, starting the command if
+// it is not running
+func (t *ExecTranslator) send(ctx context.Context, req Request) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.proc == nil {
+		proc, err := t.start()
+		if err != nil {
+			return "", err
+		}
+
+		t.proc = proc
+	}
+
+	t.lastID++
+
+	data, err := json.Marshal(newExecRequest(t.lastID, req))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+
+	// A command that stops reading its input blocks the write, so it is bound by the timeout as well.
+	// Stopping the command closes its input, which ends the write.
+	written := make(chan error, 1)
+
+	go func(stdin io.Writer) {
+		_, err := stdin.Write(append(data, '\n'))
+		written <- err
+	}(t.proc.stdin)
+
+	select {
+	case err := <-written:
+		if err != nil {
+			t.stop()
+			return "", &APIError{Provider: "exec", Kind: ErrorKindServer, Message: "failed to send request to the command", Err: err}
+		}
+	case <-timer.C:
+		t.stop()
+		return "", &APIError{Provider: "exec", Kind: ErrorKindNetwork, Message: fmt.Sprintf("command did not read the request in %s", t.timeout)}
+	case <-ctx.Done():
+		t.stop()
+		return "", ctx.Err()
+	}
+
+	select {
+	case line, ok := <-t.proc.lines:
+		if !ok {
+			t.stop()
+			return "", &APIError{Provider: "exec", Kind: ErrorKindServer, Message: "command exited without a response"}
+		}
+
+		return t.parseResponse(line)
+	case <-timer.C:
+		// A late response would be taken for the answer to the next request, so the command is restarted
+		t.stop()
+		return "", &APIError{Provider: "exec", Kind: ErrorKindNetwork, Message: fmt.Sprintf("command did not respond in %s", t.timeout)}
+	case <-ctx.Done():
+		t.stop()
+		return "", ctx.Err()
+	}
+}
+
+// parseResponse returns the translation of the response to the last request
+func (t *ExecTranslator) parseResponse(line []byte) (string, error) {
+	var resp ExecResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.stop()
+		return "", fmt.Errorf("invalid response of the command %q: %w", line, err)
+	}
+
+	if resp.ID != t.lastID {
+		t.stop()
+		return "", &APIError{Provider: "exec", Kind: ErrorKindServer, Message: fmt.Sprintf("response id %d does not match request id %d", resp.ID, t.lastID)}
+	}
+
+	if resp.Error != "" {
+		kind := ErrorKindBadRequest
+		if resp.Retryable {
+			kind = ErrorKindServer
+		}
+
+		return "", &APIError{Provider: "exec", Kind: kind, Message: resp.Error}
+	}
+
+	if resp.Translation == "" {
+		return "", fmt.Errorf("no translation returned from the command")
+	}
+
+	return resp.Translation, nil
+}
+
+// start starts the command and the reader of its output
+func (t *ExecTranslator) start() (*execProcess, error) {
+	cmd := exec.Command(t.command[0], t.command[1:]...)
+	cmd.Env = t.env
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create command input: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create command output: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command %s: %w", t.command[0], err)
+	}
+
+	slog.Debug("started translation command", slog.String("command", t.command[0]), slog.Int("pid", cmd.Process.Pid))
+
+	proc := &execProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan []byte),
+	}
+
+	go func() {
+		defer close(proc.lines)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			if len(strings.TrimSpace(string(line))) == 0 {
+				continue
+			}
+
+			proc.lines <- line
+		}
+
+		if err := cmd.Wait(); err != nil && !proc.stopped.Load() {
+			slog.Warn("translation command exited", slog.String("command", t.command[0]), slog.String("error", err.Error()))
+		}
+	}()
+
+	return proc, nil
+}
+
+// stop kills the running command, the next request starts it again
+func (t *ExecTranslator) stop() {
+	if t.proc == nil {
+		return
+	}
+
+	proc := t.proc
+	t.proc = nil
+
+	proc.stopped.Store(true)
+
+	_ = proc.stdin.Close()
+	_ = proc.cmd.Process.Kill()
+
+	// Unblock the reader of the output, so it can wait for the command
+	go func() {
+		for range proc.lines {
+		}
+	}()
+}
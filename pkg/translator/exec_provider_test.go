@@ -0,0 +1,217 @@
+package translator_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// execHelperEnv selects the behaviour of the test binary started as the translation command
+const execHelperEnv = "GOTEXT_EXEC_HELPER"
+
+// TestExecHelperProcess is not a test, it is the translation command started by the exec provider tests
+func TestExecHelperProcess(_ *testing.T) {
+	mode := os.Getenv(execHelperEnv)
+	if mode == "" {
+		return
+	}
+
+	if mode == "deaf" {
+		// Never reads the requests, so writes block once the pipe is full
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+
+	for scanner.Scan() {
+		var req translator.ExecRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		resp := translator.ExecResponse{ID: req.ID}
+
+		switch mode {
+		case "upper":
+			resp.Translation = fmt.Sprintf("%s [%s %s %s]", strings.ToUpper(req.Text), req.TargetLang,
+				req.Placeholders[0].ID, os.Getenv("GOTEXT_TRANSLATOR_MODEL"))
+		case "crash-once":
+			// Crash on the first request, the marker file tells the restarted command to answer
+			marker := os.Getenv("GOTEXT_EXEC_MARKER")
+			if _, err := os.Stat(marker); err != nil {
+				_ = os.WriteFile(marker, nil, 0644)
+				os.Exit(1)
+			}
+
+			resp.Translation = strings.ToUpper(req.Text)
+		case "slow":
+			time.Sleep(time.Second)
+			resp.Translation = strings.ToUpper(req.Text)
+		case "error":
+			resp.Error = "unsupported language " + req.TargetLang
+		}
+
+		_ = encoder.Encode(resp)
+	}
+
+	os.Exit(0)
+}
+
+// newExecTranslator creates an exec translator running the test binary in the mode
+func newExecTranslator(t *testing.T, mode string, options map[string]interface{}) translator.Translator {
+	t.Helper()
+
+	t.Setenv(execHelperEnv, mode)
+
+	config := map[string]interface{}{
+		"command":          []string{os.Args[0], "-test.run=^TestExecHelperProcess$"},
+		"retry_base_delay": "1ms",
+	}
+
+	for k, v := range options {
+		config[k] = v
+	}
+
+	trans, err := (&translator.ExecProvider{}).CreateTranslator(config)
+	require.NoError(t, err)
+
+	return trans
+}
+
+func TestExecProvider_GetName(t *testing.T) {
+	provider := &translator.ExecProvider{}
+	assert.Equal(t, "exec", provider.GetName())
+}
+
+func TestExecProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.ExecProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{"command": "python3 translate.py --fast"})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"command": " "})
+	assert.ErrorContains(t, err, "command is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"command": `python3 "translate.py`})
+	assert.ErrorContains(t, err, "unterminated quote")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"command": []interface{}{"python3", 42}})
+	assert.ErrorContains(t, err, "invalid exec provider command argument 42")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"command": "translate", "timeout": "0s"})
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestExecTranslator_Translate(t *testing.T) {
+	trans := newExecTranslator(t, "upper", map[string]interface{}{"model": "house-mt"})
+
+	req := translator.Request{
+		Text:         "hello {Name}",
+		TargetLang:   "fr-FR",
+		Placeholders: []translator.Placeholder{{ID: "Name", String: "%[1]s"}},
+	}
+
+	// The same command answers all requests
+	for i := 0; i < 3; i++ {
+		result, err := trans.Translate(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO {NAME} [fr-FR Name house-mt]", result)
+	}
+}
+
+func TestExecTranslator_QuotedCommand(t *testing.T) {
+	t.Setenv(execHelperEnv, "upper")
+
+	// Scripts may live in directories with spaces in their names
+	dir := filepath.Join(t.TempDir(), "translation scripts")
+	require.NoError(t, os.Mkdir(dir, 0755))
+
+	program := filepath.Join(dir, "translate")
+	require.NoError(t, os.Symlink(os.Args[0], program))
+
+	for _, command := range []interface{}{
+		`"` + program + `" '-test.run=^TestExecHelperProcess$'`,
+		[]interface{}{program, "-test.run=^TestExecHelperProcess$"},
+	} {
+		trans, err := (&translator.ExecProvider{}).CreateTranslator(map[string]interface{}{"command": command})
+		require.NoError(t, err)
+
+		result, err := trans.Translate(context.Background(), translator.Request{
+			Text:         "hello",
+			TargetLang:   "fr-FR",
+			Placeholders: []translator.Placeholder{{ID: "Name"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO [fr-FR Name ]", result)
+	}
+}
+
+func TestExecTranslator_RestartOnCrash(t *testing.T) {
+	t.Setenv("GOTEXT_EXEC_MARKER", filepath.Join(t.TempDir(), "crashed"))
+
+	trans := newExecTranslator(t, "crash-once", nil)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", result)
+}
+
+func TestExecTranslator_Timeout(t *testing.T) {
+	trans := newExecTranslator(t, "slow", map[string]interface{}{"timeout": "50ms", "max_attempts": "2"})
+
+	start := time.Now()
+
+	_, err := trans.Translate(context.Background(), translator.Request{Text: "hello", TargetLang: "fr-FR"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not respond in 50ms")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestExecTranslator_NotReading(t *testing.T) {
+	trans := newExecTranslator(t, "deaf", map[string]interface{}{"timeout": "50ms", "max_attempts": "1"})
+
+	start := time.Now()
+
+	// The request is larger than the pipe buffer, so the write blocks
+	_, err := trans.Translate(context.Background(), translator.Request{Text: strings.Repeat("hello ", 100000), TargetLang: "fr-FR"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not read the request in 50ms")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestExecTranslator_Error(t *testing.T) {
+	trans := newExecTranslator(t, "error", nil)
+
+	_, err := trans.Translate(context.Background(), translator.Request{Text: "hello", TargetLang: "tlh"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported language tlh")
+
+	var apiErr *translator.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.False(t, apiErr.Retryable())
+}
+
+func TestExecTranslator_CommandNotFound(t *testing.T) {
+	trans, err := (&translator.ExecProvider{}).CreateTranslator(map[string]interface{}{
+		"command": filepath.Join(t.TempDir(), "missing-command"),
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "hello", TargetLang: "fr-FR"})
+	assert.ErrorContains(t, err, "failed to start command")
+}
@@ -44,8 +44,24 @@ func (f *DefaultFactory) RegisterProvider(provider Provider) error {
 	return nil
 }
 
-// CreateTranslator creates a translator for the specified provider
+// CreateTranslator creates a translator for the specified provider. The chain provider, or its alias
+// fallback, creates a translator trying the providers of the "providers" list of the config in order.
+// The ensemble provider creates a translator selecting the best translation of all of them.
+// Every provider of a chain or an ensemble, and the judge, keeps its own rate limits.
 func (f *DefaultFactory) CreateTranslator(providerName string, config map[string]interface{}) (Translator, error) {
+	if providerName == EnsembleProviderName {
+		return newEnsemble(config, f.CreateTranslator)
+	}
+
+	if providerName == ChainProviderName || providerName == FallbackProviderName {
+		links, err := newChainLinks(config, f.CreateTranslator)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewChain(links), nil
+	}
+
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 
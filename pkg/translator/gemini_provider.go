@@ -0,0 +1,320 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	geminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+	// geminiSafetyPrefix is the prefix of the provider options setting the threshold of a harm category
+	geminiSafetyPrefix = "safety_"
+)
+
+// geminiHarmCategories maps the names of the safety options to the harm categories of the Gemini API
+var geminiHarmCategories = map[string]string{
+	"harassment":        "HARM_CATEGORY_HARASSMENT",
+	"hate_speech":       "HARM_CATEGORY_HATE_SPEECH",
+	"sexually_explicit": "HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"dangerous_content": "HARM_CATEGORY_DANGEROUS_CONTENT",
+	"civic_integrity":   "HARM_CATEGORY_CIVIC_INTEGRITY",
+}
+
+// GeminiProvider provides translation using the Google Gemini API
+type GeminiProvider struct{}
+
+// GetName returns the name of the provider
+func (p *GeminiProvider) GetName() string {
+	return "gemini"
+}
+
+// CreateTranslator creates a translator instance
+func (p *GeminiProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	apiKey, ok := config["api_key"].(string)
+	if !ok || apiKey == "" {
+		return nil, fmt.Errorf("Gemini API key is required")
+	}
+
+	// Gemini retires its models too often for a default model to keep working
+	model, ok := config["model"].(string)
+	if !ok || model == "" {
+		return nil, fmt.Errorf("Gemini model is required")
+	}
+
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		baseURL = geminiBaseURL
+	}
+
+	generationConfig, err := newGeminiGenerationConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	safetySettings, err := newGeminiSafetySettings(config)
+	if err != nil {
+		return nil, err
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GeminiTranslator{
+		apiKey:           apiKey,
+		url:              strings.TrimRight(baseURL, "/") + "/models/" + model + ":generateContent",
+		generationConfig: generationConfig,
+		safetySettings:   safetySettings,
+		retry:            retry,
+	}, nil
+}
+
+// newGeminiGenerationConfig creates the generation config from the provider config. The temperature
+// defaults to a low value for consistent translations.
+func newGeminiGenerationConfig(config map[string]interface{}) (*GeminiGenerationConfig, error) {
+	temperature := 0.3
+	gc := &GeminiGenerationConfig{Temperature: &temperature}
+
+	floats := map[string]**float64{
+		"temperature": &gc.Temperature,
+		"top_p":       &gc.TopP,
+	}
+
+	for key, target := range floats {
+		if v, ok := config[key]; ok {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %v", key, v)
+			}
+
+			*target = &f
+		}
+	}
+
+	ints := map[string]*int{
+		"top_k":             &gc.TopK,
+		"max_output_tokens": &gc.MaxOutputTokens,
+	}
+
+	for key, target := range ints {
+		if v, ok := config[key]; ok {
+			n, err := toInt(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s %v", key, v)
+			}
+
+			*target = n
+		}
+	}
+
+	return gc, nil
+}
+
+// newGeminiSafetySettings creates the safety settings from the provider config. The safety_threshold
+// option applies to all harm categories, options like safety_harassment override it for one category.
+func newGeminiSafetySettings(config map[string]interface{}) ([]GeminiSafetySetting, error) {
+	thresholds := make(map[string]string)
+
+	if threshold, ok := config[geminiSafetyPrefix+"threshold"].(string); ok && threshold != "" {
+		for _, category := range geminiHarmCategories {
+			thresholds[category] = strings.ToUpper(threshold)
+		}
+	}
+
+	for key, v := range config {
+		name, ok := strings.CutPrefix(key, geminiSafetyPrefix)
+		if !ok || name == "threshold" {
+			continue
+		}
+
+		category, ok := geminiHarmCategories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown Gemini harm category %q", name)
+		}
+
+		threshold, ok := v.(string)
+		if !ok || threshold == "" {
+			return nil, fmt.Errorf("invalid %s %v", key, v)
+		}
+
+		thresholds[category] = strings.ToUpper(threshold)
+	}
+
+	settings := make([]GeminiSafetySetting, 0, len(thresholds))
+	for category, threshold := range thresholds {
+		settings = append(settings, GeminiSafetySetting{Category: category, Threshold: threshold})
+	}
+
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Category < settings[j].Category })
+
+	return settings, nil
+}
+
+// GeminiTranslator implements the Translator interface using the Gemini API
+type GeminiTranslator struct {
+	apiKey           string
+	url              string
+	generationConfig *GeminiGenerationConfig
+	safetySettings   []GeminiSafetySetting
+	retry            RetryPolicy
+}
+
+// GeminiRequest represents a generateContent request to the Gemini API
+type GeminiRequest struct {
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	Contents          []GeminiContent         `json:"contents"`
+	SafetySettings    []GeminiSafetySetting   `json:"safetySettings,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiContent represents the content of a message in the Gemini API
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart represents a part of a content in the Gemini API
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+// GeminiSafetySetting sets the blocking threshold of a harm category
+type GeminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// GeminiGenerationConfig represents the generation parameters of the Gemini API
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            int      `json:"topK,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+}
+
+// GeminiResponse represents a generateContent response of the Gemini API
+type GeminiResponse struct {
+	Candidates []struct {
+		Content      GeminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+// Translate translates the text of the request to its target language
+func (t *GeminiTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	return t.Complete(ctx, systemPrompt, buildUserPrompt(req))
+}
+
+// TranslateBatch translates all requests in a single call
+func (t *GeminiTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateBatch(ctx, t, reqs)
+}
+
+// Complete returns the model response for the system and user prompts
+func (t *GeminiTranslator) Complete(ctx context.Context, system, user string) (string, error) {
+	requestBody := GeminiRequest{
+		SystemInstruction: &GeminiContent{
+			Parts: []GeminiPart{{Text: system}},
+		},
+		Contents: []GeminiContent{
+			{
+				Role:  "user",
+				Parts: []GeminiPart{{Text: user}},
+			},
+		},
+		SafetySettings:   t.safetySettings,
+		GenerationConfig: t.generationConfig,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var translation string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translation, err = t.send(ctx, jsonData)
+		return err
+	})
+
+	return translation, err
+}
+
+// send sends a single request to the Gemini API and returns the text of the response
+func (t *GeminiTranslator) send(ctx context.Context, jsonData []byte) (string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", t.apiKey)
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", newRequestError(ctx, "Gemini", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", newRequestError(ctx, "Gemini", err)
+	}
+
+	var response GeminiResponse
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var message string
+		if response.Error != nil {
+			message = response.Error.Message
+		}
+
+		return "", newStatusError("Gemini", resp.StatusCode, resp.Header, message)
+	}
+
+	if response.PromptFeedback != nil && response.PromptFeedback.BlockReason != "" {
+		return "", fmt.Errorf("Gemini blocked the prompt: %s", response.PromptFeedback.BlockReason)
+	}
+
+	if len(response.Candidates) == 0 {
+		return "", fmt.Errorf("no translation candidates returned from Gemini")
+	}
+
+	candidate := response.Candidates[0]
+
+	// Texts cut by the token limit or the safety filters are not complete translations
+	if candidate.FinishReason != "" && candidate.FinishReason != "STOP" {
+		return "", fmt.Errorf("Gemini did not complete the response, finish reason %s", candidate.FinishReason)
+	}
+
+	var sb strings.Builder
+	for _, part := range candidate.Content.Parts {
+		sb.WriteString(part.Text)
+	}
+
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("empty or invalid response from Gemini API")
+	}
+
+	return sb.String(), nil
+}
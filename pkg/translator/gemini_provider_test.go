@@ -0,0 +1,130 @@
+package translator_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGeminiTranslator(t *testing.T, baseURL string, options map[string]interface{}) translator.Translator {
+	t.Helper()
+
+	config := map[string]interface{}{
+		"api_key":  "gemini-key",
+		"model":    "gemini-1.5-pro",
+		"base_url": baseURL + "/v1beta",
+	}
+
+	for k, v := range options {
+		config[k] = v
+	}
+
+	trans, err := (&translator.GeminiProvider{}).CreateTranslator(config)
+	require.NoError(t, err)
+
+	return trans
+}
+
+func TestGeminiProvider_GetName(t *testing.T) {
+	provider := &translator.GeminiProvider{}
+	assert.Equal(t, "gemini", provider.GetName())
+}
+
+func TestGeminiProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.GeminiProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{"api_key": "key", "model": "gemini-2.5-flash"})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"model": "gemini-1.5-pro"})
+	assert.ErrorContains(t, err, "API key is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"api_key": "key"})
+	assert.ErrorContains(t, err, "Gemini model is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"api_key": "key", "model": "gemini-2.5-flash", "safety_violence": "BLOCK_NONE"})
+	assert.ErrorContains(t, err, "unknown Gemini harm category")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"api_key": "key", "model": "gemini-2.5-flash", "max_output_tokens": "many"})
+	assert.ErrorContains(t, err, "invalid max_output_tokens")
+}
+
+func TestGeminiTranslator_Translate(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK,
+		`{"candidates": [{"content": {"role": "model", "parts": [{"text": "Bon"}, {"text": "jour"}]}, "finishReason": "STOP"}]}`)
+
+	trans := newGeminiTranslator(t, srv.URL, map[string]interface{}{
+		"temperature":       "0",
+		"top_k":             "20",
+		"max_output_tokens": "2048",
+		"safety_threshold":  "block_only_high",
+		"safety_harassment": "BLOCK_NONE",
+	})
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/v1beta/models/gemini-1.5-pro:generateContent", requests[0].URL.Path)
+	assert.Equal(t, "gemini-key", requests[0].Header.Get("x-goog-api-key"))
+
+	var req translator.GeminiRequest
+	requests[0].decode(t, &req)
+
+	require.NotNil(t, req.SystemInstruction)
+	assert.Contains(t, req.SystemInstruction.Parts[0].Text, "translator")
+	require.Len(t, req.Contents, 1)
+	assert.Equal(t, "user", req.Contents[0].Role)
+	assert.Contains(t, req.Contents[0].Parts[0].Text, "Hello")
+
+	require.NotNil(t, req.GenerationConfig)
+	require.NotNil(t, req.GenerationConfig.Temperature)
+	assert.Equal(t, 0.0, *req.GenerationConfig.Temperature)
+	assert.Nil(t, req.GenerationConfig.TopP)
+	assert.Equal(t, 20, req.GenerationConfig.TopK)
+	assert.Equal(t, 2048, req.GenerationConfig.MaxOutputTokens)
+
+	assert.Contains(t, req.SafetySettings, translator.GeminiSafetySetting{Category: "HARM_CATEGORY_HARASSMENT", Threshold: "BLOCK_NONE"})
+	assert.Contains(t, req.SafetySettings, translator.GeminiSafetySetting{Category: "HARM_CATEGORY_HATE_SPEECH", Threshold: "BLOCK_ONLY_HIGH"})
+	assert.Len(t, req.SafetySettings, 5)
+}
+
+func TestGeminiTranslator_Blocked(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK, `{"promptFeedback": {"blockReason": "SAFETY"}}`)
+
+	_, err := newGeminiTranslator(t, srv.URL, nil).Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	assert.ErrorContains(t, err, "blocked the prompt: SAFETY")
+
+	srv = newProviderServer(t, http.StatusOK, `{"candidates": [{"content": {"parts": []}, "finishReason": "SAFETY"}]}`)
+
+	_, err = newGeminiTranslator(t, srv.URL, nil).Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	assert.ErrorContains(t, err, "finish reason SAFETY")
+
+	// Partial texts are not accepted as translations
+	srv = newProviderServer(t, http.StatusOK, `{"candidates": [{"content": {"parts": [{"text": "Bonj"}]}, "finishReason": "MAX_TOKENS"}]}`)
+
+	_, err = newGeminiTranslator(t, srv.URL, nil).Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	assert.ErrorContains(t, err, "finish reason MAX_TOKENS")
+}
+
+func TestGeminiTranslator_Error(t *testing.T) {
+	srv := newProviderServer(t, http.StatusBadRequest,
+		`{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`)
+
+	_, err := newGeminiTranslator(t, srv.URL, nil).Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API key not valid")
+
+	var apiErr *translator.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Gemini", apiErr.Provider)
+	assert.Len(t, srv.Requests(), 1)
+}
@@ -0,0 +1,427 @@
+package translator
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Term is a glossary entry relevant to a translation
+type Term struct {
+	// Source is the term in the source language
+	Source string
+	// Target is the mandated translation of the term, equal to Source for terms that must not be translated
+	Target string
+	// Note explains the term, if the glossary has a description
+	Note string
+}
+
+// DoNotTranslate reports whether the term must be kept as is
+func (t Term) DoNotTranslate() bool {
+	return t.Source == t.Target
+}
+
+// glossaryEntry is a concept of the glossary with its terms in several languages
+type glossaryEntry struct {
+	// terms maps normalized language codes to the term in that language
+	terms          map[string]string
+	doNotTranslate bool
+	note           string
+}
+
+// Glossary is a termbase of product terms that must be translated consistently
+type Glossary struct {
+	entries []glossaryEntry
+}
+
+// LoadGlossary loads the glossary from a CSV or TBX file, the format is chosen by the file extension.
+//
+// The first row of a CSV glossary names the columns: "term" (or "source") holds the term in the source
+// language, "dnt" (or "do_not_translate") marks terms that must not be translated, "note" (or
+// "description") explains the term, and every other column holds the translations to the language
+// named in the header, e.g. "fr-FR".
+func LoadGlossary(path string) (*Glossary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open glossary: %w", err)
+	}
+	defer f.Close()
+
+	var g *Glossary
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		g, err = readGlossaryCSV(f)
+	case ".tbx", ".xml":
+		g, err = readGlossaryTBX(f)
+	default:
+		return nil, fmt.Errorf("unsupported glossary format %q, use .csv or .tbx", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to load glossary %s: %w", path, err)
+	}
+
+	return g, nil
+}
+
+// readGlossaryCSV parses a CSV glossary. Terms of the source column are stored under the empty
+// language, which matches any source language.
+func readGlossaryCSV(r io.Reader) (*Glossary, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return &Glossary{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+
+	termCol, dntCol, noteCol := -1, -1, -1
+	langs := make(map[int]string)
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+
+		switch strings.ToLower(name) {
+		case "term", "source":
+			termCol = i
+		case "dnt", "do_not_translate":
+			dntCol = i
+		case "note", "description":
+			noteCol = i
+		default:
+			if name != "" {
+				langs[i] = normalizeLang(name)
+			}
+		}
+	}
+
+	if termCol < 0 {
+		return nil, errors.New(`CSV glossary must have a "term" column`)
+	}
+
+	g := &Glossary{}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[i])
+		}
+
+		term := field(termCol)
+		if term == "" {
+			continue
+		}
+
+		entry := glossaryEntry{
+			terms: map[string]string{"": term},
+			note:  field(noteCol),
+		}
+
+		if dnt := field(dntCol); dnt != "" {
+			if entry.doNotTranslate, err = strconv.ParseBool(dnt); err != nil {
+				return nil, fmt.Errorf("invalid do not translate value %q of term %q", dnt, term)
+			}
+		}
+
+		for i, lang := range langs {
+			if target := field(i); target != "" {
+				entry.terms[lang] = target
+			}
+		}
+
+		g.entries = append(g.entries, entry)
+	}
+
+	return g, nil
+}
+
+// readGlossaryTBX parses a TBX glossary. Both TBX 2 (termEntry, langSet, tig) and TBX 3 (conceptEntry,
+// langSec, termSec) structures are supported, the first term of every language is used.
+func readGlossaryTBX(r io.Reader) (*Glossary, error) {
+	dec := xml.NewDecoder(r)
+	g := &Glossary{}
+
+	var (
+		entry *glossaryEntry
+		lang  string
+	)
+
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse TBX: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "termEntry", "conceptEntry":
+				entry = &glossaryEntry{terms: make(map[string]string)}
+			case "langSet", "langSec":
+				lang = ""
+
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "lang" {
+						lang = normalizeLang(attr.Value)
+					}
+				}
+			case "term":
+				var term string
+				if err := dec.DecodeElement(&term, &t); err != nil {
+					return nil, fmt.Errorf("failed to parse TBX: %w", err)
+				}
+
+				if entry != nil && lang != "" && entry.terms[lang] == "" {
+					entry.terms[lang] = strings.TrimSpace(term)
+				}
+			case "descrip":
+				var note string
+				if err := dec.DecodeElement(&note, &t); err != nil {
+					return nil, fmt.Errorf("failed to parse TBX: %w", err)
+				}
+
+				if entry != nil && entry.note == "" {
+					entry.note = strings.TrimSpace(note)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "termEntry", "conceptEntry":
+				if entry != nil && len(entry.terms) > 0 {
+					g.entries = append(g.entries, *entry)
+				}
+
+				entry = nil
+			case "langSet", "langSec":
+				lang = ""
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Len returns the number of entries of the glossary
+func (g *Glossary) Len() int {
+	return len(g.entries)
+}
+
+// Match returns the terms of the glossary that appear in the text, with their mandated translations
+// to the target language. Terms without a translation to the target language are skipped, unless they
+// must not be translated. Longer terms take precedence, so a term that only appears as a part of
+// another term, e.g. "Pet" in "Help My Pet Bot", is not returned.
+func (g *Glossary) Match(sourceLang, targetLang, text string) []Term {
+	type match struct {
+		entry  int
+		source string
+	}
+
+	var candidates []match
+
+	for i, entry := range g.entries {
+		if source, ok := entry.sourceTerm(sourceLang); ok && containsTerm(text, source) {
+			candidates = append(candidates, match{entry: i, source: source})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return utf8.RuneCountInString(candidates[i].source) > utf8.RuneCountInString(candidates[j].source)
+	})
+
+	// Blank out the matched terms, so the terms they contain don't match them again
+	rest := strings.ToLower(text)
+
+	var matched []match
+
+	for _, c := range candidates {
+		lower := strings.ToLower(c.source)
+		if !containsTerm(rest, lower) {
+			continue
+		}
+
+		rest = strings.ReplaceAll(rest, lower, strings.Repeat(" ", len(lower)))
+		matched = append(matched, c)
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].entry < matched[j].entry })
+
+	var terms []Term
+
+	for _, m := range matched {
+		entry := g.entries[m.entry]
+		term := Term{Source: m.source, Note: entry.note}
+
+		var ok bool
+		if entry.doNotTranslate {
+			term.Target = m.source
+		} else if term.Target, ok = entry.term(targetLang); !ok {
+			continue
+		}
+
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// MissingTerms returns the terms whose mandated translation does not appear in the translation
+func MissingTerms(translation string, terms []Term) []Term {
+	var missing []Term
+
+	for _, term := range terms {
+		if !containsTerm(translation, term.Target) {
+			missing = append(missing, term)
+		}
+	}
+
+	return missing
+}
+
+// sourceTerm returns the term of the entry in the source language. The terms of CSV source columns
+// match any language.
+func (e glossaryEntry) sourceTerm(lang string) (string, bool) {
+	if term, ok := e.term(lang); ok {
+		return term, true
+	}
+
+	term, ok := e.terms[""]
+
+	return term, ok
+}
+
+// term returns the term of the entry in the language. Terms of the same base language are used if
+// there is no exact match, e.g. a "fr" term for "fr-CA".
+func (e glossaryEntry) term(lang string) (string, bool) {
+	lang = normalizeLang(lang)
+
+	if term, ok := e.terms[lang]; ok {
+		return term, true
+	}
+
+	base, _, _ := strings.Cut(lang, "-")
+
+	for l, term := range e.terms {
+		if l == "" {
+			continue
+		}
+
+		if b, _, _ := strings.Cut(l, "-"); b == base {
+			return term, true
+		}
+	}
+
+	return "", false
+}
+
+// containsTerm reports whether the text contains the term as a whole word, ignoring case
+func containsTerm(text, term string) bool {
+	if term == "" {
+		return false
+	}
+
+	lowerText, lowerTerm := strings.ToLower(text), strings.ToLower(term)
+
+	for offset := 0; ; {
+		i := strings.Index(lowerText[offset:], lowerTerm)
+		if i < 0 {
+			return false
+		}
+
+		start, end := offset+i, offset+i+len(lowerTerm)
+
+		before, _ := utf8.DecodeLastRuneInString(lowerText[:start])
+		after, _ := utf8.DecodeRuneInString(lowerText[end:])
+
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+
+		offset = start + 1
+	}
+}
+
+// isWordRune reports whether the rune is part of a word
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// glossaryTranslator adds the relevant glossary terms to the requests of the wrapped translator
+type glossaryTranslator struct {
+	translator Translator
+	glossary   *Glossary
+}
+
+// batchGlossaryTranslator adds the relevant glossary terms to the requests of the wrapped batch translator
+type batchGlossaryTranslator struct {
+	*glossaryTranslator
+	batch BatchTranslator
+}
+
+// NewGlossaryTranslator wraps the translator so that the glossary terms appearing in the texts are
+// given to the model with their mandated translations. The returned translator implements
+// BatchTranslator if t does.
+func NewGlossaryTranslator(t Translator, glossary *Glossary) Translator {
+	gt := &glossaryTranslator{
+		translator: t,
+		glossary:   glossary,
+	}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchGlossaryTranslator{
+			glossaryTranslator: gt,
+			batch:              bt,
+		}
+	}
+
+	return gt
+}
+
+// Translate translates the request with the relevant glossary terms
+func (t *glossaryTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	return t.translator.Translate(ctx, t.withTerms(req))
+}
+
+// TranslateBatch translates the requests with the relevant glossary terms
+func (t *batchGlossaryTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	withTerms := make([]Request, len(reqs))
+	for i, req := range reqs {
+		withTerms[i] = t.withTerms(req)
+	}
+
+	return t.batch.TranslateBatch(ctx, withTerms)
+}
+
+// withTerms adds the glossary terms appearing in the text to the request
+func (t *glossaryTranslator) withTerms(req Request) Request {
+	if terms := t.glossary.Match(req.SourceLang, req.TargetLang, req.Text); len(terms) > 0 {
+		req.Glossary = append(req.Glossary[:len(req.Glossary):len(req.Glossary)], terms...)
+	}
+
+	return req
+}
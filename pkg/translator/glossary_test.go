@@ -0,0 +1,134 @@
+package translator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const csvGlossary = `term,de-DE,fr,dnt,note
+Help My Pet Bot,,,true,Product name
+questionnaire,Fragebogen,questionnaire,,
+pet,Haustier,,,
+`
+
+const tbxGlossary = `<?xml version="1.0" encoding="UTF-8"?>
+<martif type="TBX" xml:lang="en">
+  <text>
+    <body>
+      <termEntry id="c1">
+        <descrip type="definition">A list of questions about the pet</descrip>
+        <langSet xml:lang="en-US"><tig><term>questionnaire</term></tig></langSet>
+        <langSet xml:lang="de-DE"><tig><term>Fragebogen</term></tig><tig><term>Umfrage</term></tig></langSet>
+      </termEntry>
+      <termEntry id="c2">
+        <langSet xml:lang="en-US"><tig><term>Help My Pet Bot</term></tig></langSet>
+        <langSet xml:lang="de-DE"><tig><term>Help My Pet Bot</term></tig></langSet>
+      </termEntry>
+    </body>
+  </text>
+</martif>
+`
+
+func writeGlossary(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	return path
+}
+
+func TestLoadGlossary_CSV(t *testing.T) {
+	gloss, err := translator.LoadGlossary(writeGlossary(t, "glossary.csv", csvGlossary))
+	require.NoError(t, err)
+	assert.Equal(t, 3, gloss.Len())
+
+	terms := gloss.Match("en-US", "de-DE", "Welcome to Help My Pet Bot! Fill in the Questionnaire.")
+	assert.Equal(t, []translator.Term{
+		{Source: "Help My Pet Bot", Target: "Help My Pet Bot", Note: "Product name"},
+		{Source: "questionnaire", Target: "Fragebogen"},
+	}, terms)
+
+	// Base language columns match regional targets, terms without a translation are skipped
+	terms = gloss.Match("en-US", "fr-CA", "Your pet questionnaire")
+	assert.Equal(t, []translator.Term{{Source: "questionnaire", Target: "questionnaire"}}, terms)
+
+	// Terms match whole words only
+	assert.Empty(t, gloss.Match("en-US", "de-DE", "Competition"))
+}
+
+func TestLoadGlossary_TBX(t *testing.T) {
+	gloss, err := translator.LoadGlossary(writeGlossary(t, "glossary.tbx", tbxGlossary))
+	require.NoError(t, err)
+	assert.Equal(t, 2, gloss.Len())
+
+	terms := gloss.Match("en-US", "de-DE", "Help My Pet Bot questionnaire")
+	assert.Equal(t, []translator.Term{
+		{Source: "questionnaire", Target: "Fragebogen", Note: "A list of questions about the pet"},
+		{Source: "Help My Pet Bot", Target: "Help My Pet Bot"},
+	}, terms)
+
+	// No terms in the source language
+	assert.Empty(t, gloss.Match("fr-FR", "de-DE", "questionnaire"))
+}
+
+func TestLoadGlossary_Errors(t *testing.T) {
+	_, err := translator.LoadGlossary(writeGlossary(t, "glossary.txt", csvGlossary))
+	assert.ErrorContains(t, err, "unsupported glossary format")
+
+	_, err = translator.LoadGlossary(writeGlossary(t, "glossary.csv", "de-DE,fr-FR\nFragebogen,questionnaire\n"))
+	assert.ErrorContains(t, err, `"term" column`)
+
+	_, err = translator.LoadGlossary(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestMissingTerms(t *testing.T) {
+	terms := []translator.Term{
+		{Source: "Help My Pet Bot", Target: "Help My Pet Bot"},
+		{Source: "questionnaire", Target: "Fragebogen"},
+	}
+
+	assert.Empty(t, translator.MissingTerms("Füllen Sie den Fragebogen von Help My Pet Bot aus", terms))
+	assert.Equal(t, terms[1:], translator.MissingTerms("Füllen Sie die Umfrage von Help My Pet Bot aus", terms))
+	assert.Equal(t, terms, translator.MissingTerms("Füllen Sie die Umfrage von Hilf meinem Haustier aus", terms))
+}
+
+func TestNewGlossaryTranslator(t *testing.T) {
+	gloss, err := translator.LoadGlossary(writeGlossary(t, "glossary.csv", csvGlossary))
+	require.NoError(t, err)
+
+	mockTranslator := new(mocks.BatchTranslator)
+	mockTranslator.On("Translate", mock.Anything, mock.MatchedBy(func(req translator.Request) bool {
+		return len(req.Glossary) == 1 && req.Glossary[0].Target == "Fragebogen"
+	})).Return("Fragebogen", nil).Once()
+	mockTranslator.On("TranslateBatch", mock.Anything, mock.MatchedBy(func(reqs []translator.Request) bool {
+		return len(reqs) == 2 && len(reqs[0].Glossary) == 1 && len(reqs[1].Glossary) == 0
+	})).Return([]string{"Haustier", "Hallo"}, nil).Once()
+
+	trans := translator.NewGlossaryTranslator(mockTranslator, gloss)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "questionnaire", SourceLang: "en-US", TargetLang: "de-DE"})
+	require.NoError(t, err)
+	assert.Equal(t, "Fragebogen", result)
+
+	batch, ok := trans.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{
+		{Text: "pet", SourceLang: "en-US", TargetLang: "de-DE"},
+		{Text: "Hello", SourceLang: "en-US", TargetLang: "de-DE"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Haustier", "Hallo"}, translations)
+
+	mockTranslator.AssertExpectations(t)
+}
@@ -6,8 +6,22 @@ import (
 
 // Translator defines the interface for translating text
 type Translator interface {
-	// Translate translates text to the specified target language
-	Translate(ctx context.Context, text string, targetLang string) (string, error)
+	// Translate translates the text of the request to its target language
+	Translate(ctx context.Context, req Request) (string, error)
+}
+
+// BatchTranslator is implemented by translators able to translate several requests in a single call
+type BatchTranslator interface {
+	Translator
+	// TranslateBatch translates all requests in a single call. The result has a translation for
+	// every request, with an empty string for the requests that could not be translated.
+	TranslateBatch(ctx context.Context, reqs []Request) ([]string, error)
+}
+
+// Completer defines the interface for sending a raw prompt to a language model
+type Completer interface {
+	// Complete returns the model response for the system and user prompts
+	Complete(ctx context.Context, system, user string) (string, error)
 }
 
 // Provider defines the interface for LLM providers
@@ -0,0 +1,56 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnsupportedLanguageError is returned by machine translation engines for languages they cannot translate
+type UnsupportedLanguageError struct {
+	// Provider is the name of the provider that does not support the language
+	Provider string
+	// Lang is the language tag as given in the request
+	Lang string
+	// Source reports whether the language is the source language, rather than the target language
+	Source bool
+}
+
+// Error returns a human readable description of the error
+func (e *UnsupportedLanguageError) Error() string {
+	role := "target"
+	if e.Source {
+		role = "source"
+	}
+
+	return fmt.Sprintf("%s does not support the %s language %q", e.Provider, role, e.Lang)
+}
+
+// langTag is a language tag split into its subtags, all in lower case
+type langTag struct {
+	base   string
+	script string
+	region string
+}
+
+// parseLangTag splits a language tag like "zh-Hant-TW" or "en_GB" into its subtags. Subtags other
+// than the script and the region are ignored.
+func parseLangTag(tag string) langTag {
+	parts := strings.Split(normalizeLang(tag), "-")
+	lt := langTag{base: parts[0]}
+
+	for _, part := range parts[1:] {
+		switch {
+		case len(part) == 4 && lt.script == "" && lt.region == "":
+			lt.script = part
+		case (len(part) == 2 || len(part) == 3) && lt.region == "":
+			lt.region = part
+		}
+	}
+
+	return lt
+}
+
+// traditionalChinese reports whether the tag is Chinese written in traditional characters
+func (t langTag) traditionalChinese() bool {
+	return t.base == "zh" && (t.script == "hant" || (t.script == "" && (t.region == "tw" || t.region == "hk" || t.region == "mo")))
+}
@@ -0,0 +1,352 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	libreTranslateBaseURL = "http://localhost:5000"
+	// libreTranslateMaxTexts is the maximum number of texts of a single LibreTranslate request
+	libreTranslateMaxTexts = 50
+)
+
+// LibreTranslateProvider provides translation using a LibreTranslate server
+type LibreTranslateProvider struct{}
+
+// GetName returns the name of the provider
+func (p *LibreTranslateProvider) GetName() string {
+	return "libretranslate"
+}
+
+// CreateTranslator creates a translator instance. The API key is optional, self-hosted servers
+// usually don't require one.
+func (p *LibreTranslateProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		baseURL = libreTranslateBaseURL
+	}
+
+	format, ok := config["format"].(string)
+	if !ok || format == "" {
+		format = "text"
+	}
+
+	if format != "text" && format != "html" {
+		return nil, fmt.Errorf("invalid LibreTranslate format %q, use text or html", format)
+	}
+
+	apiKey, _ := config["api_key"].(string)
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LibreTranslateTranslator{
+		apiKey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		format:  format,
+		retry:   retry,
+	}, nil
+}
+
+// LibreTranslateTranslator implements the Translator interface using the LibreTranslate API
+type LibreTranslateTranslator struct {
+	apiKey  string
+	baseURL string
+	format  string
+	retry   RetryPolicy
+
+	// languages are the languages of the server, loaded on the first translation
+	mu        sync.Mutex
+	languages []LibreTranslateLanguage
+}
+
+// LibreTranslateRequest represents a request to the translate endpoint of the LibreTranslate API.
+// Q is a string for a single text and a list of strings for several texts.
+type LibreTranslateRequest struct {
+	Q      interface{} `json:"q"`
+	Source string      `json:"source"`
+	Target string      `json:"target"`
+	Format string      `json:"format"`
+	APIKey string      `json:"api_key,omitempty"`
+}
+
+// LibreTranslateResponse represents a response of the translate endpoint of the LibreTranslate API.
+// TranslatedText is a string or a list of strings, like the texts of the request.
+type LibreTranslateResponse struct {
+	TranslatedText json.RawMessage `json:"translatedText"`
+	Error          string          `json:"error,omitempty"`
+}
+
+// LibreTranslateLanguage is a language supported by the LibreTranslate server
+type LibreTranslateLanguage struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Targets []string `json:"targets"`
+}
+
+// Translate translates the text of the request to its target language
+func (t *LibreTranslateTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	translations, err := t.translate(ctx, req.SourceLang, req.TargetLang, []string{req.Text})
+	if err != nil {
+		return "", err
+	}
+
+	return translations[0], nil
+}
+
+// TranslateBatch translates the requests with as few calls as possible, grouping the texts
+// by their languages
+func (t *LibreTranslateTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateGrouped(reqs, libreTranslateMaxTexts, func(sourceLang, targetLang string, texts []string) ([]string, error) {
+		return t.translate(ctx, sourceLang, targetLang, texts)
+	})
+}
+
+// translate translates the texts in a single call
+func (t *LibreTranslateTranslator) translate(ctx context.Context, sourceLang, targetLang string, texts []string) ([]string, error) {
+	source, target, err := t.languageCodes(ctx, sourceLang, targetLang)
+	if err != nil {
+		return nil, err
+	}
+
+	protected, format, escaped := t.protect(texts)
+
+	requestBody := LibreTranslateRequest{
+		Q:      protected,
+		Source: source,
+		Target: target,
+		Format: format,
+		APIKey: t.apiKey,
+	}
+
+	if len(texts) == 1 {
+		requestBody.Q = protected[0]
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var translations []string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translations, err = t.send(ctx, jsonData)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(translations) != len(texts) {
+		return nil, fmt.Errorf("LibreTranslate returned %d translations for %d texts", len(translations), len(texts))
+	}
+
+	if format == "html" {
+		for i := range translations {
+			translations[i] = mtKeepHTMLRe.ReplaceAllString(translations[i], "$1")
+
+			if escaped {
+				translations[i] = html.UnescapeString(translations[i])
+			}
+		}
+	}
+
+	return translations, nil
+}
+
+// protect marks the placeholders of the texts as not translatable, as the HTML format of LibreTranslate
+// keeps the elements with translate="no". Plain texts with placeholders are escaped and sent as HTML.
+// It returns the texts, their format and whether the texts were escaped.
+func (t *LibreTranslateTranslator) protect(texts []string) ([]string, string, bool) {
+	hasPlaceholders := false
+	for _, text := range texts {
+		if mtPlaceholderRe.MatchString(text) {
+			hasPlaceholders = true
+			break
+		}
+	}
+
+	if !hasPlaceholders {
+		return texts, t.format, false
+	}
+
+	escaped := t.format == "text"
+	protected := make([]string, len(texts))
+
+	for i, text := range texts {
+		if escaped {
+			text = html.EscapeString(text)
+		}
+
+		protected[i] = mtPlaceholderRe.ReplaceAllStringFunc(text, func(placeholder string) string {
+			return `<span translate="no">` + placeholder + `</span>`
+		})
+	}
+
+	return protected, "html", escaped
+}
+
+// languageCodes returns the codes of the server for the source and target languages. The source
+// language is detected by the server if it is empty.
+func (t *LibreTranslateTranslator) languageCodes(ctx context.Context, sourceLang, targetLang string) (string, string, error) {
+	languages, err := t.loadLanguages(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	target, ok := libreTranslateCode(languages, targetLang)
+	if !ok {
+		return "", "", &UnsupportedLanguageError{Provider: "LibreTranslate", Lang: targetLang}
+	}
+
+	if sourceLang == "" {
+		return "auto", target, nil
+	}
+
+	source, ok := libreTranslateCode(languages, sourceLang)
+	if !ok {
+		return "", "", &UnsupportedLanguageError{Provider: "LibreTranslate", Lang: sourceLang, Source: true}
+	}
+
+	for _, lang := range languages {
+		if lang.Code != source || lang.Targets == nil {
+			continue
+		}
+
+		for _, code := range lang.Targets {
+			if code == target {
+				return source, target, nil
+			}
+		}
+
+		return "", "", &UnsupportedLanguageError{Provider: "LibreTranslate", Lang: targetLang}
+	}
+
+	return source, target, nil
+}
+
+// libreTranslateCode returns the code of the server for the language tag. The full tag is preferred,
+// e.g. pt-BR or zh-Hant, then the base language.
+func libreTranslateCode(languages []LibreTranslateLanguage, lang string) (string, bool) {
+	tag := parseLangTag(lang)
+
+	candidates := []string{normalizeLang(lang)}
+
+	switch {
+	case tag.traditionalChinese():
+		candidates = append(candidates, "zh-hant", "zt")
+	case tag.base == "zh":
+		candidates = append(candidates, "zh-hans")
+	case tag.region != "":
+		candidates = append(candidates, tag.base+"-"+tag.region)
+	}
+
+	candidates = append(candidates, tag.base)
+
+	for _, candidate := range candidates {
+		for _, language := range languages {
+			if sameLang(language.Code, candidate) {
+				return language.Code, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// loadLanguages returns the languages supported by the server, loading them on the first call
+func (t *LibreTranslateTranslator) loadLanguages(ctx context.Context) ([]LibreTranslateLanguage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.languages != nil {
+		return t.languages, nil
+	}
+
+	var languages []LibreTranslateLanguage
+
+	err := t.retry.Do(ctx, func(ctx context.Context) error {
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", t.baseURL+"/languages", nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		client := &http.Client{}
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return newRequestError(ctx, "LibreTranslate", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError("LibreTranslate", resp.StatusCode, resp.Header, "")
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&languages); err != nil {
+			return fmt.Errorf("failed to unmarshal languages: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load LibreTranslate languages: %w", err)
+	}
+
+	t.languages = languages
+
+	return languages, nil
+}
+
+// send sends a single request to the LibreTranslate API and returns the translations of the response
+func (t *LibreTranslateTranslator) send(ctx context.Context, jsonData []byte) ([]string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.baseURL+"/translate", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, newRequestError(ctx, "LibreTranslate", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newRequestError(ctx, "LibreTranslate", err)
+	}
+
+	var response LibreTranslateResponse
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError("LibreTranslate", resp.StatusCode, resp.Header, response.Error)
+	}
+
+	var translations []string
+	if err := json.Unmarshal(response.TranslatedText, &translations); err == nil {
+		return translations, nil
+	}
+
+	var translation string
+	if err := json.Unmarshal(response.TranslatedText, &translation); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal translated text: %w", err)
+	}
+
+	return []string{translation}, nil
+}
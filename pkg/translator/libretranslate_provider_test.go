@@ -0,0 +1,212 @@
+package translator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const libreTranslateLanguages = `[
+  {"code": "en", "name": "English", "targets": ["en", "de", "pt-BR", "zh-Hans", "zh-Hant"]},
+  {"code": "de", "name": "German", "targets": ["de", "en"]},
+  {"code": "pt-BR", "name": "Portuguese (Brazil)", "targets": ["pt-BR", "en"]},
+  {"code": "zh-Hans", "name": "Chinese", "targets": ["zh-Hans", "en"]},
+  {"code": "zh-Hant", "name": "Chinese (traditional)", "targets": ["zh-Hant", "en"]}
+]`
+
+// libreTranslateKeepRe matches the elements that the HTML format of LibreTranslate does not translate
+var libreTranslateKeepRe = regexp.MustCompile(`<span translate="no">.*?</span>`)
+
+// libreTranslateUpper upper cases the text like a translation, keeping the elements marked as not
+// translatable in HTML texts
+func libreTranslateUpper(text, format string) string {
+	if format != "html" {
+		return strings.ToUpper(text)
+	}
+
+	var sb strings.Builder
+
+	last := 0
+	for _, loc := range libreTranslateKeepRe.FindAllStringIndex(text, -1) {
+		sb.WriteString(strings.ToUpper(text[last:loc[0]]))
+		sb.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+
+	sb.WriteString(strings.ToUpper(text[last:]))
+
+	return sb.String()
+}
+
+// libreTranslateServer starts a stand-in LibreTranslate server that upper cases the texts
+func libreTranslateServer(t *testing.T) *providerServer {
+	t.Helper()
+
+	return newProviderServerFunc(t, func(r recordedRequest) (int, string) {
+		if r.URL.Path == "/languages" {
+			return http.StatusOK, libreTranslateLanguages
+		}
+
+		assert.Equal(t, "/translate", r.URL.Path)
+
+		var req map[string]interface{}
+		assert.NoError(t, json.Unmarshal(r.Body, &req))
+
+		var translated interface{}
+
+		format, _ := req["format"].(string)
+
+		switch q := req["q"].(type) {
+		case string:
+			translated = libreTranslateUpper(q, format)
+		case []interface{}:
+			texts := make([]string, len(q))
+			for i, text := range q {
+				texts[i] = libreTranslateUpper(text.(string), format)
+			}
+
+			translated = texts
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"translatedText": translated})
+		assert.NoError(t, err)
+
+		return http.StatusOK, string(data)
+	})
+}
+
+// libreTranslateRequests returns the translate requests received by the stand-in server
+func libreTranslateRequests(t *testing.T, srv *providerServer) []map[string]interface{} {
+	t.Helper()
+
+	var requests []recordedRequest
+
+	for _, r := range srv.Requests() {
+		if r.URL.Path == "/translate" {
+			requests = append(requests, r)
+		}
+	}
+
+	return decodeRequests[map[string]interface{}](t, requests)
+}
+
+func TestLibreTranslateProvider_GetName(t *testing.T) {
+	provider := &translator.LibreTranslateProvider{}
+	assert.Equal(t, "libretranslate", provider.GetName())
+}
+
+func TestLibreTranslateProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.LibreTranslateProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"format": "markdown"})
+	assert.ErrorContains(t, err, "invalid LibreTranslate format")
+}
+
+func TestLibreTranslateTranslator_Translate(t *testing.T) {
+	srv := libreTranslateServer(t)
+
+	trans, err := (&translator.LibreTranslateProvider{}).CreateTranslator(map[string]interface{}{
+		"base_url": srv.URL,
+		"api_key":  "libre-key",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		source, target         string
+		sourceCode, targetCode string
+	}{
+		{"en-US", "de-DE", "en", "de"},
+		{"en-GB", "pt-BR", "en", "pt-BR"},
+		{"en", "zh-CN", "en", "zh-Hans"},
+		{"en", "zh-TW", "en", "zh-Hant"},
+		{"", "de", "auto", "de"},
+	}
+
+	for i, tt := range tests {
+		result, err := trans.Translate(context.Background(), translator.Request{Text: "hello", SourceLang: tt.source, TargetLang: tt.target})
+		require.NoError(t, err, tt.target)
+		assert.Equal(t, "HELLO", result)
+
+		req := libreTranslateRequests(t, srv)[i]
+		assert.Equal(t, tt.sourceCode, req["source"], tt.target)
+		assert.Equal(t, tt.targetCode, req["target"], tt.target)
+		assert.Equal(t, "text", req["format"])
+		assert.Equal(t, "libre-key", req["api_key"])
+	}
+}
+
+func TestLibreTranslateTranslator_Placeholders(t *testing.T) {
+	srv := libreTranslateServer(t)
+
+	trans, err := (&translator.LibreTranslateProvider{}).CreateTranslator(map[string]interface{}{"base_url": srv.URL})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{
+		Text:       "hello {Name}, you have %[1]d <new> ⟦1⟧messages⟦2⟧",
+		SourceLang: "en",
+		TargetLang: "de",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO {Name}, YOU HAVE %[1]d <NEW> ⟦1⟧MESSAGES⟦2⟧", result)
+
+	requests := libreTranslateRequests(t, srv)
+	require.Len(t, requests, 1)
+
+	req := requests[0]
+	assert.Equal(t, "html", req["format"])
+	assert.Equal(t, `hello <span translate="no">{Name}</span>, you have <span translate="no">%[1]d</span> &lt;new&gt; `+
+		`<span translate="no">⟦1⟧</span>messages<span translate="no">⟦2⟧</span>`, req["q"])
+}
+
+func TestLibreTranslateTranslator_UnsupportedLanguage(t *testing.T) {
+	srv := libreTranslateServer(t)
+
+	trans, err := (&translator.LibreTranslateProvider{}).CreateTranslator(map[string]interface{}{"base_url": srv.URL})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "hello", SourceLang: "en", TargetLang: "ja-JP"})
+
+	var langErr *translator.UnsupportedLanguageError
+	require.True(t, errors.As(err, &langErr))
+	assert.Equal(t, `LibreTranslate does not support the target language "ja-JP"`, err.Error())
+
+	// German is supported, but not from Portuguese
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "olá", SourceLang: "pt-BR", TargetLang: "de"})
+	assert.EqualError(t, err, `LibreTranslate does not support the target language "de"`)
+
+	assert.Empty(t, libreTranslateRequests(t, srv))
+}
+
+func TestLibreTranslateTranslator_TranslateBatch(t *testing.T) {
+	srv := libreTranslateServer(t)
+
+	trans, err := (&translator.LibreTranslateProvider{}).CreateTranslator(map[string]interface{}{"base_url": srv.URL, "format": "html"})
+	require.NoError(t, err)
+
+	batch, ok := trans.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{
+		{Text: "one", SourceLang: "en", TargetLang: "de"},
+		{Text: "<b>two</b>", SourceLang: "en", TargetLang: "de"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ONE", "<B>TWO</B>"}, translations)
+
+	requests := libreTranslateRequests(t, srv)
+	require.Len(t, requests, 1)
+	assert.Equal(t, []interface{}{"one", "<b>two</b>"}, requests[0]["q"])
+	assert.Equal(t, "html", requests[0]["format"])
+}
@@ -0,0 +1,298 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// tokenOpen and tokenClose delimit the tokens that replace protected spans, e.g. ⟦1⟧
+	tokenOpen  = "⟦"
+	tokenClose = "⟧"
+)
+
+var (
+	tokenRe        = regexp.MustCompile(`⟦(\d+)⟧`)
+	htmlTagRe      = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(?:\s[^<>]*)?/?>`)
+	urlRe          = regexp.MustCompile(`(?:https?|ftp)://[^\s<>"]+|www\.[a-zA-Z0-9-]+\.[^\s<>"]+`)
+	emailRe        = regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
+	slashCommandRe = regexp.MustCompile(`/[a-zA-Z0-9_]+(?:@[a-zA-Z0-9_]+)?`)
+
+	// voidTags are HTML elements without closing tags
+	voidTags = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+)
+
+// MarkupError is returned when the translation of a text with protected markup lost or broke it
+type MarkupError struct {
+	// Missing are the protected spans whose tokens are missing from the translation
+	Missing []string
+	// Duplicated are the protected spans whose tokens appear more than once in the translation
+	Duplicated []string
+	// Unexpected are tokens in the translation that don't stand for any protected span
+	Unexpected []string
+	// Unbalanced describes the broken structure of HTML tags, if any
+	Unbalanced string
+}
+
+// Error returns a human readable description of the broken markup
+func (e *MarkupError) Error() string {
+	var parts []string
+
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing markup "+strings.Join(e.Missing, ", "))
+	}
+
+	if len(e.Duplicated) > 0 {
+		parts = append(parts, "duplicated markup "+strings.Join(e.Duplicated, ", "))
+	}
+
+	if len(e.Unexpected) > 0 {
+		parts = append(parts, "unexpected tokens "+strings.Join(e.Unexpected, ", "))
+	}
+
+	if e.Unbalanced != "" {
+		parts = append(parts, e.Unbalanced)
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// span is a protected part of a text
+type span struct {
+	start, end int
+}
+
+// maskMarkup replaces HTML tags, URLs, emails and slash commands of the text with tokens like ⟦1⟧.
+// It returns the masked text and the protected spans in the order of their tokens. Texts that already
+// contain tokens are not masked.
+func maskMarkup(text string) (string, []string) {
+	if strings.Contains(text, tokenOpen) {
+		return text, nil
+	}
+
+	var spans []span
+
+	for _, re := range []*regexp.Regexp{htmlTagRe, urlRe, emailRe} {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			end := loc[1]
+			if re == urlRe {
+				// Trailing punctuation usually ends the sentence, not the URL
+				end = loc[0] + len(strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?)'"))
+			}
+
+			spans = append(spans, span{start: loc[0], end: end})
+		}
+	}
+
+	for _, loc := range slashCommandRe.FindAllStringIndex(text, -1) {
+		if isSlashCommand(text, loc[0], loc[1]) {
+			spans = append(spans, span{start: loc[0], end: loc[1]})
+		}
+	}
+
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	// Keep the earliest and then the longest of overlapping spans, e.g. a tag containing a URL
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+
+		return spans[i].end > spans[j].end
+	})
+
+	var (
+		sb        strings.Builder
+		protected []string
+		pos       int
+	)
+
+	for _, s := range spans {
+		if s.start < pos {
+			continue
+		}
+
+		protected = append(protected, text[s.start:s.end])
+
+		sb.WriteString(text[pos:s.start])
+		sb.WriteString(tokenOpen + strconv.Itoa(len(protected)) + tokenClose)
+
+		pos = s.end
+	}
+
+	sb.WriteString(text[pos:])
+
+	return sb.String(), protected
+}
+
+// isSlashCommand reports whether the match of slashCommandRe is a command like /start rather than
+// a part of a path or a fraction: it must start the text or follow a space or an opening bracket,
+// and must not continue as a path.
+func isSlashCommand(text string, start, end int) bool {
+	if start > 0 && !strings.ContainsAny(text[start-1:start], " \t\n(\"'«") {
+		return false
+	}
+
+	return end == len(text) || text[end] != '/'
+}
+
+// unmaskMarkup restores the protected spans in the translation of a masked text. It fails if any
+// token is missing, duplicated or unknown, or if the HTML tags no longer balance while they did in
+// the source text.
+func unmaskMarkup(source, translation string, protected []string) (string, error) {
+	if len(protected) == 0 {
+		return translation, nil
+	}
+
+	counts := make(map[int]int, len(protected))
+
+	mErr := &MarkupError{}
+
+	for _, m := range tokenRe.FindAllStringSubmatch(translation, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 || n > len(protected) {
+			mErr.Unexpected = append(mErr.Unexpected, m[0])
+			continue
+		}
+
+		counts[n]++
+	}
+
+	for i, p := range protected {
+		switch counts[i+1] {
+		case 0:
+			mErr.Missing = append(mErr.Missing, p)
+		case 1:
+		default:
+			mErr.Duplicated = append(mErr.Duplicated, p)
+		}
+	}
+
+	if len(mErr.Missing) > 0 || len(mErr.Duplicated) > 0 || len(mErr.Unexpected) > 0 {
+		return "", mErr
+	}
+
+	restored := tokenRe.ReplaceAllStringFunc(translation, func(token string) string {
+		n, _ := strconv.Atoi(token[len(tokenOpen) : len(token)-len(tokenClose)])
+		return protected[n-1]
+	})
+
+	if checkTags(source) == "" {
+		if mErr.Unbalanced = checkTags(restored); mErr.Unbalanced != "" {
+			return "", mErr
+		}
+	}
+
+	return restored, nil
+}
+
+// checkTags returns a description of the first problem with the nesting of HTML tags in the text,
+// or an empty string if the tags balance
+func checkTags(text string) string {
+	var stack []string
+
+	for _, tag := range htmlTagRe.FindAllString(text, -1) {
+		name := strings.ToLower(strings.TrimLeft(strings.Fields(strings.Trim(tag, "<>/"))[0], "/"))
+
+		switch {
+		case voidTags[name] || strings.HasSuffix(tag, "/>"):
+			continue
+		case strings.HasPrefix(tag, "</"):
+			if len(stack) == 0 || stack[len(stack)-1] != name {
+				return fmt.Sprintf("unbalanced tag %s", tag)
+			}
+
+			stack = stack[:len(stack)-1]
+		default:
+			stack = append(stack, name)
+		}
+	}
+
+	if len(stack) > 0 {
+		return fmt.Sprintf("unclosed tag <%s>", stack[len(stack)-1])
+	}
+
+	return ""
+}
+
+// markupTranslator protects the markup of texts sent to the wrapped translator
+type markupTranslator struct {
+	translator Translator
+}
+
+// batchMarkupTranslator protects the markup of texts sent to the wrapped batch translator
+type batchMarkupTranslator struct {
+	*markupTranslator
+	batch BatchTranslator
+}
+
+// NewMarkupProtection wraps the translator so that HTML tags, URLs, emails and slash commands are
+// replaced with opaque tokens before the text is sent and restored in the translation. Translations
+// that lose or break the protected markup fail with *MarkupError. The returned translator implements
+// BatchTranslator if t does.
+func NewMarkupProtection(t Translator) Translator {
+	mt := &markupTranslator{translator: t}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchMarkupTranslator{
+			markupTranslator: mt,
+			batch:            bt,
+		}
+	}
+
+	return mt
+}
+
+// Translate translates the request with its markup protected
+func (t *markupTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	source := req.Text
+
+	var protected []string
+
+	req.Text, protected = maskMarkup(source)
+
+	translation, err := t.translator.Translate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	return unmaskMarkup(source, translation, protected)
+}
+
+// TranslateBatch translates the requests with their markup protected. Translations with broken
+// markup are returned empty, so they are translated again one by one.
+func (t *batchMarkupTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	masked := make([]Request, len(reqs))
+	protected := make([][]string, len(reqs))
+
+	for i, req := range reqs {
+		masked[i] = req
+		masked[i].Text, protected[i] = maskMarkup(req.Text)
+	}
+
+	translations, err := t.batch.TranslateBatch(ctx, masked)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range translations {
+		if i >= len(reqs) || translations[i] == "" {
+			continue
+		}
+
+		if translations[i], err = unmaskMarkup(reqs[i].Text, translations[i], protected[i]); err != nil {
+			translations[i] = ""
+		}
+	}
+
+	return translations, nil
+}
@@ -0,0 +1,145 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskMarkup(t *testing.T) {
+	tests := []struct {
+		text      string
+		masked    string
+		protected []string
+	}{
+		{
+			text:   "Hello, World!",
+			masked: "Hello, World!",
+		},
+		{
+			text:      "<b>Bold</b> and <i>italic</i>",
+			masked:    "⟦1⟧Bold⟦2⟧ and ⟦3⟧italic⟦4⟧",
+			protected: []string{"<b>", "</b>", "<i>", "</i>"},
+		},
+		{
+			text:      `Read <a href="https://example.com/faq">the FAQ</a> or visit https://example.com/help.`,
+			masked:    "Read ⟦1⟧the FAQ⟦2⟧ or visit ⟦3⟧.",
+			protected: []string{`<a href="https://example.com/faq">`, "</a>", "https://example.com/help"},
+		},
+		{
+			text:      "Write to support@example.com or use /help and /start@MyBot",
+			masked:    "Write to ⟦1⟧ or use ⟦2⟧ and ⟦3⟧",
+			protected: []string{"support@example.com", "/help", "/start@MyBot"},
+		},
+		{
+			text:   "Costs 1/2 and/or the path /usr/bin",
+			masked: "Costs 1/2 and/or the path /usr/bin",
+		},
+		{
+			text:   "Already ⟦1⟧ masked <b>text</b>",
+			masked: "Already ⟦1⟧ masked <b>text</b>",
+		},
+	}
+
+	for _, tt := range tests {
+		masked, protected := maskMarkup(tt.text)
+		assert.Equal(t, tt.masked, masked, tt.text)
+		assert.Equal(t, tt.protected, protected, tt.text)
+	}
+}
+
+func TestUnmaskMarkup(t *testing.T) {
+	source := "Use /editprofile to update <b>your profile</b>"
+	_, protected := maskMarkup(source)
+
+	restored, err := unmaskMarkup(source, "Nutzen Sie ⟦1⟧, um ⟦2⟧Ihr Profil⟦3⟧ zu ändern", protected)
+	require.NoError(t, err)
+	assert.Equal(t, "Nutzen Sie /editprofile, um <b>Ihr Profil</b> zu ändern", restored)
+
+	tests := []struct {
+		translation string
+		expected    string
+	}{
+		{"Nutzen Sie /profil, um ⟦2⟧Ihr Profil⟦3⟧ zu ändern", "missing markup /editprofile"},
+		{"Nutzen Sie ⟦1⟧ ⟦1⟧, um ⟦2⟧Ihr Profil⟦3⟧ zu ändern", "duplicated markup /editprofile"},
+		{"Nutzen Sie ⟦1⟧, um ⟦2⟧Ihr Profil⟦3⟧ zu ändern ⟦4⟧", "unexpected tokens ⟦4⟧"},
+		{"Nutzen Sie ⟦1⟧, um ⟦3⟧Ihr Profil⟦2⟧ zu ändern", "unbalanced tag </b>"},
+	}
+
+	for _, tt := range tests {
+		_, err := unmaskMarkup(source, tt.translation, protected)
+
+		var mErr *MarkupError
+		require.True(t, errors.As(err, &mErr), tt.translation)
+		assert.Equal(t, tt.expected, err.Error())
+	}
+
+	// Tags that don't balance in the source are not checked
+	source = "Closing</b> tag"
+	_, protected = maskMarkup(source)
+
+	restored, err = unmaskMarkup(source, "Schließendes⟦1⟧ Tag", protected)
+	require.NoError(t, err)
+	assert.Equal(t, "Schließendes</b> Tag", restored)
+}
+
+func TestCheckTags(t *testing.T) {
+	assert.Empty(t, checkTags(`<b>bold <a href="x">link</a></b><br>line<br/>`))
+	assert.Equal(t, "unclosed tag <i>", checkTags("<i>italic"))
+	assert.Equal(t, "unbalanced tag </i>", checkTags("<b>bold</i>"))
+}
+
+// scriptedBatchTranslator returns the translations given for the texts
+type scriptedBatchTranslator struct {
+	translations map[string]string
+	requests     []Request
+}
+
+func (t *scriptedBatchTranslator) Translate(_ context.Context, req Request) (string, error) {
+	t.requests = append(t.requests, req)
+	return t.translations[req.Text], nil
+}
+
+func (t *scriptedBatchTranslator) TranslateBatch(_ context.Context, reqs []Request) ([]string, error) {
+	translations := make([]string, len(reqs))
+
+	for i, req := range reqs {
+		t.requests = append(t.requests, req)
+		translations[i] = t.translations[req.Text]
+	}
+
+	return translations, nil
+}
+
+func TestNewMarkupProtection(t *testing.T) {
+	inner := &scriptedBatchTranslator{translations: map[string]string{
+		"Open ⟦1⟧":  "Öffne ⟦1⟧",
+		"Close ⟦1⟧": "Schließe",
+		"Hello":     "Hallo",
+	}}
+
+	trans := NewMarkupProtection(inner)
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Open https://example.com", TargetLang: "de"})
+	require.NoError(t, err)
+	assert.Equal(t, "Öffne https://example.com", result)
+	assert.Contains(t, buildUserPrompt(inner.requests[0]), "tokens like ⟦1⟧")
+
+	_, err = trans.Translate(context.Background(), Request{Text: "Close https://example.com", TargetLang: "de"})
+	assert.ErrorContains(t, err, "missing markup https://example.com")
+
+	batch, ok := trans.(BatchTranslator)
+	require.True(t, ok)
+
+	// Broken translations are returned empty to be translated again separately
+	translations, err := batch.TranslateBatch(context.Background(), []Request{
+		{Text: "Open https://example.com"},
+		{Text: "Close https://example.com"},
+		{Text: "Hello"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Öffne https://example.com", "", "Hallo"}, translations)
+}
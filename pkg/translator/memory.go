@@ -0,0 +1,409 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxReferences is the maximum number of fuzzy matches given to the model as references
+const maxReferences = 3
+
+// memoryUnit is a source text with its approved translations
+type memoryUnit struct {
+	SourceLang string
+	Source     string
+	Variants   []memoryVariant
+}
+
+// memoryVariant is an approved translation of a memory unit
+type memoryVariant struct {
+	Lang string
+	Text string
+}
+
+// memoryKey identifies a unit by its source language and text
+type memoryKey struct {
+	sourceLang string
+	source     string
+}
+
+// Memory is a translation memory of approved translations. It is stored as a TMX 1.4 file, so it can
+// be exchanged with localization vendors and CAT tools.
+type Memory struct {
+	mu    sync.RWMutex
+	path  string
+	units []*memoryUnit
+	index map[memoryKey]*memoryUnit
+	dirty bool
+}
+
+// NewMemory creates an empty translation memory that is not stored anywhere
+func NewMemory() *Memory {
+	return &Memory{
+		index: make(map[memoryKey]*memoryUnit),
+	}
+}
+
+// OpenMemory loads the translation memory stored in the TMX file at path. A missing file results in
+// an empty memory, which is created by Save.
+func OpenMemory(path string) (*Memory, error) {
+	m := NewMemory()
+	m.path = path
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open translation memory: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := m.Import(f); err != nil {
+		return nil, fmt.Errorf("failed to load translation memory %s: %w", path, err)
+	}
+
+	m.dirty = false
+
+	return m, nil
+}
+
+// Import merges the translation units of the TMX document into the memory and returns their number.
+// Imported translations replace the ones stored for the same texts and languages.
+func (m *Memory) Import(r io.Reader) (int, error) {
+	units, err := readTMX(r)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, unit := range units {
+		for _, v := range unit.Variants {
+			m.Add(unit.SourceLang, v.Lang, unit.Source, v.Text)
+		}
+	}
+
+	return len(units), nil
+}
+
+// Export writes the memory as a TMX 1.4 document
+func (m *Memory) Export(w io.Writer) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	units := make([]memoryUnit, len(m.units))
+	for i, unit := range m.units {
+		units[i] = *unit
+	}
+
+	return writeTMX(w, units)
+}
+
+// Save writes the memory to the file it was opened from, if it has changed. The file is replaced
+// atomically, so an interrupted save does not corrupt it.
+func (m *Memory) Save() error {
+	m.mu.Lock()
+	dirty, path := m.dirty, m.path
+	m.dirty = false
+	m.mu.Unlock()
+
+	if !dirty || path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create translation memory directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create translation memory file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := m.Export(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write translation memory: %w", err)
+	}
+
+	return nil
+}
+
+// Add stores the approved translation of the source text
+func (m *Memory) Add(sourceLang, targetLang, source, translation string) {
+	if sourceLang == "" || targetLang == "" || source == "" || translation == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := memoryKey{sourceLang: normalizeLang(sourceLang), source: source}
+
+	unit, ok := m.index[key]
+	if !ok {
+		unit = &memoryUnit{SourceLang: sourceLang, Source: source}
+		m.index[key] = unit
+		m.units = append(m.units, unit)
+	}
+
+	for i, v := range unit.Variants {
+		if sameLang(v.Lang, targetLang) {
+			if v.Text != translation {
+				unit.Variants[i].Text = translation
+				m.dirty = true
+			}
+
+			return
+		}
+	}
+
+	unit.Variants = append(unit.Variants, memoryVariant{Lang: targetLang, Text: translation})
+	m.dirty = true
+}
+
+// Lookup returns the approved translation of exactly the same source text
+func (m *Memory) Lookup(sourceLang, targetLang, source string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	unit, ok := m.index[memoryKey{sourceLang: normalizeLang(sourceLang), source: source}]
+	if !ok {
+		return "", false
+	}
+
+	return unit.translation(targetLang)
+}
+
+// Fuzzy returns up to limit approved translations of texts similar to the source text, with
+// the similarity of at least threshold, the most similar first. Exact matches are not included.
+func (m *Memory) Fuzzy(sourceLang, targetLang, source string, threshold float64, limit int) []Reference {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var refs []Reference
+
+	sourceLen := utf8.RuneCountInString(source)
+
+	for _, unit := range m.units {
+		if unit.Source == source || !sameLang(unit.SourceLang, sourceLang) {
+			continue
+		}
+
+		translation, ok := unit.translation(targetLang)
+		if !ok {
+			continue
+		}
+
+		// The similarity can't exceed the ratio of the lengths, skip the distance calculation
+		unitLen := utf8.RuneCountInString(unit.Source)
+		if float64(min(sourceLen, unitLen)) < threshold*float64(max(sourceLen, unitLen)) {
+			continue
+		}
+
+		if score := similarity(source, unit.Source); score >= threshold {
+			refs = append(refs, Reference{Source: unit.Source, Translation: translation, Score: score})
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].Score > refs[j].Score })
+
+	if len(refs) > limit {
+		refs = refs[:limit]
+	}
+
+	return refs
+}
+
+// Len returns the number of source texts in the memory
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.units)
+}
+
+// translation returns the translation of the unit to the language
+func (u *memoryUnit) translation(lang string) (string, bool) {
+	for _, v := range u.Variants {
+		if sameLang(v.Lang, lang) {
+			return v.Text, true
+		}
+	}
+
+	return "", false
+}
+
+// normalizeLang returns the language code in the form used for comparison, e.g. en_US -> en-us
+func normalizeLang(lang string) string {
+	return strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+}
+
+// sameLang reports whether the language codes are the same, ignoring case and separators
+func sameLang(a, b string) bool {
+	return normalizeLang(a) == normalizeLang(b)
+}
+
+// similarity returns the similarity of the texts from 0 to 1 based on their Levenshtein distance
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of single character edits needed to change a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// memoryTranslator serves exact matches of the translation memory and gives fuzzy matches to the
+// wrapped translator as references
+type memoryTranslator struct {
+	translator Translator
+	memory     *Memory
+	threshold  float64
+}
+
+// batchMemoryTranslator serves exact matches of the translation memory and gives fuzzy matches to
+// the wrapped batch translator as references
+type batchMemoryTranslator struct {
+	*memoryTranslator
+	batch BatchTranslator
+}
+
+// NewMemoryTranslator wraps the translator so that texts with an approved translation in the memory
+// are not translated again. Texts of plural forms are always translated, since the same text may need
+// different forms in the target language. If fuzzyThreshold is positive, up to three approved
+// translations of texts at least that similar are added to the requests as references.
+// The returned translator implements BatchTranslator if t does.
+func NewMemoryTranslator(t Translator, memory *Memory, fuzzyThreshold float64) Translator {
+	mt := &memoryTranslator{
+		translator: t,
+		memory:     memory,
+		threshold:  fuzzyThreshold,
+	}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchMemoryTranslator{
+			memoryTranslator: mt,
+			batch:            bt,
+		}
+	}
+
+	return mt
+}
+
+// Translate returns the approved translation of the text or translates it with the wrapped translator
+func (t *memoryTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	if translation, ok := t.lookup(ctx, req); ok {
+		return translation, nil
+	}
+
+	return t.translator.Translate(ctx, t.withReferences(req))
+}
+
+// TranslateBatch serves the approved translations and translates the rest with the wrapped translator
+func (t *batchMemoryTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	translations := make([]string, len(reqs))
+
+	var (
+		missing []int
+		misses  []Request
+	)
+
+	for i, req := range reqs {
+		if translation, ok := t.lookup(ctx, req); ok {
+			translations[i] = translation
+			continue
+		}
+
+		missing = append(missing, i)
+		misses = append(misses, t.withReferences(req))
+	}
+
+	if len(misses) == 0 {
+		return translations, nil
+	}
+
+	translated, err := t.batch.TranslateBatch(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, i := range missing {
+		if j < len(translated) {
+			translations[i] = translated[j]
+		}
+	}
+
+	return translations, nil
+}
+
+// lookup returns the exact match of the request, unless the context bypasses stored translations
+func (t *memoryTranslator) lookup(ctx context.Context, req Request) (string, bool) {
+	if req.PluralCategory != "" || cacheBypassed(ctx) {
+		return "", false
+	}
+
+	translation, ok := t.memory.Lookup(req.SourceLang, req.TargetLang, req.Text)
+	if ok {
+		slog.Debug("translation memory match", slog.String("text", req.Text))
+	}
+
+	return translation, ok
+}
+
+// withReferences adds the fuzzy matches of the text to the request
+func (t *memoryTranslator) withReferences(req Request) Request {
+	if t.threshold <= 0 {
+		return req
+	}
+
+	if refs := t.memory.Fuzzy(req.SourceLang, req.TargetLang, req.Text, t.threshold, maxReferences); len(refs) > 0 {
+		req.References = append(req.References[:len(req.References):len(req.References)], refs...)
+	}
+
+	return req
+}
@@ -0,0 +1,203 @@
+package translator_test
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const vendorTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="Vendor" creationtoolversion="2" segtype="sentence" o-tmf="vendor" adminlang="en" srclang="en-US" datatype="plaintext"/>
+  <body>
+    <tu>
+      <tuv xml:lang="en-US"><seg>Save changes</seg></tuv>
+      <tuv xml:lang="fr-FR"><seg>Enregistrer les modifications</seg></tuv>
+      <tuv xml:lang="de-DE"><seg>Änderungen speichern</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="en-US"><seg>Click <ph x="1">&lt;b&gt;</ph>here<ph x="2">&lt;/b&gt;</ph></seg></tuv>
+      <tuv xml:lang="fr-FR"><seg>Cliquez <ph x="1">&lt;b&gt;</ph>ici<ph x="2">&lt;/b&gt;</ph></seg></tuv>
+    </tu>
+    <tu srclang="fr-FR">
+      <tuv lang="fr-FR"><seg>Bonjour</seg></tuv>
+      <tuv lang="en-US"><seg>Hello</seg></tuv>
+    </tu>
+  </body>
+</tmx>
+`
+
+func TestMemory_Import(t *testing.T) {
+	mem := translator.NewMemory()
+
+	count, err := mem.Import(strings.NewReader(vendorTMX))
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	translation, ok := mem.Lookup("en-US", "fr-FR", "Save changes")
+	assert.True(t, ok)
+	assert.Equal(t, "Enregistrer les modifications", translation)
+
+	// Language codes are compared ignoring case and separators
+	translation, ok = mem.Lookup("en_us", "DE-de", "Save changes")
+	assert.True(t, ok)
+	assert.Equal(t, "Änderungen speichern", translation)
+
+	// Inline elements keep their native codes
+	translation, ok = mem.Lookup("en-US", "fr-FR", "Click <b>here</b>")
+	assert.True(t, ok)
+	assert.Equal(t, "Cliquez <b>ici</b>", translation)
+
+	// Units with their own source language and TMX 1.1 lang attributes
+	translation, ok = mem.Lookup("fr-FR", "en-US", "Bonjour")
+	assert.True(t, ok)
+	assert.Equal(t, "Hello", translation)
+
+	_, ok = mem.Lookup("en-US", "es-ES", "Save changes")
+	assert.False(t, ok)
+
+	_, err = mem.Import(strings.NewReader("<tmx version=\"1.4\"><body>"))
+	assert.Error(t, err)
+}
+
+func TestMemory_ExportImport(t *testing.T) {
+	mem := translator.NewMemory()
+	mem.Add("en-US", "fr-FR", "Hello & <welcome>", "Bonjour & <bienvenue>")
+	mem.Add("en-US", "de-DE", "Hello & <welcome>", "Hallo & <willkommen>")
+	mem.Add("en-US", "fr-FR", "Bye", "Salut")
+	mem.Add("en-US", "fr-FR", "Bye", "Au revoir")
+
+	var buf bytes.Buffer
+	require.NoError(t, mem.Export(&buf))
+	assert.Contains(t, buf.String(), `<tmx version="1.4">`)
+	assert.Contains(t, buf.String(), `srclang="en-US"`)
+	assert.Contains(t, buf.String(), `xml:lang="de-DE"`)
+
+	imported := translator.NewMemory()
+
+	count, err := imported.Import(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	translation, ok := imported.Lookup("en-US", "de-DE", "Hello & <welcome>")
+	assert.True(t, ok)
+	assert.Equal(t, "Hallo & <willkommen>", translation)
+
+	translation, ok = imported.Lookup("en-US", "fr-FR", "Bye")
+	assert.True(t, ok)
+	assert.Equal(t, "Au revoir", translation)
+}
+
+func TestMemory_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tm", "memory.tmx")
+
+	mem, err := translator.OpenMemory(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, mem.Len())
+
+	mem.Add("en-US", "fr-FR", "Hello", "Bonjour")
+	require.NoError(t, mem.Save())
+
+	reopened, err := translator.OpenMemory(path)
+	require.NoError(t, err)
+
+	translation, ok := reopened.Lookup("en-US", "fr-FR", "Hello")
+	assert.True(t, ok)
+	assert.Equal(t, "Bonjour", translation)
+}
+
+func TestMemory_Fuzzy(t *testing.T) {
+	mem := translator.NewMemory()
+	mem.Add("en-US", "fr-FR", "Delete the file", "Supprimer le fichier")
+	mem.Add("en-US", "fr-FR", "Delete the files", "Supprimer les fichiers")
+	mem.Add("en-US", "fr-FR", "Open settings", "Ouvrir les paramètres")
+	mem.Add("en-US", "de-DE", "Delete the folder", "Ordner löschen")
+
+	refs := mem.Fuzzy("en-US", "fr-FR", "Delete this file", 0.6, 3)
+	require.Len(t, refs, 2)
+	assert.Equal(t, "Delete the file", refs[0].Source)
+	assert.Equal(t, "Supprimer le fichier", refs[0].Translation)
+	assert.Greater(t, refs[0].Score, refs[1].Score)
+
+	assert.Len(t, mem.Fuzzy("en-US", "fr-FR", "Delete this file", 0.6, 1), 1)
+	assert.Empty(t, mem.Fuzzy("en-US", "fr-FR", "Delete this file", 0.95, 3))
+
+	// Exact matches are not references
+	refs = mem.Fuzzy("en-US", "fr-FR", "Delete the file", 0.6, 3)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "Delete the files", refs[0].Source)
+}
+
+func TestNewMemoryTranslator(t *testing.T) {
+	mem := translator.NewMemory()
+	mem.Add("en-US", "fr-FR", "Delete the file", "Supprimer le fichier")
+
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, mock.MatchedBy(func(req translator.Request) bool {
+		return req.Text == "Delete the files" && len(req.References) == 1 &&
+			req.References[0].Translation == "Supprimer le fichier"
+	})).Return("Supprimer les fichiers", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, mock.MatchedBy(func(req translator.Request) bool {
+		return req.Text == "Delete the file" && req.PluralCategory == "one"
+	})).Return("Supprimer le fichier", nil).Once()
+	mockTranslator.On("Translate", mock.Anything, mock.MatchedBy(func(req translator.Request) bool {
+		return req.Text == "Delete the file" && req.PluralCategory == ""
+	})).Return("Effacer le fichier", nil).Once()
+
+	trans := translator.NewMemoryTranslator(mockTranslator, mem, 0.8)
+
+	_, isBatch := trans.(translator.BatchTranslator)
+	assert.False(t, isBatch)
+
+	// Exact match
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Delete the file", SourceLang: "en-US", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Supprimer le fichier", result)
+
+	// Fuzzy match given as reference
+	result, err = trans.Translate(context.Background(), translator.Request{Text: "Delete the files", SourceLang: "en-US", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Supprimer les fichiers", result)
+
+	// Plural forms are always translated
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Delete the file", SourceLang: "en-US", TargetLang: "fr-FR", PluralCategory: "one"})
+	require.NoError(t, err)
+
+	// Bypassed memory
+	result, err = trans.Translate(translator.BypassCache(context.Background()), translator.Request{Text: "Delete the file", SourceLang: "en-US", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Effacer le fichier", result)
+
+	mockTranslator.AssertExpectations(t)
+}
+
+func TestNewMemoryTranslator_Batch(t *testing.T) {
+	mem := translator.NewMemory()
+	mem.Add("en-US", "fr-FR", "Hello", "Bonjour")
+
+	hello := translator.Request{Text: "Hello", SourceLang: "en-US", TargetLang: "fr-FR"}
+	bye := translator.Request{Text: "Bye", SourceLang: "en-US", TargetLang: "fr-FR"}
+
+	mockTranslator := new(mocks.BatchTranslator)
+	mockTranslator.On("TranslateBatch", mock.Anything, []translator.Request{bye}).
+		Return([]string{"Au revoir"}, nil).Once()
+
+	trans := translator.NewMemoryTranslator(mockTranslator, mem, 0)
+
+	batch, ok := trans.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{hello, bye})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "Au revoir"}, translations)
+
+	mockTranslator.AssertExpectations(t)
+}
@@ -5,6 +5,7 @@ package mocks
 import (
 	context "context"
 
+	translator "github.com/ksysoev/gotext-translator/pkg/translator"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -13,9 +14,9 @@ type Translator struct {
 	mock.Mock
 }
 
-// Translate provides a mock function with given fields: ctx, text, targetLang
-func (_m *Translator) Translate(ctx context.Context, text string, targetLang string) (string, error) {
-	ret := _m.Called(ctx, text, targetLang)
+// Translate provides a mock function with given fields: ctx, req
+func (_m *Translator) Translate(ctx context.Context, req translator.Request) (string, error) {
+	ret := _m.Called(ctx, req)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Translate")
@@ -23,17 +24,17 @@ func (_m *Translator) Translate(ctx context.Context, text string, targetLang str
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (string, error)); ok {
-		return rf(ctx, text, targetLang)
+	if rf, ok := ret.Get(0).(func(context.Context, translator.Request) (string, error)); ok {
+		return rf(ctx, req)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, text, targetLang)
+	if rf, ok := ret.Get(0).(func(context.Context, translator.Request) string); ok {
+		r0 = rf(ctx, req)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, text, targetLang)
+	if rf, ok := ret.Get(1).(func(context.Context, translator.Request) error); ok {
+		r1 = rf(ctx, req)
 	} else {
 		r1 = ret.Error(1)
 	}
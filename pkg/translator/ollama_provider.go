@@ -0,0 +1,232 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const ollamaBaseURL = "http://localhost:11434"
+
+// ollamaIntOptions and ollamaFloatOptions are the model options passed to Ollama from the provider config
+var (
+	ollamaIntOptions   = []string{"num_ctx", "num_predict", "num_gpu", "num_thread", "top_k", "seed", "repeat_last_n"}
+	ollamaFloatOptions = []string{"temperature", "top_p", "min_p", "repeat_penalty", "presence_penalty", "frequency_penalty"}
+)
+
+// OllamaProvider provides translation using a local Ollama server
+type OllamaProvider struct{}
+
+// GetName returns the name of the provider
+func (p *OllamaProvider) GetName() string {
+	return "ollama"
+}
+
+// CreateTranslator creates a translator instance. The API key is optional, it is only needed
+// for servers behind an authenticating proxy.
+func (p *OllamaProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	model, ok := config["model"].(string)
+	if !ok || model == "" {
+		return nil, fmt.Errorf("Ollama model is required")
+	}
+
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		baseURL = ollamaBaseURL
+	}
+
+	apiKey, _ := config["api_key"].(string)
+
+	options, err := newOllamaOptions(config)
+	if err != nil {
+		return nil, err
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OllamaTranslator{
+		apiKey:    apiKey,
+		model:     model,
+		url:       strings.TrimRight(baseURL, "/") + "/api/chat",
+		keepAlive: newOllamaKeepAlive(config["keep_alive"]),
+		options:   options,
+		retry:     retry,
+	}, nil
+}
+
+// newOllamaOptions collects the model options of the provider config, e.g. num_ctx
+func newOllamaOptions(config map[string]interface{}) (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+
+	for _, key := range ollamaIntOptions {
+		if v, ok := config[key]; ok {
+			n, err := toInt(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %v", key, v)
+			}
+
+			options[key] = n
+		}
+	}
+
+	for _, key := range ollamaFloatOptions {
+		if v, ok := config[key]; ok {
+			f, err := toFloat(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %v", key, v)
+			}
+
+			options[key] = f
+		}
+	}
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+
+	return options, nil
+}
+
+// newOllamaKeepAlive returns the keep_alive value of the request. Ollama accepts durations like "10m"
+// and numbers of seconds, where a negative number keeps the model loaded forever.
+func newOllamaKeepAlive(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	return s
+}
+
+// OllamaTranslator implements the Translator interface using the chat API of Ollama
+type OllamaTranslator struct {
+	apiKey    string
+	model     string
+	url       string
+	keepAlive interface{}
+	options   map[string]interface{}
+	retry     RetryPolicy
+}
+
+// OllamaRequest represents a request to the Ollama chat API
+type OllamaRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []OllamaMessage        `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	KeepAlive interface{}            `json:"keep_alive,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+}
+
+// OllamaMessage represents a chat message in the Ollama API
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OllamaResponse represents a response from the Ollama chat API
+type OllamaResponse struct {
+	Message OllamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Translate translates the text of the request to its target language
+func (t *OllamaTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	return t.Complete(ctx, systemPrompt, buildUserPrompt(req))
+}
+
+// TranslateBatch translates all requests in a single call
+func (t *OllamaTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateBatch(ctx, t, reqs)
+}
+
+// Complete returns the model response for the system and user prompts
+func (t *OllamaTranslator) Complete(ctx context.Context, system, user string) (string, error) {
+	requestBody := OllamaRequest{
+		Model: t.model,
+		Messages: []OllamaMessage{
+			{
+				Role:    "system",
+				Content: system,
+			},
+			{
+				Role:    "user",
+				Content: user,
+			},
+		},
+		KeepAlive: t.keepAlive,
+		Options:   t.options,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var translation string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translation, err = t.send(ctx, jsonData)
+		return err
+	})
+
+	return translation, err
+}
+
+// send sends a single request to the Ollama API and returns the content of the response
+func (t *OllamaTranslator) send(ctx context.Context, jsonData []byte) (string, error) {
+	client := &http.Client{}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	if t.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", newRequestError(ctx, "Ollama", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", newRequestError(ctx, "Ollama", err)
+	}
+
+	var response OllamaResponse
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError("Ollama", resp.StatusCode, resp.Header, response.Error)
+	}
+
+	if response.Error != "" {
+		return "", fmt.Errorf("Ollama API error: %s", response.Error)
+	}
+
+	if response.Message.Content == "" {
+		return "", fmt.Errorf("no translation returned from Ollama")
+	}
+
+	return response.Message.Content, nil
+}
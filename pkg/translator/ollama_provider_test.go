@@ -0,0 +1,132 @@
+package translator_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaProvider_GetName(t *testing.T) {
+	provider := &translator.OllamaProvider{}
+	assert.Equal(t, "ollama", provider.GetName())
+}
+
+func TestOllamaProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.OllamaProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{"model": "llama3.1"})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, isBatch := trans.(translator.BatchTranslator)
+	assert.True(t, isBatch)
+
+	// The model is required, there is no sensible default for local models
+	_, err = provider.CreateTranslator(map[string]interface{}{"base_url": "http://localhost:11434"})
+	assert.ErrorContains(t, err, "model is required")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"model": "llama3.1", "num_ctx": "large"})
+	assert.ErrorContains(t, err, "invalid num_ctx")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"model": "llama3.1", "temperature": "warm"})
+	assert.ErrorContains(t, err, "invalid temperature")
+}
+
+func TestOllamaTranslator_Translate(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK,
+		`{"model": "llama3.1", "message": {"role": "assistant", "content": "Bonjour"}, "done": true}`)
+
+	trans, err := (&translator.OllamaProvider{}).CreateTranslator(map[string]interface{}{
+		"model":       "llama3.1",
+		"base_url":    srv.URL + "/",
+		"keep_alive":  "10m",
+		"num_ctx":     "8192",
+		"temperature": "0.2",
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", SourceLang: "en-US", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	recorded := srv.Requests()
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "/api/chat", recorded[0].URL.Path)
+	assert.Equal(t, http.MethodPost, recorded[0].Method)
+
+	var req map[string]interface{}
+	recorded[0].decode(t, &req)
+	assert.Equal(t, "llama3.1", req["model"])
+	assert.Equal(t, false, req["stream"])
+	assert.Equal(t, "10m", req["keep_alive"])
+	assert.Equal(t, map[string]interface{}{"num_ctx": float64(8192), "temperature": 0.2}, req["options"])
+
+	messages, ok := req["messages"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "system", messages[0].(map[string]interface{})["role"])
+	assert.Contains(t, messages[1].(map[string]interface{})["content"], "Hello")
+}
+
+func TestOllamaTranslator_KeepAliveSeconds(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK, `{"message": {"role": "assistant", "content": "Bonjour"}, "done": true}`)
+
+	trans, err := (&translator.OllamaProvider{}).CreateTranslator(map[string]interface{}{
+		"model":      "llama3.1",
+		"base_url":   srv.URL,
+		"keep_alive": "-1",
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+
+	requests := decodeRequests[map[string]interface{}](t, srv.Requests())
+	require.Len(t, requests, 1)
+	assert.Equal(t, float64(-1), requests[0]["keep_alive"])
+	assert.NotContains(t, requests[0], "options")
+}
+
+func TestOllamaTranslator_TranslateBatch(t *testing.T) {
+	srv := newProviderServer(t, http.StatusOK,
+		`{"message": {"role": "assistant", "content": "{\"1\": \"Bonjour\", \"2\": \"Au revoir\"}"}, "done": true}`)
+
+	trans, err := (&translator.OllamaProvider{}).CreateTranslator(map[string]interface{}{"model": "llama3.1", "base_url": srv.URL})
+	require.NoError(t, err)
+
+	batch, ok := trans.(translator.BatchTranslator)
+	require.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{
+		{Text: "Hello", TargetLang: "fr-FR"},
+		{Text: "Bye", TargetLang: "fr-FR"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour", "Au revoir"}, translations)
+	assert.Len(t, srv.Requests(), 1)
+}
+
+func TestOllamaTranslator_ModelNotFound(t *testing.T) {
+	srv := newProviderServer(t, http.StatusNotFound, `{"error": "model \"llama9\" not found, try pulling it first"}`)
+
+	trans, err := (&translator.OllamaProvider{}).CreateTranslator(map[string]interface{}{
+		"model":            "llama9",
+		"base_url":         srv.URL,
+		"retry_base_delay": "1ms",
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try pulling it first")
+
+	var apiErr *translator.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.False(t, translator.IsRetryable(err))
+	assert.Len(t, srv.Requests(), 1)
+}
@@ -0,0 +1,53 @@
+package translator
+
+import (
+	"fmt"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// OpenAICompatibleProvider provides translation using any server implementing the chat completions
+// API of OpenAI, e.g. vLLM, LM Studio, LiteLLM or a corporate gateway
+type OpenAICompatibleProvider struct {
+	// HTTPClient sends the requests to the server, http.DefaultClient is used if nil. Proxies and TLS
+	// certificates are configured by options, set it for authentication that options cannot express.
+	// The ca_file, cert_file, key_file, proxy_url and insecure_skip_verify options are ignored then.
+	HTTPClient openai.HTTPDoer
+}
+
+// GetName returns the name of the provider
+func (p *OpenAICompatibleProvider) GetName() string {
+	return "openai-compatible"
+}
+
+// CreateTranslator creates a translator instance. The base URL and the model are required,
+// the API key is optional since many self-hosted servers don't check it.
+func (p *OpenAICompatibleProvider) CreateTranslator(config map[string]interface{}) (Translator, error) {
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		return nil, fmt.Errorf("base_url of the OpenAI compatible API is required")
+	}
+
+	model, ok := config["model"].(string)
+	if !ok || model == "" {
+		return nil, fmt.Errorf("model of the OpenAI compatible API is required")
+	}
+
+	apiKey, _ := config["api_key"].(string)
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := openai.DefaultConfig(apiKey)
+	if p.HTTPClient != nil {
+		cfg.HTTPClient = p.HTTPClient
+	}
+
+	if err := applyOpenAIOptions(&cfg, config); err != nil {
+		return nil, err
+	}
+
+	return newOpenAITranslator(cfg, model, retry), nil
+}
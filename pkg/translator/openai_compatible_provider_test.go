@@ -0,0 +1,227 @@
+package translator_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDoer records the requests sent by the OpenAI API client
+type recordingDoer struct {
+	requests []*http.Request
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests = append(d.requests, req)
+	return http.DefaultClient.Do(req)
+}
+
+// chatCompletionServer starts a stand-in chat completions server replying with the content
+func chatCompletionServer(t *testing.T, content string) *providerServer {
+	t.Helper()
+
+	return newProviderServer(t, http.StatusOK, `{"choices": [{"message": {"role": "assistant", "content": "`+content+`"}}]}`)
+}
+
+func TestOpenAICompatibleProvider_GetName(t *testing.T) {
+	provider := &translator.OpenAICompatibleProvider{}
+	assert.Equal(t, "openai-compatible", provider.GetName())
+}
+
+func TestOpenAICompatibleProvider_CreateTranslator(t *testing.T) {
+	provider := &translator.OpenAICompatibleProvider{}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{
+		"base_url": "http://localhost:8000/v1",
+		"model":    "mistral-7b",
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, trans)
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"model": "mistral-7b"})
+	assert.ErrorContains(t, err, "base_url")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{"base_url": "http://localhost:8000/v1"})
+	assert.ErrorContains(t, err, "model")
+
+	_, err = provider.CreateTranslator(map[string]interface{}{
+		"base_url": "http://localhost:8000/v1",
+		"model":    "mistral-7b",
+		"timeout":  "soon",
+	})
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestOpenAICompatibleTranslator_Translate(t *testing.T) {
+	srv := chatCompletionServer(t, "Bonjour")
+
+	trans, err := (&translator.OpenAICompatibleProvider{}).CreateTranslator(map[string]interface{}{
+		"base_url":           srv.URL + "/v1/",
+		"model":              "mistral-7b",
+		"api_key":            "gateway-key",
+		"organization":       "org-1",
+		"project":            "proj-1",
+		"header_x-team":      "i18n",
+		"header_x-cost-unit": "42",
+		"timeout":            "30s",
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/v1/chat/completions", requests[0].URL.Path)
+
+	h := requests[0].Header
+	assert.Equal(t, "Bearer gateway-key", h.Get("Authorization"))
+	assert.Equal(t, "org-1", h.Get("OpenAI-Organization"))
+	assert.Equal(t, "proj-1", h.Get("OpenAI-Project"))
+	assert.Equal(t, "i18n", h.Get("X-Team"))
+	assert.Equal(t, "42", h.Get("X-Cost-Unit"))
+}
+
+func TestOpenAICompatibleTranslator_WithoutAPIKey(t *testing.T) {
+	srv := chatCompletionServer(t, "Bonjour")
+
+	trans, err := (&translator.OpenAICompatibleProvider{}).CreateTranslator(map[string]interface{}{
+		"base_url": srv.URL + "/v1",
+		"model":    "local-model",
+	})
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.Empty(t, requests[0].Header.Get("Authorization"))
+}
+
+func TestOpenAICompatibleTranslator_CustomHTTPClient(t *testing.T) {
+	srv := chatCompletionServer(t, "Bonjour")
+
+	doer := &recordingDoer{}
+	provider := &translator.OpenAICompatibleProvider{HTTPClient: doer}
+
+	trans, err := provider.CreateTranslator(map[string]interface{}{
+		"base_url":      srv.URL + "/v1",
+		"model":         "local-model",
+		"header_x-team": "i18n",
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	require.Len(t, doer.requests, 1)
+	assert.Equal(t, "i18n", doer.requests[0].Header.Get("X-Team"))
+}
+
+func TestOpenAIProvider_BaseURL(t *testing.T) {
+	srv := chatCompletionServer(t, "Bonjour")
+
+	trans, err := (&translator.OpenAIProvider{}).CreateTranslator(map[string]interface{}{
+		"api_key":  "key",
+		"base_url": srv.URL + "/v1",
+		"project":  "proj-1",
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "proj-1", requests[0].Header.Get("OpenAI-Project"))
+}
+
+func TestOpenAICompatibleTranslator_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0644))
+
+	config := map[string]interface{}{
+		"base_url":     srv.URL + "/v1",
+		"model":        "local-model",
+		"max_attempts": 1,
+	}
+
+	// The certificate of the gateway is not trusted without the CA bundle
+	trans, err := (&translator.OpenAICompatibleProvider{}).CreateTranslator(config)
+	require.NoError(t, err)
+
+	_, err = trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	assert.Error(t, err)
+
+	config["ca_file"] = caFile
+
+	trans, err = (&translator.OpenAICompatibleProvider{}).CreateTranslator(config)
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+}
+
+func TestOpenAICompatibleTranslator_ProxyURL(t *testing.T) {
+	// The proxy answers the requests forwarded to it instead of the unreachable gateway
+	proxy := chatCompletionServer(t, "Bonjour")
+
+	trans, err := (&translator.OpenAICompatibleProvider{}).CreateTranslator(map[string]interface{}{
+		"base_url":  "http://llm-gateway.invalid/v1",
+		"model":     "local-model",
+		"proxy_url": proxy.URL,
+	})
+	require.NoError(t, err)
+
+	result, err := trans.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr-FR"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	requests := proxy.Requests()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "http://llm-gateway.invalid/v1/chat/completions", requests[0].URL.String())
+}
+
+func TestOpenAICompatibleProvider_InvalidTransportOptions(t *testing.T) {
+	provider := &translator.OpenAICompatibleProvider{}
+
+	tests := []struct {
+		option      string
+		value       string
+		errContains string
+	}{
+		{"ca_file", filepath.Join(t.TempDir(), "missing.pem"), "failed to read ca_file"},
+		{"cert_file", "client.pem", "cert_file and key_file of the client certificate are both required"},
+		{"proxy_url", "://proxy", "invalid proxy_url"},
+		{"insecure_skip_verify", "maybe", "invalid insecure_skip_verify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.option, func(t *testing.T) {
+			_, err := provider.CreateTranslator(map[string]interface{}{
+				"base_url": "http://localhost:8000/v1",
+				"model":    "mistral-7b",
+				tt.option:  tt.value,
+			})
+			assert.ErrorContains(t, err, tt.errContains)
+		})
+	}
+}
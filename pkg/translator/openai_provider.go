@@ -2,11 +2,25 @@ package translator
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 )
 
+// openAIHeaderPrefix is the prefix of the provider options holding extra request headers
+const openAIHeaderPrefix = "header_"
+
+// defaultOpenAIModel is the model used when none is configured
+const defaultOpenAIModel = "gpt-3.5-turbo"
+
 // OpenAIProvider provides translation using OpenAI
 type OpenAIProvider struct{}
 
@@ -24,41 +38,281 @@ func (p *OpenAIProvider) CreateTranslator(config map[string]interface{}) (Transl
 
 	model, ok := config["model"].(string)
 	if !ok || model == "" {
-		model = "gpt-3.5-turbo" // Default model
+		model = defaultOpenAIModel
 	}
 
-	client := openai.NewClient(apiKey)
-	return &OpenAITranslator{
-		client: client,
-		model:  model,
-	}, nil
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := openai.DefaultConfig(apiKey)
+	if err := applyOpenAIOptions(&cfg, config); err != nil {
+		return nil, err
+	}
+
+	return newOpenAITranslator(cfg, model, retry), nil
+}
+
+// applyOpenAIOptions applies the endpoint, organization, project, extra headers, TLS and proxy
+// settings and timeout of the provider config to the OpenAI API client configuration. Extra headers
+// are given as options prefixed with "header_", e.g. "header_x-gateway-key".
+func applyOpenAIOptions(cfg *openai.ClientConfig, config map[string]interface{}) error {
+	if baseURL, ok := config["base_url"].(string); ok && baseURL != "" {
+		cfg.BaseURL = strings.TrimRight(baseURL, "/")
+	}
+
+	if org, ok := config["organization"].(string); ok {
+		cfg.OrgID = org
+	}
+
+	headers := make(http.Header)
+
+	if project, ok := config["project"].(string); ok && project != "" {
+		headers.Set("OpenAI-Project", project)
+	}
+
+	for key, v := range config {
+		name, ok := strings.CutPrefix(key, openAIHeaderPrefix)
+		if !ok || name == "" {
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("invalid header %s value %v", name, v)
+		}
+
+		headers.Set(name, value)
+	}
+
+	transport, err := newHTTPTransport(config)
+	if err != nil {
+		return err
+	}
+
+	// Only the default client is changed, a custom client has its own transport
+	if client, ok := cfg.HTTPClient.(*http.Client); ok && transport != nil {
+		withTransport := *client
+		withTransport.Transport = transport
+		cfg.HTTPClient = &withTransport
+	}
+
+	if v, ok := config["timeout"]; ok {
+		timeout, err := toDuration(v)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("invalid timeout %v", v)
+		}
+
+		// Only the default client is changed, a custom client has its own timeout
+		if client, ok := cfg.HTTPClient.(*http.Client); ok {
+			withTimeout := *client
+			withTimeout.Timeout = timeout
+			cfg.HTTPClient = &withTimeout
+		}
+	}
+
+	if len(headers) > 0 {
+		cfg.HTTPClient = &headerDoer{doer: cfg.HTTPClient, headers: headers}
+	}
+
+	return nil
+}
+
+// newHTTPTransport returns the transport of the CA bundle "ca_file", the client certificate "cert_file"
+// with its key "key_file", the proxy "proxy_url" and "insecure_skip_verify" of the provider config, as
+// needed by corporate gateways. It returns nil if none of them is set.
+func newHTTPTransport(config map[string]interface{}) (*http.Transport, error) {
+	caFile, _ := config["ca_file"].(string)
+	certFile, _ := config["cert_file"].(string)
+	keyFile, _ := config["key_file"].(string)
+	proxyURL, _ := config["proxy_url"].(string)
+
+	insecure := false
+
+	if v, ok := config["insecure_skip_verify"]; ok {
+		var err error
+		if insecure, err = toBool(v); err != nil {
+			return nil, fmt.Errorf("invalid insecure_skip_verify %v", v)
+		}
+	}
+
+	if caFile == "" && certFile == "" && keyFile == "" && proxyURL == "" && !insecure {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecure,
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca_file %s", caFile)
+		}
+
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("cert_file and key_file of the client certificate are both required")
+		}
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if proxyURL != "" {
+		proxy, err := url.Parse(proxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy_url %s", proxyURL)
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return transport, nil
+}
+
+// headerDoer adds extra headers to the requests of the OpenAI API client
+type headerDoer struct {
+	doer    openai.HTTPDoer
+	headers http.Header
+}
+
+// Do sends the request with the extra headers
+func (d *headerDoer) Do(req *http.Request) (*http.Response, error) {
+	for name, values := range d.headers {
+		req.Header[name] = values
+	}
+
+	return d.doer.Do(req)
 }
 
 // OpenAITranslator implements the Translator interface using OpenAI
 type OpenAITranslator struct {
 	client *openai.Client
 	model  string
+	retry  RetryPolicy
 }
 
-// Translate translates text to the specified target language
-func (t *OpenAITranslator) Translate(ctx context.Context, text string, targetLang string) (string, error) {
-	resp, err := t.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model: t.model,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: "You are a professional translator. Your task is to translate text accurately while preserving all formatting, placeholders, and special characters.",
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: fmt.Sprintf("Translate the following text to %s. Preserve any formatting, placeholders, and special characters:\n\n%s", targetLang, text),
-				},
+// newOpenAITranslator creates a translator for the OpenAI API client configuration
+func newOpenAITranslator(cfg openai.ClientConfig, model string, retry RetryPolicy) *OpenAITranslator {
+	cfg.HTTPClient = &retryAfterDoer{doer: cfg.HTTPClient}
+
+	return &OpenAITranslator{
+		client: openai.NewClientWithConfig(cfg),
+		model:  model,
+		retry:  retry,
+	}
+}
+
+// retryAfterKey is the context key of the delay requested by a failed response of the OpenAI API
+type retryAfterKey struct{}
+
+// retryAfterDoer records the delay requested by failed responses of the OpenAI API,
+// since the client library does not expose response headers in its errors
+type retryAfterDoer struct {
+	doer openai.HTTPDoer
+}
+
+// Do sends the request and records the requested retry delay for failed responses
+func (d *retryAfterDoer) Do(req *http.Request) (*http.Response, error) {
+	resp, err := d.doer.Do(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	if retryAfter, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+		*retryAfter = parseRetryAfter(resp.Header)
+	}
+
+	return resp, nil
+}
+
+// newOpenAIError converts errors of the OpenAI client into APIError
+func newOpenAIError(ctx context.Context, err error, retryAfter time.Duration) error {
+	var status int
+
+	var message string
+
+	var apiErr *openai.APIError
+
+	var reqErr *openai.RequestError
+
+	switch {
+	case errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0:
+		status, message = apiErr.HTTPStatusCode, apiErr.Message
+	case errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0:
+		status, message = reqErr.HTTPStatusCode, reqErr.Error()
+	default:
+		return newRequestError(ctx, "OpenAI", err)
+	}
+
+	statusErr := newStatusError("OpenAI", status, nil, message)
+	statusErr.RetryAfter = retryAfter
+	statusErr.Err = err
+
+	return statusErr
+}
+
+// Translate translates the text of the request to its target language
+func (t *OpenAITranslator) Translate(ctx context.Context, req Request) (string, error) {
+	return t.Complete(ctx, systemPrompt, buildUserPrompt(req))
+}
+
+// TranslateBatch translates all requests in a single call
+func (t *OpenAITranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateBatch(ctx, t, reqs)
+}
+
+// Complete returns the model response for the system and user prompts
+func (t *OpenAITranslator) Complete(ctx context.Context, system, user string) (string, error) {
+	req := openai.ChatCompletionRequest{
+		Model: t.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: system,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: user,
 			},
-			Temperature: 0.3, // Lower temperature for more consistent translations
 		},
-	)
+		Temperature: 0.3, // Lower temperature for more consistent translations
+	}
+
+	var resp openai.ChatCompletionResponse
+
+	err := t.retry.Do(ctx, func(ctx context.Context) error {
+		var retryAfter time.Duration
+
+		var err error
+
+		resp, err = t.client.CreateChatCompletion(context.WithValue(ctx, retryAfterKey{}, &retryAfter), req)
+		if err != nil {
+			return newOpenAIError(ctx, err, retryAfter)
+		}
+
+		return nil
+	})
 
 	if err != nil {
 		return "", fmt.Errorf("failed to get translation from OpenAI: %w", err)
@@ -7,9 +7,14 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
-const openRouterBaseURL = "https://openrouter.ai/api/v1/chat/completions"
+const (
+	openRouterBaseURL = "https://openrouter.ai/api/v1"
+	// defaultOpenRouterModel is the model used when none is configured
+	defaultOpenRouterModel = "openai/gpt-3.5-turbo"
+)
 
 // OpenRouterProvider provides translation using OpenRouter
 type OpenRouterProvider struct{}
@@ -28,12 +33,24 @@ func (p *OpenRouterProvider) CreateTranslator(config map[string]interface{}) (Tr
 
 	model, ok := config["model"].(string)
 	if !ok || model == "" {
-		model = "openai/gpt-3.5-turbo" // Default model
+		model = defaultOpenRouterModel
+	}
+
+	baseURL, ok := config["base_url"].(string)
+	if !ok || baseURL == "" {
+		baseURL = openRouterBaseURL
+	}
+
+	retry, err := newRetryPolicy(config)
+	if err != nil {
+		return nil, err
 	}
 
 	return &OpenRouterTranslator{
 		apiKey: apiKey,
 		model:  model,
+		url:    strings.TrimRight(baseURL, "/") + "/chat/completions",
+		retry:  retry,
 	}, nil
 }
 
@@ -41,6 +58,8 @@ func (p *OpenRouterProvider) CreateTranslator(config map[string]interface{}) (Tr
 type OpenRouterTranslator struct {
 	apiKey string
 	model  string
+	url    string
+	retry  RetryPolicy
 }
 
 // OpenRouterRequest represents a request to OpenRouter API
@@ -63,24 +82,33 @@ type OpenRouterResponse struct {
 		} `json:"message"`
 	} `json:"choices"`
 	Error *struct {
+		Code    int    `json:"code"`
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
-// Translate translates text to the specified target language
-func (t *OpenRouterTranslator) Translate(ctx context.Context, text string, targetLang string) (string, error) {
-	client := &http.Client{}
+// Translate translates the text of the request to its target language
+func (t *OpenRouterTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	return t.Complete(ctx, systemPrompt, buildUserPrompt(req))
+}
+
+// TranslateBatch translates all requests in a single call
+func (t *OpenRouterTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	return translateBatch(ctx, t, reqs)
+}
 
+// Complete returns the model response for the system and user prompts
+func (t *OpenRouterTranslator) Complete(ctx context.Context, system, user string) (string, error) {
 	requestBody := OpenRouterRequest{
 		Model: t.model,
 		Messages: []OpenRouterMessage{
 			{
 				Role:    "system",
-				Content: "You are a professional translator. Your task is to translate text accurately while preserving all formatting, placeholders, and special characters.",
+				Content: system,
 			},
 			{
 				Role:    "user",
-				Content: fmt.Sprintf("Translate the following text to %s. Preserve any formatting, placeholders, and special characters:\n\n%s", targetLang, text),
+				Content: user,
 			},
 		},
 	}
@@ -90,33 +118,61 @@ func (t *OpenRouterTranslator) Translate(ctx context.Context, text string, targe
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", openRouterBaseURL, bytes.NewBuffer(jsonData))
+	var translation string
+
+	err = t.retry.Do(ctx, func(ctx context.Context) error {
+		translation, err = t.send(ctx, jsonData)
+		return err
+	})
+
+	return translation, err
+}
+
+// send sends a single request to the OpenRouter API and returns the content of the response
+func (t *OpenRouterTranslator) send(ctx context.Context, jsonData []byte) (string, error) {
+	client := &http.Client{}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+t.apiKey)
-	req.Header.Set("HTTP-Referer", "https://github.com/ksysoev/gotext-translator")
-	req.Header.Set("X-Title", "Gotext Translator")
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+t.apiKey)
+	httpReq.Header.Set("HTTP-Referer", "https://github.com/ksysoev/gotext-translator")
+	httpReq.Header.Set("X-Title", "Gotext Translator")
 
-	resp, err := client.Do(req)
+	resp, err := client.Do(httpReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", newRequestError(ctx, "OpenRouter", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+		return "", newRequestError(ctx, "OpenRouter", err)
 	}
 
 	var response OpenRouterResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil && resp.StatusCode == http.StatusOK {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	if resp.StatusCode != http.StatusOK {
+		var message string
+		if response.Error != nil {
+			message = response.Error.Message
+		}
+
+		return "", newStatusError("OpenRouter", resp.StatusCode, resp.Header, message)
+	}
+
+	// OpenRouter may report errors of the upstream provider in a successful response
 	if response.Error != nil {
+		if response.Error.Code >= 400 {
+			return "", newStatusError("OpenRouter", response.Error.Code, resp.Header, response.Error.Message)
+		}
+
 		return "", fmt.Errorf("OpenRouter API error: %s", response.Error.Message)
 	}
 
@@ -0,0 +1,111 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// promptVersion identifies the revision of the prompts. Bump it whenever the prompts change,
+// so that cached translations made with the previous prompts are not reused.
+const promptVersion = "5"
+
+const systemPrompt = "You are a professional translator of software user interfaces. Your task is to translate text accurately while preserving all formatting, placeholders, and special characters. Respond with the translated text only, without any explanations."
+
+// buildUserPrompt renders the translation request into a prompt for the model
+func buildUserPrompt(req Request) string {
+	var sb strings.Builder
+
+	if req.SourceLang != "" {
+		fmt.Fprintf(&sb, "Translate the following text from %s to %s.", req.SourceLang, req.TargetLang)
+	} else {
+		fmt.Fprintf(&sb, "Translate the following text to %s.", req.TargetLang)
+	}
+
+	sb.WriteString(" Preserve any formatting, placeholders, and special characters.\n")
+
+	if req.MessageID != "" && req.MessageID != req.Text {
+		fmt.Fprintf(&sb, "\nMessage ID: %s\n", req.MessageID)
+	}
+
+	if req.Comment != "" {
+		fmt.Fprintf(&sb, "\nNote from the developers: %s\n", req.Comment)
+	}
+
+	if tokenRe.MatchString(req.Text) {
+		sb.WriteString("\nThe text contains tokens like ⟦1⟧ that stand for HTML tags, links, emails and commands. Keep each of them exactly once and unchanged, next to the words they belong to.\n")
+	}
+
+	if len(req.Placeholders) > 0 {
+		sb.WriteString("\nThe text contains the following placeholders, keep each of them exactly once and unchanged:\n")
+
+		for _, ph := range req.Placeholders {
+			fmt.Fprintf(&sb, "- {%s}", ph.ID)
+
+			var details []string
+			if ph.String != "" {
+				details = append(details, "printf verb "+ph.String)
+			}
+
+			if ph.Type != "" {
+				details = append(details, "type "+ph.Type)
+			}
+
+			if ph.Expr != "" {
+				details = append(details, "value of "+ph.Expr)
+			}
+
+			if len(details) > 0 {
+				fmt.Fprintf(&sb, " (%s)", strings.Join(details, ", "))
+			}
+
+			sb.WriteString("\n")
+		}
+	}
+
+	if req.PluralCategory != "" {
+		fmt.Fprintf(&sb, "\nThe text is the %q plural form of the message. Use the grammatical form that %s requires for the %q plural category.\n",
+			req.PluralCategory, req.TargetLang, req.PluralCategory)
+	}
+
+	if len(req.Neighbours) > 0 {
+		sb.WriteString("\nSurrounding messages from the same file, given for context only (do not translate them):\n")
+
+		for _, n := range req.Neighbours {
+			if n.Translation != "" {
+				fmt.Fprintf(&sb, "- %q translated as %q\n", n.Text, n.Translation)
+			} else {
+				fmt.Fprintf(&sb, "- %q\n", n.Text)
+			}
+		}
+	}
+
+	if len(req.Glossary) > 0 {
+		sb.WriteString("\nGlossary terms used in the text, translate them exactly as given:\n")
+
+		for _, term := range req.Glossary {
+			if term.DoNotTranslate() {
+				fmt.Fprintf(&sb, "- %q must not be translated", term.Source)
+			} else {
+				fmt.Fprintf(&sb, "- %q translated as %q", term.Source, term.Target)
+			}
+
+			if term.Note != "" {
+				fmt.Fprintf(&sb, " (%s)", term.Note)
+			}
+
+			sb.WriteString("\n")
+		}
+	}
+
+	if len(req.References) > 0 {
+		sb.WriteString("\nSimilar texts with approved translations, follow their terminology and style where they fit:\n")
+
+		for _, ref := range req.References {
+			fmt.Fprintf(&sb, "- %q translated as %q\n", ref.Source, ref.Translation)
+		}
+	}
+
+	fmt.Fprintf(&sb, "\nText to translate:\n\n%s", req.Text)
+
+	return sb.String()
+}
@@ -0,0 +1,59 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildUserPrompt(t *testing.T) {
+	// Bare request
+	prompt := buildUserPrompt(Request{Text: "Unknown command", TargetLang: "ru-RU"})
+	assert.Contains(t, prompt, "Translate the following text to ru-RU.")
+	assert.Contains(t, prompt, "Text to translate:\n\nUnknown command")
+	assert.NotContains(t, prompt, "Message ID")
+
+	// Request with the full context
+	prompt = buildUserPrompt(Request{
+		Text:       "Please, provide no more than {MaxAllowedPhotos} photo(s)",
+		SourceLang: "en-GB",
+		TargetLang: "ru-RU",
+		MessageID:  "too-many-photos",
+		Comment:    "Shown when too many photos are attached",
+		Placeholders: []Placeholder{
+			{ID: "MaxAllowedPhotos", String: "%[1]d", Type: "int", Expr: "maxAllowedPhotos"},
+		},
+		PluralCategory: "few",
+		Neighbours: []ContextMessage{
+			{Text: "Please, provide at least one photo", Translation: "Пожалуйста, отправьте хотя бы одно фото"},
+			{Text: "Unknown command"},
+		},
+	})
+
+	assert.Contains(t, prompt, "Translate the following text from en-GB to ru-RU.")
+	assert.Contains(t, prompt, "Message ID: too-many-photos")
+	assert.Contains(t, prompt, "Note from the developers: Shown when too many photos are attached")
+	assert.Contains(t, prompt, "- {MaxAllowedPhotos} (printf verb %[1]d, type int, value of maxAllowedPhotos)")
+	assert.Contains(t, prompt, `"few" plural form`)
+	assert.Contains(t, prompt, `- "Please, provide at least one photo" translated as "Пожалуйста, отправьте хотя бы одно фото"`)
+	assert.Contains(t, prompt, `- "Unknown command"`)
+	assert.Contains(t, prompt, "Text to translate:\n\nPlease, provide no more than {MaxAllowedPhotos} photo(s)")
+}
+
+func TestBuildUserPrompt_ReferencesAndGlossary(t *testing.T) {
+	prompt := buildUserPrompt(Request{
+		Text:       "Start the Help My Pet Bot questionnaire",
+		TargetLang: "de-DE",
+		References: []Reference{
+			{Source: "Start the questionnaire", Translation: "Fragebogen starten", Score: 0.8},
+		},
+		Glossary: []Term{
+			{Source: "Help My Pet Bot", Target: "Help My Pet Bot", Note: "Product name"},
+			{Source: "questionnaire", Target: "Fragebogen"},
+		},
+	})
+
+	assert.Contains(t, prompt, `- "Start the questionnaire" translated as "Fragebogen starten"`)
+	assert.Contains(t, prompt, `- "Help My Pet Bot" must not be translated (Product name)`)
+	assert.Contains(t, prompt, `- "questionnaire" translated as "Fragebogen"`)
+}
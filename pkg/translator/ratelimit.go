@@ -0,0 +1,289 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimits are the budgets of calls to a provider
+type RateLimits struct {
+	// RequestsPerMinute is the maximum number of requests per minute, 0 means no limit
+	RequestsPerMinute int
+	// TokensPerMinute is the maximum number of estimated prompt and completion tokens per minute, 0 means no limit
+	TokensPerMinute int
+}
+
+// newRateLimits reads the rate limits from the "requests_per_minute" and "tokens_per_minute" of the
+// provider config, missing values mean no limit
+func newRateLimits(config map[string]interface{}) (RateLimits, error) {
+	var limits RateLimits
+
+	values := map[string]*int{
+		"requests_per_minute": &limits.RequestsPerMinute,
+		"tokens_per_minute":   &limits.TokensPerMinute,
+	}
+
+	for key, target := range values {
+		v, ok := config[key]
+		if !ok {
+			continue
+		}
+
+		n, err := toInt(v)
+		if err != nil || n < 0 {
+			return RateLimits{}, fmt.Errorf("invalid %s %v", key, v)
+		}
+
+		*target = n
+	}
+
+	return limits, nil
+}
+
+// budget is a token bucket refilled continuously at the rate of its per minute capacity
+type budget struct {
+	mu       sync.Mutex
+	capacity float64
+	perSec   float64
+	tokens   float64
+	last     time.Time
+}
+
+// newBudget creates a full budget of perMinute units, nil if perMinute is not positive
+func newBudget(perMinute int) *budget {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &budget{
+		capacity: float64(perMinute),
+		perSec:   float64(perMinute) / time.Minute.Seconds(),
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+// reserve takes n units from the budget and returns how long the caller has to wait until they are
+// available. Requests larger than the capacity take the whole capacity.
+func (b *budget) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.perSec)
+	b.last = now
+
+	b.tokens -= min(n, b.capacity)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.perSec * float64(time.Second))
+}
+
+// drain empties the budget, so that callers wait for it to refill
+func (b *budget) drain() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens, 0)
+	b.last = time.Now()
+}
+
+// cancel returns n units reserved by a caller that gave up waiting
+func (b *budget) cancel(n float64) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.capacity, b.tokens+min(n, b.capacity))
+}
+
+// wait takes n units from the budget, waiting until they are available or the context is done
+func (b *budget) wait(ctx context.Context, n float64) error {
+	if b == nil {
+		return nil
+	}
+
+	delay := b.reserve(n)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.cancel(n)
+		return ctx.Err()
+	}
+}
+
+// limiter takes requests and estimated tokens from the budgets of the rate limits
+type limiter struct {
+	requests *budget
+	tokens   *budget
+}
+
+// newLimiter creates the budgets of the limits, nil if no limit is set
+func newLimiter(limits RateLimits) *limiter {
+	if limits.RequestsPerMinute <= 0 && limits.TokensPerMinute <= 0 {
+		return nil
+	}
+
+	return &limiter{
+		requests: newBudget(limits.RequestsPerMinute),
+		tokens:   newBudget(limits.TokensPerMinute),
+	}
+}
+
+// rateLimitedTranslator keeps the calls to the wrapped translator within the rate limits
+type rateLimitedTranslator struct {
+	*limiter
+	translator Translator
+}
+
+// batchRateLimitedTranslator keeps the calls to the wrapped batch translator within the rate limits
+type batchRateLimitedTranslator struct {
+	*rateLimitedTranslator
+	batch BatchTranslator
+}
+
+// rateLimitedCompleter keeps the calls to the wrapped completer within the rate limits
+type rateLimitedCompleter struct {
+	*limiter
+	completer Completer
+}
+
+// NewRateLimit wraps the translator so that the calls stay within the request and estimated token
+// budgets per minute, no matter how many workers share it. If no limit is set t is returned as is.
+// The returned translator implements BatchTranslator if t does.
+func NewRateLimit(t Translator, limits RateLimits) Translator {
+	l := newLimiter(limits)
+	if l == nil {
+		return t
+	}
+
+	limited := &rateLimitedTranslator{limiter: l, translator: t}
+
+	if bt, ok := t.(BatchTranslator); ok {
+		return &batchRateLimitedTranslator{
+			rateLimitedTranslator: limited,
+			batch:                 bt,
+		}
+	}
+
+	return limited
+}
+
+// NewCompleterRateLimit wraps the completer of a judge so that the calls stay within the request and
+// estimated token budgets per minute. If no limit is set c is returned as is.
+func NewCompleterRateLimit(c Completer, limits RateLimits) Completer {
+	l := newLimiter(limits)
+	if l == nil {
+		return c
+	}
+
+	return &rateLimitedCompleter{limiter: l, completer: c}
+}
+
+// Translate translates the request once it fits the rate limits
+func (t *rateLimitedTranslator) Translate(ctx context.Context, req Request) (string, error) {
+	ctx, err := t.wait(ctx, estimateTokens(systemPrompt)+estimateRequestTokens(req))
+	if err != nil {
+		return "", err
+	}
+
+	return t.translator.Translate(ctx, req)
+}
+
+// TranslateBatch translates the requests once they fit the rate limits
+func (t *batchRateLimitedTranslator) TranslateBatch(ctx context.Context, reqs []Request) ([]string, error) {
+	tokens := estimateTokens(batchSystemPrompt)
+	for _, req := range reqs {
+		tokens += estimateRequestTokens(req)
+	}
+
+	ctx, err := t.wait(ctx, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.batch.TranslateBatch(ctx, reqs)
+}
+
+// Complete sends the prompts once they fit the rate limits. Only the tokens of the prompts are
+// counted, as the length of the response is not known in advance.
+func (c *rateLimitedCompleter) Complete(ctx context.Context, system, user string) (string, error) {
+	ctx, err := c.wait(ctx, estimateTokens(system)+estimateTokens(user))
+	if err != nil {
+		return "", err
+	}
+
+	return c.completer.Complete(ctx, system, user)
+}
+
+// wait takes a request and the estimated tokens from the budgets. The returned context makes the
+// retries of the request inside the provider take the same budgets.
+func (l *limiter) wait(ctx context.Context, tokens int) (context.Context, error) {
+	if err := l.take(ctx, tokens); err != nil {
+		return ctx, err
+	}
+
+	return withRetryBudget(ctx, &attemptBudget{limiter: l, estimate: tokens}), nil
+}
+
+// take takes a request and the estimated tokens from the budgets
+func (l *limiter) take(ctx context.Context, tokens int) error {
+	if err := l.requests.wait(ctx, 1); err != nil {
+		return err
+	}
+
+	if err := l.tokens.wait(ctx, float64(tokens)); err != nil {
+		l.requests.cancel(1)
+		return err
+	}
+
+	return nil
+}
+
+// attemptBudget takes the budgets of a request for each of its retried attempts
+type attemptBudget struct {
+	*limiter
+	// estimate is the estimated number of tokens of an attempt
+	estimate int
+}
+
+// failed empties the budgets when the API reports that its rate limits are exceeded, so that the other
+// workers sharing the limiter wait for the budgets to refill instead of sending more requests
+func (b *attemptBudget) failed(err error) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Kind == ErrorKindRateLimit {
+		b.requests.drain()
+		b.tokens.drain()
+	}
+}
+
+// wait takes the budgets of the next attempt
+func (b *attemptBudget) wait(ctx context.Context) error {
+	return b.take(ctx, b.estimate)
+}
+
+// estimateRequestTokens estimates the prompt and completion tokens used to translate the request
+// without the system prompt, assuming the translation is about as long as the text
+func estimateRequestTokens(req Request) int {
+	return estimateTokens(buildUserPrompt(req)) + estimateTokens(req.Text)
+}
@@ -0,0 +1,87 @@
+package translator_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ksysoev/gotext-translator/pkg/translator"
+	"github.com/ksysoev/gotext-translator/pkg/translator/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewRateLimit_NoLimits(t *testing.T) {
+	mockTranslator := new(mocks.Translator)
+
+	assert.Same(t, mockTranslator, translator.NewRateLimit(mockTranslator, translator.RateLimits{}))
+}
+
+func TestNewRateLimit_RequestsPerMinute(t *testing.T) {
+	mockTranslator := new(mocks.Translator)
+	mockTranslator.On("Translate", mock.Anything, mock.Anything).Return("Bonjour", nil).Once()
+
+	limited := translator.NewRateLimit(mockTranslator, translator.RateLimits{RequestsPerMinute: 1})
+
+	_, isBatch := limited.(translator.BatchTranslator)
+	assert.False(t, isBatch)
+
+	result, err := limited.Translate(context.Background(), translator.Request{Text: "Hello", TargetLang: "fr"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+
+	// The budget is spent, the next request has to wait for about a minute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = limited.Translate(ctx, translator.Request{Text: "Hello", TargetLang: "fr"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	mockTranslator.AssertExpectations(t)
+}
+
+func TestNewRateLimit_TokensPerMinute(t *testing.T) {
+	mockTranslator := new(mocks.BatchTranslator)
+	mockTranslator.On("TranslateBatch", mock.Anything, mock.Anything).Return([]string{"Bonjour"}, nil).Once()
+
+	limited := translator.NewRateLimit(mockTranslator, translator.RateLimits{TokensPerMinute: 1000})
+
+	batch, ok := limited.(translator.BatchTranslator)
+	assert.True(t, ok)
+
+	translations, err := batch.TranslateBatch(context.Background(), []translator.Request{{Text: "Hello", TargetLang: "fr"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bonjour"}, translations)
+
+	// A long text does not fit the remaining budget
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = batch.TranslateBatch(ctx, []translator.Request{{Text: strings.Repeat("Hello ", 500), TargetLang: "fr"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	mockTranslator.AssertExpectations(t)
+}
+
+func TestNewCompleterRateLimit(t *testing.T) {
+	mockCompleter := new(mocks.Completer)
+
+	assert.Same(t, mockCompleter, translator.NewCompleterRateLimit(mockCompleter, translator.RateLimits{}))
+
+	mockCompleter.On("Complete", mock.Anything, "system", "user").Return(`{"best": 1}`, nil).Once()
+
+	limited := translator.NewCompleterRateLimit(mockCompleter, translator.RateLimits{RequestsPerMinute: 1})
+
+	result, err := limited.Complete(context.Background(), "system", "user")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"best": 1}`, result)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = limited.Complete(ctx, "system", "user")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	mockCompleter.AssertExpectations(t)
+}
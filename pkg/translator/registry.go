@@ -2,12 +2,34 @@ package translator
 
 import "log/slog"
 
+// DefaultModel returns the model the provider uses when none is configured, empty if the provider
+// has no default model
+func DefaultModel(provider string) string {
+	switch provider {
+	case "openai":
+		return defaultOpenAIModel
+	case "anthropic":
+		return defaultAnthropicModel
+	case "openrouter":
+		return defaultOpenRouterModel
+	default:
+		return ""
+	}
+}
+
 // RegisterProviders registers all available providers with the factory
 func RegisterProviders(factory Factory) {
 	providers := []Provider{
 		&OpenAIProvider{},
+		&OpenAICompatibleProvider{},
+		&AzureOpenAIProvider{},
 		&OpenRouterProvider{},
 		&AnthropicProvider{},
+		&GeminiProvider{},
+		&OllamaProvider{},
+		&DeepLProvider{},
+		&LibreTranslateProvider{},
+		&ExecProvider{},
 		// Future providers to be added:
 		// &LangChainProvider{},
 	}
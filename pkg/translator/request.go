@@ -0,0 +1,56 @@
+package translator
+
+// Request describes a text to translate together with the context of the message it belongs to
+type Request struct {
+	// Text is the text to translate
+	Text string
+	// SourceLang is the language of the text, it may be empty if unknown
+	SourceLang string
+	// TargetLang is the language to translate the text to
+	TargetLang string
+	// MessageID is the identifier of the message in the gotext file
+	MessageID string
+	// Comment contains notes for translators about the message
+	Comment string
+	// Placeholders describes the placeholders used in the text
+	Placeholders []Placeholder
+	// PluralCategory is the CLDR plural category (e.g. one, few, many) the text is used for,
+	// it is empty for messages without plural forms
+	PluralCategory string
+	// Neighbours contains surrounding messages of the same file to give the translation context
+	Neighbours []ContextMessage
+	// References contains similar texts translated before, e.g. fuzzy matches of the translation memory
+	References []Reference
+	// Glossary contains the glossary terms appearing in the text with their mandated translations
+	Glossary []Term
+}
+
+// Placeholder describes a placeholder used in the text
+type Placeholder struct {
+	// ID is the name of the placeholder as referenced in the text, e.g. {Count}
+	ID string
+	// String is the printf verb the placeholder is substituted with, e.g. %[1]d
+	String string
+	// Type is the Go type of the substituted value
+	Type string
+	// Expr is the Go expression the value comes from
+	Expr string
+}
+
+// ContextMessage is a message given to the translator as context only
+type ContextMessage struct {
+	// Text is the source text of the message
+	Text string
+	// Translation is the existing translation of the message, if any
+	Translation string
+}
+
+// Reference is a similar text and its approved translation given to the translator as an example
+type Reference struct {
+	// Source is the similar source text
+	Source string
+	// Translation is the approved translation of the source text
+	Translation string
+	// Score is the similarity of the source text to the text to translate, from 0 to 1
+	Score float64
+}
@@ -0,0 +1,384 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorKind classifies errors returned by provider APIs
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown is an error that could not be classified
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindRateLimit is returned when the rate limits of the API are exceeded
+	ErrorKindRateLimit
+	// ErrorKindOverloaded is returned when the API is temporarily overloaded
+	ErrorKindOverloaded
+	// ErrorKindServer is an internal error of the API
+	ErrorKindServer
+	// ErrorKindNetwork is a failure to reach the API
+	ErrorKindNetwork
+	// ErrorKindAuth is returned for missing or invalid credentials
+	ErrorKindAuth
+	// ErrorKindBadRequest is returned for requests the API refuses to process
+	ErrorKindBadRequest
+)
+
+// String returns the name of the error kind
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindRateLimit:
+		return "rate limit"
+	case ErrorKindOverloaded:
+		return "overloaded"
+	case ErrorKindServer:
+		return "server"
+	case ErrorKindNetwork:
+		return "network"
+	case ErrorKindAuth:
+		return "auth"
+	case ErrorKindBadRequest:
+		return "bad request"
+	default:
+		return "unknown"
+	}
+}
+
+// APIError is returned by providers when a call to their API fails
+type APIError struct {
+	// Provider is the name of the provider that returned the error
+	Provider string
+	// Kind is the classification of the error
+	Kind ErrorKind
+	// StatusCode is the HTTP status code of the response, 0 if there was no response
+	StatusCode int
+	// Message is the error message returned by the API
+	Message string
+	// RetryAfter is the delay requested by the API before retrying, 0 if not specified
+	RetryAfter time.Duration
+	// Err is the underlying error, if any
+	Err error
+}
+
+// Error returns a human readable description of the error
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s API error (%s, status %d): %s", e.Provider, e.Kind, e.StatusCode, msg)
+	}
+
+	return fmt.Sprintf("%s API error (%s): %s", e.Provider, e.Kind, msg)
+}
+
+// Unwrap returns the underlying error
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *APIError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimit, ErrorKindOverloaded, ErrorKindServer, ErrorKindNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRetryable reports whether err is an APIError that may succeed if the request is sent again
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// classifyStatus returns the error kind for an HTTP status code of a failed request
+func classifyStatus(status int) ErrorKind {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorKindRateLimit
+	case status == http.StatusServiceUnavailable || status == 529: // 529 is used by Anthropic for overloaded
+		return ErrorKindOverloaded
+	case status >= 500:
+		return ErrorKindServer
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorKindAuth
+	case status == http.StatusRequestTimeout:
+		return ErrorKindNetwork
+	case status >= 400:
+		return ErrorKindBadRequest
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+// newStatusError creates an APIError for a response with a failure status code
+func newStatusError(provider string, status int, header http.Header, message string) *APIError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	return &APIError{
+		Provider:   provider,
+		Kind:       classifyStatus(status),
+		StatusCode: status,
+		Message:    message,
+		RetryAfter: parseRetryAfter(header),
+	}
+}
+
+// newRequestError wraps an error of sending the request. Errors caused by the context
+// being done are returned as is, since retrying them is pointless.
+func newRequestError(ctx context.Context, provider string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return &APIError{
+		Provider: provider,
+		Kind:     ErrorKindNetwork,
+		Message:  "failed to send request",
+		Err:      err,
+	}
+}
+
+// parseRetryAfter returns the delay requested by the retry-after-ms or Retry-After headers
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+
+	if ms := header.Get("retry-after-ms"); ms != "" {
+		if v, err := strconv.ParseFloat(ms, 64); err == nil && v > 0 {
+			return time.Duration(v * float64(time.Millisecond))
+		}
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// RetryPolicy defines how failed API calls are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it doubles with every attempt
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between attempts
+	MaxDelay time.Duration
+	// MaxElapsed is the maximum total time spent on a call, 0 means no limit
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by providers unless configured otherwise
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    time.Minute,
+		MaxElapsed:  5 * time.Minute,
+	}
+}
+
+// newRetryPolicy creates the retry policy from the provider config, using the defaults
+// for the missing values. Durations are given in Go duration format, e.g. "500ms".
+func newRetryPolicy(config map[string]interface{}) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+
+	if v, ok := config["max_attempts"]; ok {
+		n, err := toInt(v)
+		if err != nil || n < 1 {
+			return RetryPolicy{}, fmt.Errorf("invalid max_attempts %v", v)
+		}
+
+		policy.MaxAttempts = n
+	}
+
+	durations := map[string]*time.Duration{
+		"retry_base_delay":  &policy.BaseDelay,
+		"retry_max_delay":   &policy.MaxDelay,
+		"retry_max_elapsed": &policy.MaxElapsed,
+	}
+
+	for key, target := range durations {
+		v, ok := config[key]
+		if !ok {
+			continue
+		}
+
+		d, err := toDuration(v)
+		if err != nil || d < 0 {
+			return RetryPolicy{}, fmt.Errorf("invalid %s %v", key, v)
+		}
+
+		*target = d
+	}
+
+	return policy, nil
+}
+
+// retryBudgetKey is the context key of the budget taken by the attempts of a request
+type retryBudgetKey struct{}
+
+// retryBudget is the rate limit budget of the retried attempts of a request, set in the context by
+// the rate limit wrappers so that retries inside the providers are limited like first attempts
+type retryBudget interface {
+	// failed is called with the error of an attempt before the delay of the next one
+	failed(err error)
+	// wait takes the budget of the next attempt, waiting until it is available or the context is done
+	wait(ctx context.Context) error
+}
+
+// withRetryBudget returns a context whose retried attempts take the budget
+func withRetryBudget(ctx context.Context, budget retryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or the policy limits are
+// reached. Delays between attempts grow exponentially with full jitter, unless the API requested
+// a specific delay with the Retry-After header. If the context is done while waiting for the next
+// attempt, the error of the context is returned with the last error in its message.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+
+	budget, _ := ctx.Value(retryBudgetKey{}).(retryBudget)
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsRetryable(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		delay := p.delay(attempt, err)
+
+		if p.MaxElapsed > 0 && time.Since(start)+delay > p.MaxElapsed {
+			return err
+		}
+
+		slog.Warn("retrying request",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()))
+
+		if budget != nil {
+			budget.failed(err)
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w while waiting to retry: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		// Every attempt is sent to the API, so every attempt takes the rate limit budget
+		if budget != nil {
+			if waitErr := budget.wait(ctx); waitErr != nil {
+				return fmt.Errorf("%w while waiting to retry: %v", waitErr, err)
+			}
+		}
+	}
+}
+
+// delay returns the time to wait after the failed attempt
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	return rand.N(backoff) + 1
+}
+
+// toInt converts a config value given as a number or a string to int
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	case string:
+		return strconv.Atoi(n)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// toFloat converts a config value given as a number or a string to float64
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// toBool converts a config value given as a bool or a string to bool
+func toBool(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		return strconv.ParseBool(b)
+	default:
+		return false, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// toDuration converts a config value given as a duration or a string to time.Duration
+func toDuration(v interface{}) (time.Duration, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		return time.ParseDuration(d)
+	default:
+		return 0, fmt.Errorf("unsupported type %T", v)
+	}
+}
@@ -0,0 +1,283 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		kind      ErrorKind
+		retryable bool
+	}{
+		{http.StatusTooManyRequests, ErrorKindRateLimit, true},
+		{http.StatusServiceUnavailable, ErrorKindOverloaded, true},
+		{529, ErrorKindOverloaded, true},
+		{http.StatusInternalServerError, ErrorKindServer, true},
+		{http.StatusRequestTimeout, ErrorKindNetwork, true},
+		{http.StatusUnauthorized, ErrorKindAuth, false},
+		{http.StatusForbidden, ErrorKindAuth, false},
+		{http.StatusBadRequest, ErrorKindBadRequest, false},
+	}
+
+	for _, tt := range tests {
+		err := newStatusError("Test", tt.status, nil, "")
+		assert.Equal(t, tt.kind, err.Kind, "status %d", tt.status)
+		assert.Equal(t, tt.retryable, IsRetryable(err), "status %d", tt.status)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), parseRetryAfter(nil))
+	assert.Equal(t, 2*time.Second, parseRetryAfter(http.Header{"Retry-After": []string{"2"}}))
+	assert.Equal(t, 1500*time.Millisecond, parseRetryAfter(http.Header{"Retry-After-Ms": []string{"1500"}, "Retry-After": []string{"2"}}))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(http.Header{"Retry-After": []string{"soon"}}))
+
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	d := parseRetryAfter(http.Header{"Retry-After": []string{date}})
+	assert.Greater(t, d, 50*time.Second)
+	assert.LessOrEqual(t, d, time.Minute)
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := newRetryPolicy(map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetryPolicy(), policy)
+
+	policy, err = newRetryPolicy(map[string]interface{}{
+		"max_attempts":      "2",
+		"retry_base_delay":  "10ms",
+		"retry_max_delay":   "1s",
+		"retry_max_elapsed": "0s",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, RetryPolicy{MaxAttempts: 2, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}, policy)
+
+	_, err = newRetryPolicy(map[string]interface{}{"max_attempts": "0"})
+	assert.Error(t, err)
+
+	_, err = newRetryPolicy(map[string]interface{}{"retry_base_delay": "fast"})
+	assert.Error(t, err)
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := testRetryPolicy()
+
+	// Retryable errors are retried until success
+	calls := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return newStatusError("Test", http.StatusTooManyRequests, nil, "")
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// Attempts are limited
+	calls = 0
+	err = policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		return newStatusError("Test", http.StatusInternalServerError, nil, "")
+	})
+	assert.True(t, IsRetryable(err))
+	assert.Equal(t, 3, calls)
+
+	// Other errors are returned immediately
+	calls = 0
+	err = policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		return newStatusError("Test", http.StatusUnauthorized, nil, "")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// The requested delay is bounded by the elapsed time limit
+	policy.MaxElapsed = 10 * time.Millisecond
+	calls = 0
+	err = policy.Do(context.Background(), func(context.Context) error {
+		calls++
+		return newStatusError("Test", http.StatusTooManyRequests, http.Header{"Retry-After": []string{"60"}}, "")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPolicy_Do_ContextCanceled(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	calls := 0
+	err := policy.Do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+
+		return newStatusError("Test", http.StatusServiceUnavailable, nil, "")
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "Service Unavailable")
+	assert.False(t, IsRetryable(err))
+	assert.Equal(t, 1, calls)
+}
+
+// flakyServer responds with the given failure status to the first failures requests
+// and with the successful response afterwards
+func flakyServer(t *testing.T, failures int, status int, header http.Header, response string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if int(calls.Add(1)) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error": {"message": "try again later", "type": "error"}}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &calls
+}
+
+func TestAnthropicTranslator_Retry(t *testing.T) {
+	srv, calls := flakyServer(t, 1, 529, http.Header{"Retry-After-Ms": []string{"1"}},
+		`{"content": [{"type": "text", "text": "Bonjour"}]}`)
+
+	trans := &AnthropicTranslator{apiKey: "key", model: "model", url: srv.URL, retry: testRetryPolicy()}
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestOpenRouterTranslator_Retry(t *testing.T) {
+	srv, calls := flakyServer(t, 2, http.StatusTooManyRequests, nil,
+		`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`)
+
+	trans := &OpenRouterTranslator{apiKey: "key", model: "model", url: srv.URL, retry: testRetryPolicy()}
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestOpenRouterTranslator_NoRetryOnAuthError(t *testing.T) {
+	srv, calls := flakyServer(t, 5, http.StatusUnauthorized, nil, "")
+
+	trans := &OpenRouterTranslator{apiKey: "key", model: "model", url: srv.URL, retry: testRetryPolicy()}
+
+	_, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorKindAuth, apiErr.Kind)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestOpenAITranslator_Retry(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0.001"}},
+		`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`)
+
+	cfg := openai.DefaultConfig("key")
+	cfg.BaseURL = srv.URL
+
+	trans := newOpenAITranslator(cfg, "model", testRetryPolicy())
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNewOpenAIError(t *testing.T) {
+	err := newOpenAIError(context.Background(), &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Message: "slow down"}, time.Second)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorKindRateLimit, apiErr.Kind)
+	assert.Equal(t, time.Second, apiErr.RetryAfter)
+	assert.Equal(t, "slow down", apiErr.Message)
+
+	err = newOpenAIError(context.Background(), errors.New("connection refused"), 0)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorKindNetwork, apiErr.Kind)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = newOpenAIError(ctx, errors.New("connection refused"), 0)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRateLimit_RetriesTakeBudget(t *testing.T) {
+	srv, calls := flakyServer(t, 1, 529, http.Header{"Retry-After-Ms": []string{"1"}},
+		`{"content": [{"type": "text", "text": "Bonjour"}]}`)
+
+	trans := NewRateLimit(&AnthropicTranslator{apiKey: "key", model: "model", url: srv.URL, retry: testRetryPolicy()},
+		RateLimits{RequestsPerMinute: 2})
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// The retried request took the budget of both attempts
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = trans.Translate(ctx, Request{Text: "Hello", TargetLang: "fr"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRateLimit_RateLimitedRetryDrainsBudget(t *testing.T) {
+	srv, calls := flakyServer(t, 1, http.StatusTooManyRequests, nil,
+		`{"choices": [{"message": {"role": "assistant", "content": "Bonjour"}}]}`)
+
+	// A budget of 600 requests per minute refills a request every 100ms
+	trans := NewRateLimit(&OpenRouterTranslator{apiKey: "key", model: "model", url: srv.URL, retry: testRetryPolicy()},
+		RateLimits{RequestsPerMinute: 600})
+
+	start := time.Now()
+
+	result, err := trans.Translate(context.Background(), Request{Text: "Hello", TargetLang: "fr"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bonjour", result)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// The rate limit error emptied the budget, so the retry waited for it to refill
+	assert.GreaterOrEqual(t, time.Since(start), 80*time.Millisecond)
+}
@@ -0,0 +1,99 @@
+package translator_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is a request received by a stand-in provider server
+type recordedRequest struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// providerServer is a stand-in provider API. The requests are recorded behind a mutex, as they are
+// handled on the goroutines of the server.
+type providerServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// newProviderServer starts a stand-in provider API that replies to every request with the status and response
+func newProviderServer(t *testing.T, status int, response string) *providerServer {
+	t.Helper()
+
+	return newProviderServerFunc(t, func(recordedRequest) (int, string) { return status, response })
+}
+
+// newProviderServerFunc starts a stand-in provider API that replies with the status and response returned
+// by reply for the request
+func newProviderServerFunc(t *testing.T, reply func(r recordedRequest) (int, string)) *providerServer {
+	t.Helper()
+
+	s := &providerServer{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		req := recordedRequest{
+			Method: r.Method,
+			URL:    r.URL,
+			Header: r.Header.Clone(),
+			Body:   bytes.Clone(body),
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+
+		status, response := reply(req)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Requests returns a copy of the requests received so far
+func (s *providerServer) Requests() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+// decode unmarshals the JSON body of the request into v
+func (r recordedRequest) decode(t *testing.T, v interface{}) {
+	t.Helper()
+
+	require.NoError(t, json.Unmarshal(r.Body, v))
+}
+
+// decodeRequests unmarshals the JSON bodies of the requests
+func decodeRequests[T any](t *testing.T, requests []recordedRequest) []T {
+	t.Helper()
+
+	decoded := make([]T, len(requests))
+	for i, r := range requests {
+		r.decode(t, &decoded[i])
+	}
+
+	return decoded
+}
@@ -0,0 +1,204 @@
+package translator
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	tmxVersion = "1.4"
+	// tmxAllLanguages is the srclang of TMX headers whose units use different source languages
+	tmxAllLanguages = "*all*"
+)
+
+// tmxDocument is the root element of a TMX 1.4 document
+type tmxDocument struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+// tmxHeader is the header of a TMX document
+type tmxHeader struct {
+	CreationTool        string `xml:"creationtool,attr"`
+	CreationToolVersion string `xml:"creationtoolversion,attr"`
+	SegType             string `xml:"segtype,attr"`
+	OTMF                string `xml:"o-tmf,attr"`
+	AdminLang           string `xml:"adminlang,attr"`
+	SrcLang             string `xml:"srclang,attr"`
+	DataType            string `xml:"datatype,attr"`
+}
+
+// tmxUnit is a translation unit with the variants of a text in several languages
+type tmxUnit struct {
+	SrcLang  string       `xml:"srclang,attr,omitempty"`
+	Variants []tmxVariant `xml:"tuv"`
+}
+
+// tmxVariant is the text of a translation unit in a language. TMX 1.1 used lang instead of xml:lang,
+// it is still accepted when reading.
+type tmxVariant struct {
+	Lang       string     `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	LegacyLang string     `xml:"lang,attr,omitempty"`
+	Seg        tmxSegment `xml:"seg"`
+}
+
+// tmxSegment is the text of a variant. Inline elements (bpt, ept, ph, it, hi) are flattened when
+// reading, keeping the native codes they contain.
+type tmxSegment string
+
+// UnmarshalXML collects the character data of the segment and of all nested elements except sub,
+// which holds translatable text that is not part of the segment itself
+func (s *tmxSegment) UnmarshalXML(d *xml.Decoder, _ xml.StartElement) error {
+	var (
+		sb       strings.Builder
+		depth    int
+		subDepth int
+	)
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+
+			if subDepth == 0 && t.Name.Local == "sub" {
+				subDepth = depth
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				*s = tmxSegment(sb.String())
+				return nil
+			}
+
+			if subDepth == depth {
+				subDepth = 0
+			}
+
+			depth--
+		case xml.CharData:
+			if subDepth == 0 {
+				sb.Write(t)
+			}
+		}
+	}
+}
+
+// lang returns the language of the variant
+func (v tmxVariant) lang() string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+
+	return v.LegacyLang
+}
+
+// readTMX parses a TMX document into memory units. The source of a unit is its variant in the
+// source language of the unit or, if not set, of the header. Units of headers with "*all*" source
+// language and without their own are read in every direction.
+func readTMX(r io.Reader) ([]memoryUnit, error) {
+	var doc tmxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse TMX: %w", err)
+	}
+
+	if doc.Version != "" && !strings.HasPrefix(doc.Version, "1.") {
+		return nil, fmt.Errorf("unsupported TMX version %s", doc.Version)
+	}
+
+	var units []memoryUnit
+
+	for _, tu := range doc.Units {
+		srcLang := tu.SrcLang
+		if srcLang == "" {
+			srcLang = doc.Header.SrcLang
+		}
+
+		for _, src := range tu.Variants {
+			if srcLang != tmxAllLanguages && !sameLang(src.lang(), srcLang) {
+				continue
+			}
+
+			unit := memoryUnit{
+				SourceLang: src.lang(),
+				Source:     string(src.Seg),
+			}
+
+			for _, v := range tu.Variants {
+				if !sameLang(v.lang(), src.lang()) && v.Seg != "" {
+					unit.Variants = append(unit.Variants, memoryVariant{Lang: v.lang(), Text: string(v.Seg)})
+				}
+			}
+
+			if unit.Source != "" && len(unit.Variants) > 0 {
+				units = append(units, unit)
+			}
+		}
+	}
+
+	if len(units) == 0 && len(doc.Units) > 0 {
+		return nil, errors.New("no translation units with source and target texts in TMX")
+	}
+
+	return units, nil
+}
+
+// writeTMX writes the memory units as a TMX 1.4 document
+func writeTMX(w io.Writer, units []memoryUnit) error {
+	doc := tmxDocument{
+		Version: tmxVersion,
+		Header: tmxHeader{
+			CreationTool:        "gotext-translator",
+			CreationToolVersion: "1",
+			SegType:             "sentence",
+			OTMF:                "gotext-translator",
+			AdminLang:           "en",
+			SrcLang:             tmxAllLanguages,
+			DataType:            "plaintext",
+		},
+	}
+
+	for i, unit := range units {
+		if i == 0 {
+			doc.Header.SrcLang = unit.SourceLang
+		} else if !sameLang(doc.Header.SrcLang, unit.SourceLang) {
+			doc.Header.SrcLang = tmxAllLanguages
+		}
+
+		tu := tmxUnit{
+			SrcLang:  unit.SourceLang,
+			Variants: []tmxVariant{{Lang: unit.SourceLang, Seg: tmxSegment(unit.Source)}},
+		}
+
+		for _, v := range unit.Variants {
+			tu.Variants = append(tu.Variants, tmxVariant{Lang: v.Lang, Seg: tmxSegment(v.Text)})
+		}
+
+		doc.Units = append(doc.Units, tu)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write TMX: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write TMX: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write TMX: %w", err)
+	}
+
+	return nil
+}